@@ -0,0 +1,16 @@
+package applemusic
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel/cmd/applemusic/seeder"
+)
+
+var Commands = []*cli.Command{
+	{
+		Name:        "seeder",
+		Usage:       "Apple Music play activity data seeder",
+		Description: "Seed your Apple Music play activity in the local database, next to your other services",
+		Commands:    seeder.Commands,
+	},
+}
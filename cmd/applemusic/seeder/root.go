@@ -0,0 +1,13 @@
+package seeder
+
+import "github.com/urfave/cli/v3"
+
+var Commands = []*cli.Command{
+	{
+		Name:        "run",
+		Usage:       "Run Apple Music play activity data seeder",
+		Description: "Reads Apple Music Play Activity.csv from Apple's privacy data export and seeds its plays into the SQLite database",
+		Action:      runAction,
+		Flags:       runFlags,
+	},
+}
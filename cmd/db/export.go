@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel/internal/dbexport"
+	"github.com/cadoween/decibel/internal/query"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+var exportFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "db",
+		Usage:    "Path to the SQLite database file",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:     "out",
+		Usage:    "Directory to write a file per table to, created if missing",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:  "format",
+		Usage: "Format of the files (csv or parquet)",
+		Value: string(dbexport.FormatCSV),
+	},
+
+	&cli.StringSliceFlag{
+		Name:  "table",
+		Usage: "Only export the given table, can be repeated",
+	},
+
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
+
+func exportAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+	dbPath := c.String("db")
+	outDir := c.String("out")
+
+	if c.Bool("verbose") {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	} else {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	}
+
+	format, err := dbexport.ParseFormat(c.String("format"))
+	if err != nil {
+		return fmt.Errorf("dbexport.ParseFormat: %w", err)
+	}
+
+	logger.Debug().
+		Str("db_path", dbPath).
+		Str("out", outDir).
+		Msg("Exporting database tables")
+
+	db, err := query.Open(ctx, dbPath)
+	if err != nil {
+		return fmt.Errorf("query.Open: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	tables, err := dbexport.Tables(ctx, db, c.StringSlice("table"))
+	if err != nil {
+		return fmt.Errorf("dbexport.Tables: %w", err)
+	}
+
+	if err := os.MkdirAll(outDir, 0o750); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	for _, table := range tables {
+		path := filepath.Join(outDir, table+format.Extension())
+
+		count, err := exportTable(ctx, db, format, table, path)
+		if err != nil {
+			return fmt.Errorf("exportTable %s: %w", table, err)
+		}
+
+		logger.Info().
+			Str("table", table).
+			Int64("rows", count).
+			Str("file", path).
+			Msg("Exported table")
+	}
+
+	logger.Info().
+		Int("tables", len(tables)).
+		Str("out", outDir).
+		Msg("Successfully exported database")
+
+	return nil
+}
+
+func exportTable(ctx context.Context, db query.DB, format dbexport.Format, table, path string) (int64, error) {
+	f, err := os.Create(path) //nolint:gosec // The directory is given by the user on purpose.
+	if err != nil {
+		return 0, fmt.Errorf("os.Create: %w", err)
+	}
+	defer iox.Close(f, zerolog.Ctx(ctx))
+
+	count, err := format.Write(ctx, db, table, f)
+	if err != nil {
+		return 0, fmt.Errorf("format.Write: %w", err)
+	}
+
+	return count, nil
+}
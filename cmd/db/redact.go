@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel/internal/database"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/iox"
+	"github.com/cadoween/decibel/pkg/redact"
+)
+
+var redactFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "db",
+		Usage:    "Path to the SQLite database file",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:  "ip",
+		Usage: "How to redact IP addresses (keep, drop, truncate or hash)",
+		Value: string(redact.ModeTruncate),
+	},
+
+	&cli.StringFlag{
+		Name:  "user-agent",
+		Usage: "How to redact user agents (keep, drop, truncate or hash)",
+		Value: string(redact.ModeDrop),
+	},
+
+	&cli.StringFlag{
+		Name:    "salt",
+		Usage:   "Secret salt used when hashing redacted values",
+		Sources: cli.EnvVars("DECIBEL_REDACT_SALT"),
+	},
+
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
+
+func redactAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+	dbPath := c.String("db")
+	verbose := c.Bool("verbose")
+
+	if verbose {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	} else {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	}
+
+	ipMode, err := redact.ParseMode(c.String("ip"))
+	if err != nil {
+		return fmt.Errorf("redact.ParseMode: %w", err)
+	}
+
+	userAgentMode, err := redact.ParseMode(c.String("user-agent"))
+	if err != nil {
+		return fmt.Errorf("redact.ParseMode: %w", err)
+	}
+
+	redactor := redact.Redactor{
+		IPMode:        ipMode,
+		UserAgentMode: userAgentMode,
+		Salt:          c.String("salt"),
+	}
+
+	logger.Debug().
+		Str("db_path", dbPath).
+		Str("ip_mode", string(ipMode)).
+		Str("user_agent_mode", string(userAgentMode)).
+		Msg("Redacting database")
+
+	db, err := database.Open(ctx, dbPath)
+	if err != nil {
+		return fmt.Errorf("database.Open: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	spotifySQLite := spotify.NewSQLite(db)
+	if err := spotifySQLite.RedactStreams(ctx, redactor); err != nil {
+		return fmt.Errorf("spotifySQLite.RedactStreams: %w", err)
+	}
+
+	logger.Info().Str("database", dbPath).Msg("Successfully redacted streams")
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package db
+
+import "github.com/urfave/cli/v3"
+
+var Commands = []*cli.Command{
+	{
+		Name:        "redact",
+		Usage:       "Redact IP addresses and user agents",
+		Description: "Drops, truncates or hashes the IP addresses and user agents already stored in the SQLite database, keeping country and device statistics intact",
+		Action:      redactAction,
+		Flags:       redactFlags,
+	},
+	{
+		Name:        "export",
+		Usage:       "Export the tables as CSV or Parquet",
+		Description: "Writes every table of the SQLite database, or the ones given with --table, as a CSV or Parquet file per table with typed values, reading rows one at a time so large histories don't need to fit in memory",
+		Action:      exportAction,
+		Flags:       exportFlags,
+	},
+}
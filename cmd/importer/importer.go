@@ -0,0 +1,227 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/ingest"
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/iox"
+	"github.com/cadoween/decibel/pkg/redact"
+)
+
+var errMissingPath = errors.New("expected the path of an export, or of a directory of exports, as argument")
+
+var Flags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "db",
+		Usage:    "Path to the SQLite database file",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:  "user",
+		Usage: "Profile or username to store the listens under, required by exports that don't record one",
+	},
+
+	&cli.StringFlag{
+		Name:  "format",
+		Usage: "Format of the exports instead of detecting it, one of " + strings.Join(formatNames(), ", "),
+	},
+
+	&cli.StringFlag{
+		Name:  "timezone",
+		Usage: "Time zone of exports recording local times without one, e.g. Europe/Paris",
+		Value: "Local",
+	},
+
+	&cli.StringFlag{
+		Name:  "redact-ip",
+		Usage: "How to store IP addresses (keep, drop, truncate or hash)",
+		Value: string(redact.ModeKeep),
+	},
+
+	&cli.StringFlag{
+		Name:  "redact-user-agent",
+		Usage: "How to store user agents (keep, drop, truncate or hash)",
+		Value: string(redact.ModeKeep),
+	},
+
+	&cli.StringFlag{
+		Name:    "salt",
+		Usage:   "Secret salt used when hashing redacted values",
+		Sources: cli.EnvVars("DECIBEL_REDACT_SALT"),
+	},
+
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
+
+func Action(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+	dbPath := c.String("db")
+
+	if c.Bool("verbose") {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	} else {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	}
+
+	if c.Args().Len() != 1 {
+		return errMissingPath
+	}
+
+	loc, err := time.LoadLocation(c.String("timezone"))
+	if err != nil {
+		return fmt.Errorf("time.LoadLocation: %w", err)
+	}
+
+	redactor, err := redactorFromFlags(c)
+	if err != nil {
+		return fmt.Errorf("redactorFromFlags: %w", err)
+	}
+
+	var forced listen.Importer
+	if formatName := c.String("format"); formatName != "" {
+		if forced, err = listen.Lookup(formatName); err != nil {
+			return fmt.Errorf("listen.Lookup: %w", err)
+		}
+	}
+
+	path := c.Args().First()
+	exports, err := ingest.FindExports(ctx, path, forced)
+	if err != nil {
+		return fmt.Errorf("ingest.FindExports: %w", err)
+	}
+
+	logger.Debug().
+		Str("db_path", dbPath).
+		Int("files", len(exports)).
+		Msg("Initializing database connection and data import")
+
+	db, err := ksqlite.New(ctx, dbPath, ksql.Config{})
+	if err != nil {
+		return fmt.Errorf("ksqlite.New: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	spotifySQLite := spotify.NewSQLite(db)
+	if err := spotifySQLite.Migrate(ctx); err != nil {
+		return fmt.Errorf("spotifySQLite.Migrate: %w", err)
+	}
+
+	opts := ingest.Options{
+		Options:  listen.Options{Location: loc},
+		Path:     path,
+		Redactor: redactor,
+	}
+	if user := c.String("user"); user != "" {
+		usernames, err := decibel.NewUserService(db).ResolveUsernames(ctx, []string{user})
+		if err != nil {
+			return fmt.Errorf("userService.ResolveUsernames: %w", err)
+		}
+		opts.Username = usernames[0]
+	}
+
+	// Files of a format are imported together, as the listens already
+	// imported are skipped by time whatever the order of the files.
+	var formats []listen.Format
+	for importer, files := range groupByImporter(exports) {
+		format := importer.Format()
+
+		result, err := ingest.ImportFiles(ctx, spotifySQLite, importer, files, opts)
+		if err != nil {
+			return fmt.Errorf("ingest.ImportFiles %s: %w", format.Name, err)
+		}
+
+		logger.Info().
+			Str("format", format.Name).
+			Int("files", len(files)).
+			Int("count", result.Imported).
+			Msg("Imported listens")
+
+		formats = append(formats, format)
+	}
+
+	if err := ingest.Merge(ctx, spotifySQLite, formats...); err != nil {
+		return fmt.Errorf("ingest.Merge: %w", err)
+	}
+
+	logger.Info().
+		Int("files", len(exports)).
+		Str("database", dbPath).
+		Msg("Successfully imported exports into database")
+
+	return nil
+}
+
+// groupByImporter returns the files of exports per importer, in the order
+// importers are first found.
+func groupByImporter(exports []ingest.Export) iter.Seq2[listen.Importer, []string] {
+	return func(yield func(listen.Importer, []string) bool) {
+		var names []string
+		files := make(map[string][]string)
+		importers := make(map[string]listen.Importer)
+		for _, export := range exports {
+			name := export.Importer.Format().Name
+			if _, ok := importers[name]; !ok {
+				names = append(names, name)
+				importers[name] = export.Importer
+			}
+			files[name] = append(files[name], export.Path)
+		}
+
+		for _, name := range names {
+			if !yield(importers[name], files[name]) {
+				return
+			}
+		}
+	}
+}
+
+func formatNames() []string {
+	importers := listen.Importers()
+	names := make([]string, 0, len(importers))
+	for _, importer := range importers {
+		names = append(names, importer.Format().Name)
+	}
+	return names
+}
+
+func redactorFromFlags(c *cli.Command) (redact.Redactor, error) {
+	ipMode, err := redact.ParseMode(c.String("redact-ip"))
+	if err != nil {
+		return redact.Redactor{}, fmt.Errorf("redact.ParseMode: %w", err)
+	}
+
+	userAgentMode, err := redact.ParseMode(c.String("redact-user-agent"))
+	if err != nil {
+		return redact.Redactor{}, fmt.Errorf("redact.ParseMode: %w", err)
+	}
+
+	redactor := redact.Redactor{
+		IPMode:        ipMode,
+		UserAgentMode: userAgentMode,
+		Salt:          c.String("salt"),
+	}
+	if err := redactor.Validate(); err != nil {
+		return redact.Redactor{}, fmt.Errorf("redactor.Validate: %w", err)
+	}
+
+	return redactor, nil
+}
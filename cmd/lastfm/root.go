@@ -0,0 +1,16 @@
+package lastfm
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel/cmd/lastfm/seeder"
+)
+
+var Commands = []*cli.Command{
+	{
+		Name:        "seeder",
+		Usage:       "Last.fm scrobble data seeder",
+		Description: "Seed your Last.fm scrobbles in the local database, next to your Spotify streams",
+		Commands:    seeder.Commands,
+	},
+}
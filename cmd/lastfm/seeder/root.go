@@ -0,0 +1,13 @@
+package seeder
+
+import "github.com/urfave/cli/v3"
+
+var Commands = []*cli.Command{
+	{
+		Name:        "run",
+		Usage:       "Run Last.fm scrobble data seeder",
+		Description: "Reads a Last.fm CSV or JSON export, seeds its scrobbles into the SQLite database and merges the ones duplicating a Spotify stream",
+		Action:      runAction,
+		Flags:       runFlags,
+	},
+}
@@ -0,0 +1,25 @@
+package listenbrainz
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel/cmd/listenbrainz/export"
+	"github.com/cadoween/decibel/cmd/listenbrainz/seeder"
+)
+
+var Commands = []*cli.Command{
+	{
+		Name:        "seeder",
+		Usage:       "ListenBrainz listen data seeder",
+		Description: "Seed the listens of a ListenBrainz export in the local database, next to your other services",
+		Commands:    seeder.Commands,
+	},
+
+	{
+		Name:        "export",
+		Usage:       "Export Spotify streams as ListenBrainz listens",
+		Description: "Write the Spotify streams of the database as a JSON file in the ListenBrainz import format, with Spotify track links",
+		Action:      export.Action,
+		Flags:       export.Flags,
+	},
+}
@@ -0,0 +1,13 @@
+package seeder
+
+import "github.com/urfave/cli/v3"
+
+var Commands = []*cli.Command{
+	{
+		Name:        "run",
+		Usage:       "Run ListenBrainz listen data seeder",
+		Description: "Reads a ListenBrainz listen export, as JSON or JSON Lines, seeds its listens into the SQLite database and merges the ones duplicating a stream of another service",
+		Action:      runAction,
+		Flags:       runFlags,
+	},
+}
@@ -7,7 +7,19 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/urfave/cli/v3"
 
+	"github.com/cadoween/decibel/cmd/applemusic"
+	"github.com/cadoween/decibel/cmd/db"
+	"github.com/cadoween/decibel/cmd/importer"
+	"github.com/cadoween/decibel/cmd/lastfm"
+	"github.com/cadoween/decibel/cmd/listenbrainz"
+	"github.com/cadoween/decibel/cmd/profiles"
+	"github.com/cadoween/decibel/cmd/query"
+	"github.com/cadoween/decibel/cmd/report"
+	"github.com/cadoween/decibel/cmd/scrobblerlog"
+	"github.com/cadoween/decibel/cmd/serve"
+	"github.com/cadoween/decibel/cmd/shell"
 	"github.com/cadoween/decibel/cmd/spotify"
+	"github.com/cadoween/decibel/cmd/youtubemusic"
 )
 
 func main() {
@@ -16,12 +28,90 @@ func main() {
 		Usage:       "Analyze and manage your music listening history (test)",
 		Description: "A command-line tool for processing and analyzing music streaming history data, providing insights into your listening habits across different platforms.",
 		Commands: []*cli.Command{
+			{
+				Name:        "import",
+				Usage:       "Import exports of any supported service",
+				Description: "Detect the format of an export, or of every export in a directory, from its content and import its listens, merging the plays recorded by several services.",
+				ArgsUsage:   "PATH",
+				Flags:       importer.Flags,
+				Action:      importer.Action,
+			},
 			{
 				Name:        "spotify",
 				Usage:       "Analyze and manage your Spotify listening history",
 				Description: "A command-line tool for processing and analyzing Spotify streaming history data, providing insights into your listening habits.",
 				Commands:    spotify.Commands,
 			},
+			{
+				Name:        "applemusic",
+				Usage:       "Import your Apple Music play activity",
+				Description: "Import the play activity of Apple's privacy data export into the same database as your other services.",
+				Commands:    applemusic.Commands,
+			},
+			{
+				Name:        "lastfm",
+				Usage:       "Import your Last.fm scrobbles",
+				Description: "Import Last.fm scrobble exports into the same database as your Spotify streams, merging the plays recorded by both.",
+				Commands:    lastfm.Commands,
+			},
+			{
+				Name:        "listenbrainz",
+				Usage:       "Import and export ListenBrainz listens",
+				Description: "Import ListenBrainz listen exports into the same database as your other services, or export your Spotify streams in the ListenBrainz import format, entirely offline.",
+				Commands:    listenbrainz.Commands,
+			},
+			{
+				Name:        "scrobblerlog",
+				Usage:       "Import and export .scrobbler.log files",
+				Description: "Import the .scrobbler.log of Rockbox and other portable players into the same database as your other services, or export your history as one.",
+				Commands:    scrobblerlog.Commands,
+			},
+			{
+				Name:        "youtubemusic",
+				Usage:       "Import your YouTube Music history",
+				Description: "Import the YouTube Music entries of your Google Takeout watch history into the same database as your other services.",
+				Commands:    youtubemusic.Commands,
+			},
+			{
+				Name:        "profiles",
+				Usage:       "Manage user profiles",
+				Description: "Name the accounts found in your exports so a household can share a single database and filter statistics per person.",
+				Commands:    profiles.Commands,
+			},
+			{
+				Name:        "db",
+				Usage:       "Manage the decibel database",
+				Description: "Maintenance commands operating on the SQLite database shared by every service.",
+				Commands:    db.Commands,
+			},
+			{
+				Name:        "report",
+				Usage:       "Run reports defined in a configuration file",
+				Description: "Define reusable reports as SQL or as a structured group-by of the streams, with their own columns and formatting, and run them by name.",
+				Commands:    report.Commands,
+			},
+			{
+				Name:        "serve",
+				Usage:       "Serve statistics and a dashboard over HTTP",
+				Description: "Expose every statistic as a JSON REST endpoint under /api/v1, with user, period and limit query parameters, and a web dashboard on /.",
+				Flags:       serve.Flags,
+				Action:      serve.Action,
+			},
+			{
+				Name:        "query",
+				Usage:       "Run a read-only SQL query",
+				Description: "Run a single SELECT, WITH, VALUES or EXPLAIN statement against the database, opened read-only, and print its rows as a table, CSV or JSON.",
+				ArgsUsage:   "STATEMENT",
+				Flags:       query.Flags,
+				Action:      query.Action,
+			},
+			{
+				Name:        "shell",
+				Usage:       "Start an interactive read-only SQL shell",
+				Description: "Run read-only SQL statements against the database interactively, with history, tab completion of table and column names and dot commands such as .tables, .schema and .format. Statements piped on stdin are run as a script.",
+				Flags:       shell.Flags,
+				Action:      shell.Action,
+			},
 		},
 	}
 
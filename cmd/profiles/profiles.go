@@ -0,0 +1,113 @@
+package profiles
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/database"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+func addAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	profile, err := decibel.NewUserService(db).AddProfile(ctx, c.String("name"), c.String("username"))
+	if err != nil {
+		return fmt.Errorf("decibel.NewUserService(db).AddProfile: %w", err)
+	}
+
+	logger.Info().
+		Str("profile", profile.Name).
+		Str("username", profile.Username).
+		Msg("Successfully added profile")
+
+	return nil
+}
+
+func listAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	profiles, err := decibel.NewUserService(db).ListProfiles(ctx)
+	if err != nil {
+		return fmt.Errorf("decibel.NewUserService(db).ListProfiles: %w", err)
+	}
+
+	imports, err := spotify.NewSQLite(db).GetImports(ctx)
+	if err != nil {
+		return fmt.Errorf("spotify.NewSQLite(db).GetImports: %w", err)
+	}
+
+	_, _ = fmt.Printf("\nProfiles:\n\n")
+	_, _ = fmt.Printf("%-20s %-30s %-10s %-12s %-17s\n", "Profile", "Username", "Imports", "Streams", "Last Import")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 93))
+
+	for _, profile := range profiles {
+		var (
+			importCount, streamCount int
+			lastImport               time.Time
+		)
+
+		for i := range imports {
+			if imports[i].Username != profile.Username {
+				continue
+			}
+			importCount++
+			streamCount += imports[i].StreamCount
+			if imports[i].ImportedAt.After(lastImport) {
+				lastImport = imports[i].ImportedAt
+			}
+		}
+
+		lastImportStr := "never"
+		if !lastImport.IsZero() {
+			lastImportStr = lastImport.Format("2006-01-02 15:04")
+		}
+
+		_, _ = fmt.Printf("%-20s %-30s %-10d %-12d %s\n",
+			profile.Name,
+			profile.Username,
+			importCount,
+			streamCount,
+			lastImportStr,
+		)
+	}
+
+	return nil
+}
+
+func removeAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	if err := decibel.NewUserService(db).RemoveProfile(ctx, c.String("name")); err != nil {
+		return fmt.Errorf("decibel.NewUserService(db).RemoveProfile: %w", err)
+	}
+
+	logger.Info().Str("profile", c.String("name")).Msg("Successfully removed profile")
+
+	return nil
+}
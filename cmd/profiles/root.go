@@ -0,0 +1,58 @@
+package profiles
+
+import "github.com/urfave/cli/v3"
+
+var Commands = []*cli.Command{
+	{
+		Name:        "add",
+		Usage:       "Add a profile",
+		Description: "Create a named profile for an account username found in the imported exports",
+		Action:      addAction,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:     "name",
+				Usage:    "Name of the profile",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "username",
+				Usage:    "Account username the profile refers to",
+				Required: true,
+			},
+		}, sharedFlags...),
+	},
+	{
+		Name:        "list",
+		Usage:       "List profiles",
+		Description: "List the profiles and the imports recorded for their accounts",
+		Action:      listAction,
+		Flags:       sharedFlags,
+	},
+	{
+		Name:        "remove",
+		Usage:       "Remove a profile",
+		Description: "Remove a profile, the streams of its account are kept in the database",
+		Action:      removeAction,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:     "name",
+				Usage:    "Name of the profile",
+				Required: true,
+			},
+		}, sharedFlags...),
+	},
+}
+
+var sharedFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "db",
+		Usage:    "Path to the SQLite database file",
+		Required: true,
+	},
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
@@ -0,0 +1,81 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel/internal/query"
+	"github.com/cadoween/decibel/pkg/iox"
+	"github.com/cadoween/decibel/pkg/tabular"
+)
+
+var errMissingStatement = errors.New("expected a single SQL statement as argument")
+
+var Flags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "db",
+		Usage:    "Path to the SQLite database file",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format (table, csv or json)",
+		Value: string(tabular.FormatTable),
+	},
+
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
+
+func Action(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+	dbPath := c.String("db")
+
+	if c.Bool("verbose") {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	} else {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	}
+
+	if c.Args().Len() != 1 {
+		return errMissingStatement
+	}
+	statement := c.Args().First()
+
+	format, err := tabular.ParseFormat(c.String("format"))
+	if err != nil {
+		return fmt.Errorf("tabular.ParseFormat: %w", err)
+	}
+
+	logger.Debug().
+		Str("db_path", dbPath).
+		Str("statement", statement).
+		Msg("Running query")
+
+	db, err := query.Open(ctx, dbPath)
+	if err != nil {
+		return fmt.Errorf("query.Open: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	table, err := db.Run(ctx, statement)
+	if err != nil {
+		return fmt.Errorf("db.Run: %w", err)
+	}
+
+	if err := tabular.Write(os.Stdout, format, table); err != nil {
+		return fmt.Errorf("tabular.Write: %w", err)
+	}
+
+	return nil
+}
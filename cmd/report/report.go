@@ -0,0 +1,175 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/customreport"
+	"github.com/cadoween/decibel/internal/query"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/iox"
+	"github.com/cadoween/decibel/pkg/tabular"
+	"github.com/cadoween/decibel/pkg/termchart"
+)
+
+var (
+	errInvalidFlags = errors.New("invalid flags")
+	errMissingName  = errors.New("expected the name of a report as argument")
+)
+
+func runAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	config, err := loadConfig(c)
+	if err != nil {
+		return fmt.Errorf("loadConfig: %w", err)
+	}
+
+	if c.Args().Len() != 1 {
+		return errMissingName
+	}
+
+	definition, err := config.Find(c.Args().First())
+	if err != nil {
+		return fmt.Errorf("config.Find: %w", err)
+	}
+
+	if definition.Query == nil && (c.IsSet("user") || c.IsSet("period") || c.IsSet("limit")) {
+		return fmt.Errorf("%w: --user, --period and --limit only apply to reports defined with a query", errInvalidFlags)
+	}
+
+	format := tabular.FormatTable
+	for _, value := range []string{definition.Format, c.String("format")} {
+		if value == "" {
+			continue
+		}
+		if format, err = tabular.ParseFormat(value); err != nil {
+			return fmt.Errorf("tabular.ParseFormat: %w", err)
+		}
+	}
+
+	logger.Debug().
+		Str("db_path", c.String("db")).
+		Str("report", definition.Name).
+		Msg("Running report")
+
+	db, err := query.Open(ctx, c.String("db"))
+	if err != nil {
+		return fmt.Errorf("query.Open: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	var filter spotify.Filter
+	if definition.Query != nil {
+		if filter, err = filterFromFlags(ctx, c, db, *definition.Query); err != nil {
+			return fmt.Errorf("filterFromFlags: %w", err)
+		}
+	}
+
+	statement, args, err := definition.Statement(filter)
+	if err != nil {
+		return fmt.Errorf("definition.Statement: %w", err)
+	}
+
+	table, err := db.Run(ctx, statement, args...)
+	if err != nil {
+		return fmt.Errorf("db.Run: %w", err)
+	}
+
+	if table, err = definition.Apply(table); err != nil {
+		return fmt.Errorf("definition.Apply: %w", err)
+	}
+
+	if format == tabular.FormatTable {
+		title := definition.Title
+		if title == "" {
+			title = definition.Name
+		}
+		_, _ = fmt.Printf("\n%s\n\n", termchart.NewPalette(os.Stdout).Bold(title+":"))
+	}
+
+	if err := tabular.Write(os.Stdout, format, table); err != nil {
+		return fmt.Errorf("tabular.Write: %w", err)
+	}
+
+	return nil
+}
+
+func listAction(_ context.Context, c *cli.Command) error {
+	config, err := loadConfig(c)
+	if err != nil {
+		return fmt.Errorf("loadConfig: %w", err)
+	}
+
+	_, _ = fmt.Printf("\nReports:\n\n")
+	_, _ = fmt.Printf("%-25s %-6s %s\n", "Name", "Kind", "Description")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 80))
+
+	for _, definition := range config.Reports {
+		_, _ = fmt.Printf("%-25s %-6s %s\n", definition.Name, definition.Kind(), definition.Description)
+	}
+
+	return nil
+}
+
+// loadConfig sets the log level from the --verbose flag and loads the report
+// configuration given by the --config flag, or the default one.
+func loadConfig(c *cli.Command) (customreport.Config, error) {
+	if c.Bool("verbose") {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	} else {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	}
+
+	path := c.String("config")
+	if path == "" {
+		var err error
+		if path, err = customreport.DefaultPath(); err != nil {
+			return customreport.Config{}, fmt.Errorf("customreport.DefaultPath: %w", err)
+		}
+	}
+
+	config, err := customreport.Load(path)
+	if err != nil {
+		return customreport.Config{}, fmt.Errorf("customreport.Load: %w", err)
+	}
+
+	return config, nil
+}
+
+// filterFromFlags builds the filter of a structured report from its users and
+// period, overridden by --user and --period, resolving profile names to
+// their usernames.
+func filterFromFlags(ctx context.Context, c *cli.Command, db query.DB, spec customreport.Query) (spotify.Filter, error) {
+	users, periodValue := spec.Users, spec.Period
+	if c.IsSet("user") {
+		users = c.StringSlice("user")
+	}
+	if c.IsSet("period") {
+		periodValue = c.String("period")
+	}
+
+	usernames, err := decibel.NewUserService(db).ResolveUsernames(ctx, users)
+	if err != nil {
+		return spotify.Filter{}, fmt.Errorf("decibel.NewUserService(db).ResolveUsernames: %w", err)
+	}
+
+	filter := spotify.Filter{Usernames: usernames, Limit: c.Int("limit")}
+	if periodValue == "" {
+		return filter, nil
+	}
+
+	period, err := spotify.ParsePeriod(periodValue)
+	if err != nil {
+		return spotify.Filter{}, fmt.Errorf("spotify.ParsePeriod: %w", err)
+	}
+
+	return filter.WithPeriod(period), nil
+}
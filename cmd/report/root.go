@@ -0,0 +1,57 @@
+package report
+
+import "github.com/urfave/cli/v3"
+
+var Commands = []*cli.Command{
+	{
+		Name:        "run",
+		Usage:       "Run a report",
+		Description: "Run a report defined in the configuration file against the database, opened read-only",
+		ArgsUsage:   "NAME",
+		Action:      runAction,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:     "db",
+				Usage:    "Path to the SQLite database file",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:  "user",
+				Usage: "Only include the streams of this profile or username, can be repeated, overrides the users of the report",
+			},
+			&cli.StringFlag{
+				Name:  "period",
+				Usage: "Period as YYYY, YYYY-MM, YYYY-MM-DD or an inclusive FROM..TO range, overrides the period of the report",
+			},
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "Maximum number of rows, overrides the limit of the report",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format (table, csv or json), overrides the format of the report",
+			},
+		}, sharedFlags...),
+	},
+	{
+		Name:        "list",
+		Usage:       "List reports",
+		Description: "List the reports defined in the configuration file",
+		Action:      listAction,
+		Flags:       sharedFlags,
+	},
+}
+
+var sharedFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "config",
+		Usage:   "Path to the report configuration file, decibel/reports.json in the user configuration directory by default",
+		Sources: cli.EnvVars("DECIBEL_REPORTS"),
+	},
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
@@ -0,0 +1,112 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/database"
+	"github.com/cadoween/decibel/internal/scrobblerlog"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+var Flags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "db",
+		Usage:    "Path to the SQLite database file",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:  "out",
+		Usage: "Path of the log file to write",
+		Value: scrobblerlog.FileName,
+	},
+
+	&cli.StringSliceFlag{
+		Name:  "user",
+		Usage: "Only include the streams of this profile or username, can be repeated",
+	},
+
+	&cli.StringFlag{
+		Name:  "period",
+		Usage: "Only include the streams of this period, e.g. 2024, 2024-05 or 2023-01..2023-06",
+	},
+
+	&cli.StringSliceFlag{
+		Name:  "source",
+		Usage: "Only include the streams imported from this source, e.g. spotify or lastfm, can be repeated",
+	},
+
+	&cli.DurationFlag{
+		Name:  "min-play-time",
+		Usage: "Minimum play time of the streams to export",
+		Value: 30 * time.Second,
+	},
+
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
+
+func Action(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+	outPath := c.String("out")
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	spotifySQLite := spotify.NewSQLite(db)
+
+	usernames, err := decibel.NewUserService(db).ResolveUsernames(ctx, c.StringSlice("user"))
+	if err != nil {
+		return fmt.Errorf("decibel.NewUserService(db).ResolveUsernames: %w", err)
+	}
+
+	filter := spotify.Filter{
+		Usernames: usernames,
+		Sources:   c.StringSlice("source"),
+		MinPlayed: c.Duration("min-play-time"),
+	}
+	if rawPeriod := c.String("period"); rawPeriod != "" {
+		period, err := spotify.ParsePeriod(rawPeriod)
+		if err != nil {
+			return fmt.Errorf("spotify.ParsePeriod: %w", err)
+		}
+		filter = filter.WithPeriod(period)
+	}
+
+	listens, err := spotifySQLite.QueryListens(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("spotifySQLite.QueryListens: %w", err)
+	}
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("os.Create: %w", err)
+	}
+	defer iox.Close(f, logger)
+
+	if err := scrobblerlog.Write(f, scrobblerlog.FromListens(listens)); err != nil {
+		return fmt.Errorf("scrobblerlog.Write: %w", err)
+	}
+
+	logger.Info().
+		Int("plays", len(listens)).
+		Str("file", outPath).
+		Msg("Successfully exported plays")
+
+	return nil
+}
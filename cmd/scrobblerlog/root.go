@@ -0,0 +1,25 @@
+package scrobblerlog
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel/cmd/scrobblerlog/export"
+	"github.com/cadoween/decibel/cmd/scrobblerlog/seeder"
+)
+
+var Commands = []*cli.Command{
+	{
+		Name:        "seeder",
+		Usage:       ".scrobbler.log data seeder",
+		Description: "Seed the plays of a portable player .scrobbler.log in the local database, next to your other services",
+		Commands:    seeder.Commands,
+	},
+
+	{
+		Name:        "export",
+		Usage:       "Export streams as a .scrobbler.log",
+		Description: "Write the streams of the database as a tab-separated .scrobbler.log, for tools uploading the logs of portable players",
+		Action:      export.Action,
+		Flags:       export.Flags,
+	},
+}
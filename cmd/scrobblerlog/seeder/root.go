@@ -0,0 +1,13 @@
+package seeder
+
+import "github.com/urfave/cli/v3"
+
+var Commands = []*cli.Command{
+	{
+		Name:        "run",
+		Usage:       "Run .scrobbler.log data seeder",
+		Description: "Reads a .scrobbler.log written by Rockbox or another portable player, seeds its plays into the SQLite database and merges the scrobbles duplicating them",
+		Action:      runAction,
+		Flags:       runFlags,
+	},
+}
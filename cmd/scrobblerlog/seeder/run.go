@@ -0,0 +1,114 @@
+package seeder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/ingest"
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/internal/scrobblerlog"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+var runFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "db",
+		Usage:    "Path to the SQLite database file",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:     "file",
+		Usage:    "The .scrobbler.log file of the player",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:     "user",
+		Usage:    "Profile or username to store the plays under, so statistics span every service",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:  "timezone",
+		Usage: "Time zone of the player clock, e.g. Europe/Paris, for logs without one",
+		Value: "Local",
+	},
+
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
+
+func runAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+	dbPath := c.String("db")
+	path := c.String("file")
+	verbose := c.Bool("verbose")
+
+	if verbose {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	} else {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	}
+
+	loc, err := time.LoadLocation(c.String("timezone"))
+	if err != nil {
+		return fmt.Errorf("time.LoadLocation: %w", err)
+	}
+
+	logger.Debug().
+		Str("db_path", dbPath).
+		Str("file", path).
+		Msg("Initializing database connection and data import")
+
+	db, err := ksqlite.New(ctx, dbPath, ksql.Config{})
+	if err != nil {
+		return fmt.Errorf("ksqlite.New: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	spotifySQLite := spotify.NewSQLite(db)
+	if err := spotifySQLite.Migrate(ctx); err != nil {
+		return fmt.Errorf("spotifySQLite.Migrate: %w", err)
+	}
+
+	usernames, err := decibel.NewUserService(db).ResolveUsernames(ctx, []string{c.String("user")})
+	if err != nil {
+		return fmt.Errorf("userService.ResolveUsernames: %w", err)
+	}
+
+	importer, err := listen.Lookup(scrobblerlog.Source)
+	if err != nil {
+		return fmt.Errorf("listen.Lookup: %w", err)
+	}
+
+	opts := ingest.Options{Options: listen.Options{Username: usernames[0], Location: loc}, Path: path}
+	result, err := ingest.ImportFiles(ctx, spotifySQLite, importer, []string{path}, opts)
+	if err != nil {
+		return fmt.Errorf("ingest.ImportFiles: %w", err)
+	}
+
+	if err := ingest.Merge(ctx, spotifySQLite, importer.Format()); err != nil {
+		return fmt.Errorf("ingest.Merge: %w", err)
+	}
+
+	logger.Info().
+		Int("total_streams", result.Imported).
+		Str("username", usernames[0]).
+		Str("database", dbPath).
+		Msg("Successfully imported plays into database")
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel/internal/database"
+	"github.com/cadoween/decibel/internal/server"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+// shutdownTimeout is how long in-flight requests are given to complete once
+// the server is asked to stop.
+const shutdownTimeout = 5 * time.Second
+
+var Flags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "db",
+		Usage:    "Path to the SQLite database file",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:  "addr",
+		Usage: "Address to listen on",
+		Value: ":8080",
+	},
+
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
+
+func Action(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+	dbPath := c.String("db")
+	addr := c.String("addr")
+
+	if c.Bool("verbose") {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	} else {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	}
+
+	logger.Debug().
+		Str("db_path", dbPath).
+		Msg("Connecting to database")
+
+	db, err := database.Open(ctx, dbPath)
+	if err != nil {
+		return fmt.Errorf("database.Open: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           server.New(db),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info().Str("addr", addr).Msg("Serving statistics")
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("httpServer.ListenAndServe: %w", err)
+	case <-ctx.Done():
+	}
+
+	logger.Info().Msg("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("httpServer.Shutdown: %w", err)
+	}
+
+	return nil
+}
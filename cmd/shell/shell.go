@@ -0,0 +1,87 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel/internal/query"
+	"github.com/cadoween/decibel/internal/shell"
+	"github.com/cadoween/decibel/pkg/iox"
+	"github.com/cadoween/decibel/pkg/tabular"
+)
+
+var Flags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "db",
+		Usage:    "Path to the SQLite database file",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:  "format",
+		Usage: "Initial output format (table, csv or json)",
+		Value: string(tabular.FormatTable),
+	},
+
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
+
+func Action(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+	dbPath := c.String("db")
+
+	if c.Bool("verbose") {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	} else {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	}
+
+	format, err := tabular.ParseFormat(c.String("format"))
+	if err != nil {
+		return fmt.Errorf("tabular.ParseFormat: %w", err)
+	}
+
+	logger.Debug().
+		Str("db_path", dbPath).
+		Msg("Connecting to database")
+
+	db, err := query.Open(ctx, dbPath)
+	if err != nil {
+		return fmt.Errorf("query.Open: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	tables, err := query.GetSchema(ctx, db)
+	if err != nil {
+		return fmt.Errorf("query.GetSchema: %w", err)
+	}
+
+	session := shell.NewSession(db, tables, format)
+
+	// Statements piped in are run as a script, without the line editor.
+	if !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		if err := session.RunScript(ctx, os.Stdin, os.Stdout); err != nil {
+			return fmt.Errorf("session.RunScript: %w", err)
+		}
+		return nil
+	}
+
+	_, _ = fmt.Printf("Connected to %s read-only. Type .help for commands, tab completes table and column names.\n", dbPath)
+
+	if _, err := tea.NewProgram(shell.New(ctx, session), tea.WithContext(ctx)).Run(); err != nil {
+		return fmt.Errorf("tea.NewProgram(model).Run: %w", err)
+	}
+
+	return nil
+}
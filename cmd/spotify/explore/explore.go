@@ -0,0 +1,76 @@
+package explore
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/database"
+	"github.com/cadoween/decibel/internal/explore"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+var Flags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "db",
+		Usage:    "Path to the SQLite database file",
+		Required: true,
+	},
+
+	&cli.StringSliceFlag{
+		Name:  "user",
+		Usage: "Only include the streams of this profile or username, can be repeated",
+	},
+
+	&cli.StringSliceFlag{
+		Name:  "source",
+		Usage: "Only include the streams imported from this source, e.g. spotify or lastfm, can be repeated",
+	},
+
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
+
+func Action(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+	dbPath := c.String("db")
+
+	if c.Bool("verbose") {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	} else {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	}
+
+	logger.Debug().
+		Str("db_path", dbPath).
+		Msg("Connecting to database")
+
+	db, err := database.Open(ctx, dbPath)
+	if err != nil {
+		return fmt.Errorf("database.Open: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	spotifySQLite := spotify.NewSQLite(db)
+
+	usernames, err := decibel.NewUserService(db).ResolveUsernames(ctx, c.StringSlice("user"))
+	if err != nil {
+		return fmt.Errorf("decibel.NewUserService(db).ResolveUsernames: %w", err)
+	}
+
+	model := explore.New(ctx, spotifySQLite, spotify.Filter{Usernames: usernames, Sources: c.StringSlice("source")})
+	if _, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil {
+		return fmt.Errorf("tea.NewProgram(model).Run: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/database"
+	"github.com/cadoween/decibel/internal/report"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+var Flags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "db",
+		Usage:    "Path to the SQLite database file",
+		Required: true,
+	},
+
+	&cli.StringFlag{
+		Name:  "out",
+		Usage: "Path of the HTML file to write",
+		Value: "report.html",
+	},
+
+	&cli.StringSliceFlag{
+		Name:  "user",
+		Usage: "Only include the streams of this profile or username, can be repeated",
+	},
+
+	&cli.StringSliceFlag{
+		Name:  "source",
+		Usage: "Only include the streams imported from this source, e.g. spotify or lastfm, can be repeated",
+	},
+
+	&cli.StringFlag{
+		Name:  "period",
+		Usage: "Only include the streams of this period, e.g. 2024, 2024-05 or 2023-01..2023-06",
+	},
+
+	&cli.StringFlag{
+		Name:  "interval",
+		Usage: "Period to group the listening timeline by (day, week, month or year)",
+		Value: string(spotify.IntervalMonth),
+	},
+
+	&cli.IntFlag{
+		Name:  "limit",
+		Usage: "Maximum number of entries per top list",
+		Value: 20,
+	},
+
+	&cli.BoolFlag{
+		Name:    "verbose",
+		Usage:   "Enable verbose logging",
+		Value:   false,
+		Aliases: []string{"v"},
+	},
+}
+
+func Action(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+	dbPath := c.String("db")
+	outPath := c.String("out")
+
+	if c.Bool("verbose") {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	} else {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	}
+
+	interval, err := spotify.ParseInterval(c.String("interval"))
+	if err != nil {
+		return fmt.Errorf("spotify.ParseInterval: %w", err)
+	}
+
+	logger.Debug().
+		Str("db_path", dbPath).
+		Msg("Connecting to database")
+
+	db, err := database.Open(ctx, dbPath)
+	if err != nil {
+		return fmt.Errorf("database.Open: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	spotifySQLite := spotify.NewSQLite(db)
+
+	usernames, err := decibel.NewUserService(db).ResolveUsernames(ctx, c.StringSlice("user"))
+	if err != nil {
+		return fmt.Errorf("decibel.NewUserService(db).ResolveUsernames: %w", err)
+	}
+
+	filter := spotify.Filter{Usernames: usernames, Sources: c.StringSlice("source"), Limit: int(c.Int("limit"))}
+	scope := slices.Concat(c.StringSlice("user"), c.StringSlice("source"))
+
+	if rawPeriod := c.String("period"); rawPeriod != "" {
+		period, err := spotify.ParsePeriod(rawPeriod)
+		if err != nil {
+			return fmt.Errorf("spotify.ParsePeriod: %w", err)
+		}
+		filter = filter.WithPeriod(period)
+		scope = append(scope, period.Label)
+	}
+
+	r, err := report.Collect(ctx, spotifySQLite, interval, filter)
+	if err != nil {
+		return fmt.Errorf("report.Collect: %w", err)
+	}
+	r.Scope = strings.Join(scope, ", ")
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("os.Create: %w", err)
+	}
+	defer iox.Close(f, logger)
+
+	if err := r.Render(f); err != nil {
+		return fmt.Errorf("r.Render: %w", err)
+	}
+
+	logger.Info().Str("report", outPath).Msg("Successfully generated report")
+
+	return nil
+}
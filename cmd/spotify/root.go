@@ -3,6 +3,8 @@ package spotify
 import (
 	"github.com/urfave/cli/v3"
 
+	"github.com/cadoween/decibel/cmd/spotify/explore"
+	"github.com/cadoween/decibel/cmd/spotify/report"
 	"github.com/cadoween/decibel/cmd/spotify/seeder"
 	"github.com/cadoween/decibel/cmd/spotify/stats"
 )
@@ -21,4 +23,20 @@ var Commands = []*cli.Command{
 		Description: "Analyze your Spotify listening history and view various statistics",
 		Commands:    stats.Commands,
 	},
+
+	{
+		Name:        "report",
+		Usage:       "Generate an HTML report",
+		Description: "Write a single self-contained HTML file with totals, top lists, timelines and heatmaps, to share with people who don't have decibel installed",
+		Action:      report.Action,
+		Flags:       report.Flags,
+	},
+
+	{
+		Name:        "explore",
+		Usage:       "Browse your listening history interactively",
+		Description: "Open a full-screen terminal UI to browse top artists, drill into their albums and tracks, switch years and search",
+		Action:      explore.Action,
+		Flags:       explore.Flags,
+	},
 }
@@ -3,14 +3,19 @@ package seeder
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/rs/zerolog"
 	"github.com/urfave/cli/v3"
 	"github.com/vingarcia/ksql"
 	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
 
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/ingest"
+	"github.com/cadoween/decibel/internal/listen"
 	"github.com/cadoween/decibel/internal/spotify"
 	"github.com/cadoween/decibel/pkg/iox"
+	"github.com/cadoween/decibel/pkg/redact"
 )
 
 var runFlags = []cli.Flag{
@@ -26,6 +31,29 @@ var runFlags = []cli.Flag{
 		Required: true,
 	},
 
+	&cli.StringFlag{
+		Name:  "profile",
+		Usage: "Name of the profile to link to the account found in the export, created if missing",
+	},
+
+	&cli.StringFlag{
+		Name:  "redact-ip",
+		Usage: "How to store IP addresses (keep, drop, truncate or hash)",
+		Value: string(redact.ModeKeep),
+	},
+
+	&cli.StringFlag{
+		Name:  "redact-user-agent",
+		Usage: "How to store user agents (keep, drop, truncate or hash)",
+		Value: string(redact.ModeKeep),
+	},
+
+	&cli.StringFlag{
+		Name:    "salt",
+		Usage:   "Secret salt used when hashing redacted values",
+		Sources: cli.EnvVars("DECIBEL_REDACT_SALT"),
+	},
+
 	&cli.BoolFlag{
 		Name:    "verbose",
 		Usage:   "Enable verbose logging",
@@ -46,6 +74,11 @@ func runAction(ctx context.Context, c *cli.Command) error {
 		zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	}
 
+	redactor, err := redactorFromFlags(c)
+	if err != nil {
+		return fmt.Errorf("redactorFromFlags: %w", err)
+	}
+
 	logger.Debug().
 		Str("db_path", dbPath).
 		Str("data_dir", dataDir).
@@ -57,26 +90,99 @@ func runAction(ctx context.Context, c *cli.Command) error {
 	}
 	defer iox.Close(db, logger)
 
-	logger.Debug().Str("path", dataDir).Msg("Reading streaming history")
+	spotifySQLite := spotify.NewSQLite(db)
+	if err := spotifySQLite.Migrate(ctx); err != nil {
+		return fmt.Errorf("spotifySQLite.Migrate: %w", err)
+	}
 
-	spotifyJSONReader := spotify.NewJSONReader()
-	streams, err := spotifyJSONReader.ReadStreamsFromFolder(ctx, dataDir)
+	importer, err := listen.Lookup(spotify.SourceSpotify)
 	if err != nil {
-		return fmt.Errorf("spotifyJSONReader.ReadStreamsFromFolder: %w", err)
+		return fmt.Errorf("listen.Lookup: %w", err)
 	}
-	logger.Info().Int("count", len(streams)).Msg("Found streams in history files")
 
-	logger.Debug().Msg("Starting data import")
+	exports, err := ingest.FindExports(ctx, dataDir, importer)
+	if err != nil {
+		return fmt.Errorf("ingest.FindExports: %w", err)
+	}
 
-	spotifySQLite := spotify.NewSQLite(db)
-	if err := spotifySQLite.BulkInsertStreams(ctx, streams); err != nil {
-		return fmt.Errorf("spotifySQLite.BulkInsertStreams: %w", err)
+	files := make([]string, 0, len(exports))
+	for _, export := range exports {
+		files = append(files, export.Path)
+	}
+	logger.Debug().Int("files", len(files)).Msg("Found streaming history files")
+
+	result, err := ingest.ImportFiles(ctx, spotifySQLite, importer, files, ingest.Options{Path: dataDir, Redactor: redactor})
+	if err != nil {
+		return fmt.Errorf("ingest.ImportFiles: %w", err)
+	}
+
+	if profile := c.String("profile"); profile != "" {
+		if err := linkProfile(ctx, decibel.NewUserService(db), profile, result.Usernames); err != nil {
+			return fmt.Errorf("linkProfile: %w", err)
+		}
+	}
+
+	if err := ingest.Merge(ctx, spotifySQLite, importer.Format()); err != nil {
+		return fmt.Errorf("ingest.Merge: %w", err)
 	}
 
 	logger.Info().
-		Int("total_streams", len(streams)).
+		Int("total_streams", result.Imported).
+		Int("users", len(result.Usernames)).
 		Str("database", dbPath).
 		Msg("Successfully imported streams into database")
 
 	return nil
 }
+
+// linkProfile creates the profile named name for the single account found in
+// the export, unless it already exists.
+func linkProfile(ctx context.Context, userService *decibel.UserService, name string, usernames []string) error {
+	profiles, err := userService.ListProfiles(ctx)
+	if err != nil {
+		return fmt.Errorf("userService.ListProfiles: %w", err)
+	}
+
+	if slices.ContainsFunc(profiles, func(profile decibel.Profile) bool { return profile.Name == name }) {
+		return nil
+	}
+
+	if len(usernames) != 1 {
+		return fmt.Errorf("%w: the export contains %d accounts, add the profile with the profiles command instead",
+			decibel.ErrInvalidProfile, len(usernames))
+	}
+
+	if _, err := userService.AddProfile(ctx, name, usernames[0]); err != nil {
+		return fmt.Errorf("userService.AddProfile: %w", err)
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Str("profile", name).
+		Str("username", usernames[0]).
+		Msg("Created profile")
+
+	return nil
+}
+
+func redactorFromFlags(c *cli.Command) (redact.Redactor, error) {
+	ipMode, err := redact.ParseMode(c.String("redact-ip"))
+	if err != nil {
+		return redact.Redactor{}, fmt.Errorf("redact.ParseMode: %w", err)
+	}
+
+	userAgentMode, err := redact.ParseMode(c.String("redact-user-agent"))
+	if err != nil {
+		return redact.Redactor{}, fmt.Errorf("redact.ParseMode: %w", err)
+	}
+
+	redactor := redact.Redactor{
+		IPMode:        ipMode,
+		UserAgentMode: userAgentMode,
+		Salt:          c.String("salt"),
+	}
+	if err := redactor.Validate(); err != nil {
+		return redact.Redactor{}, fmt.Errorf("redactor.Validate: %w", err)
+	}
+
+	return redactor, nil
+}
@@ -1,6 +1,10 @@
 package stats
 
-import "github.com/urfave/cli/v3"
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
 
 var Commands = []*cli.Command{
 	{
@@ -15,7 +19,7 @@ var Commands = []*cli.Command{
 		Usage:       "Get top tracks by play time",
 		Description: "Show your most played tracks sorted by play count",
 		Action:      topTracksAction,
-		Flags:       sharedFlags,
+		Flags:       append([]cli.Flag{exportPlaylistFlag}, sharedFlags...),
 	},
 	{
 		Name:        "top-albums",
@@ -29,8 +33,146 @@ var Commands = []*cli.Command{
 		Usage:       "Get most skipped tracks",
 		Description: "Show tracks that are most frequently skipped (minimum 5 plays)",
 		Action:      mostSkippedTracksAction,
-		Flags:       sharedFlags,
+		Flags:       append([]cli.Flag{exportPlaylistFlag}, sharedFlags...),
+	},
+	{
+		Name:        "devices",
+		Usage:       "Get play time per device over time",
+		Description: "Show your play time grouped by device class, operating system and manufacturer for each period",
+		Action:      devicesAction,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "interval",
+				Usage: "Period to group play time by (day, week, month or year)",
+				Value: string(spotify.IntervalMonth),
+			},
+		}, sharedFlags...),
+	},
+	{
+		Name:        "countries",
+		Usage:       "Get play time per country and travel timeline",
+		Description: "Show your play time per connection country and a chronological timeline of the periods spent streaming from each country",
+		Action:      countriesAction,
+		Flags: append([]cli.Flag{
+			&cli.IntFlag{
+				Name:  "min-streams",
+				Usage: "Minimum number of consecutive streams for a period to show up in the timeline",
+				Value: 3,
+			},
+		}, sharedFlags...),
+	},
+	{
+		Name:        "anomalies",
+		Usage:       "Detect suspicious account activity",
+		Description: "Flag concurrent streams from different IPs or platforms, sudden new countries, unusual listening hours and bursts of never heard artists, with a score per event",
+		Action:      anomaliesAction,
+		Flags: append([]cli.Flag{
+			&cli.FloatFlag{
+				Name:  "min-score",
+				Usage: "Minimum score (0 to 1) of the anomalies to show",
+				Value: 0.5,
+			},
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "Maximum number of anomalies to show",
+				Value: 50,
+			},
+		}, sharedFlags...),
 	},
+	{
+		Name:        "compare",
+		Usage:       "Compare the listening of two users",
+		Description: "Show the taste overlap of two users given with --user: shared top artists, similarity, artists unique to each and who discovered a shared artist first",
+		Action:      compareAction,
+		Flags: append([]cli.Flag{
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "Maximum number of artists to show per list",
+				Value: 10,
+			},
+		}, sharedFlags...),
+	},
+	{
+		Name:        "diff",
+		Usage:       "Compare two time periods",
+		Description: "Show the listening time delta and the rank changes of top artists, tracks and albums between two periods given with --period, e.g. 2023, 2024-05 or 2023-01..2023-06",
+		Action:      diffAction,
+		Flags: append([]cli.Flag{
+			exportPlaylistFlag,
+			&cli.StringSliceFlag{
+				Name:     "period",
+				Usage:    "Period to compare (YYYY, YYYY-MM, YYYY-MM-DD or FROM..TO), must be given twice",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "Number of entries of each top list",
+				Value: 10,
+			},
+		}, sharedFlags...),
+	},
+	{
+		Name:        "diversity",
+		Usage:       "Get listening diversity over time",
+		Description: "Show the Shannon entropy, Gini coefficient and top 10 share of play time across artists and tracks for each period, to see whether your taste is narrowing",
+		Action:      diversityAction,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "interval",
+				Usage: "Period to compute diversity for (day, week, month or year)",
+				Value: string(spotify.IntervalMonth),
+			},
+		}, sharedFlags...),
+	},
+	{
+		Name:        "obsessions",
+		Usage:       "Find tracks played on repeat",
+		Description: "Show the tracks played many times within a short window or back-to-back on repeat, with when each obsession started and faded",
+		Action:      obsessionsAction,
+		Flags: append([]cli.Flag{
+			exportPlaylistFlag,
+			&cli.IntFlag{
+				Name:  "min-plays",
+				Usage: "Minimum number of plays within the window for a track to be an obsession",
+				Value: 20,
+			},
+			&cli.IntFlag{
+				Name:  "window-days",
+				Usage: "Number of days plays are counted over",
+				Value: 7,
+			},
+			&cli.IntFlag{
+				Name:  "min-repeats",
+				Usage: "Minimum number of back-to-back plays for a track to be an obsession",
+				Value: 5,
+			},
+		}, sharedFlags...),
+	},
+	{
+		Name:        "timeline",
+		Usage:       "Plot minutes listened over time",
+		Description: "Plot the minutes listened per week or month as a bar chart, with a sparkline of the whole history",
+		Action:      timelineAction,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "interval",
+				Usage: "Period to plot minutes for (day, week, month or year)",
+				Value: string(spotify.IntervalMonth),
+			},
+			&cli.IntFlag{
+				Name:  "width",
+				Usage: "Width in characters of the longest bar",
+				Value: 50,
+			},
+		}, sharedFlags...),
+	},
+}
+
+// exportPlaylistFlag is given to the commands listing tracks to also write
+// them as a playlist.
+var exportPlaylistFlag = &cli.StringFlag{
+	Name:  "export-playlist",
+	Usage: "Also write the tracks as a playlist to this file, as XSPF (.xspf) or M3U8 with Spotify track URIs (.m3u8 or .m3u)",
 }
 
 var sharedFlags = []cli.Flag{
@@ -39,6 +181,14 @@ var sharedFlags = []cli.Flag{
 		Usage:    "Path to the SQLite database file",
 		Required: true,
 	},
+	&cli.StringSliceFlag{
+		Name:  "user",
+		Usage: "Only include streams of the given profile or username, can be repeated",
+	},
+	&cli.StringSliceFlag{
+		Name:  "source",
+		Usage: "Only include the streams imported from this source, e.g. spotify or lastfm, can be repeated",
+	},
 	&cli.BoolFlag{
 		Name:    "verbose",
 		Usage:   "Enable verbose logging",
@@ -2,61 +2,79 @@ package stats
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/urfave/cli/v3"
 	"github.com/vingarcia/ksql"
-	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
 
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/database"
 	"github.com/cadoween/decibel/internal/spotify"
 	"github.com/cadoween/decibel/pkg/iox"
+	"github.com/cadoween/decibel/pkg/playlist"
+	"github.com/cadoween/decibel/pkg/termchart"
+)
+
+var errInvalidFlags = errors.New("invalid flags")
+
+const (
+	// barWidth is the width in cells of the bar charts next to top lists.
+	barWidth = 20
+	// trendMonths is the number of months shown in the artist trends.
+	trendMonths = 12
+	// monthLayout is the layout of the periods of spotify.IntervalMonth.
+	monthLayout = "2006-01"
 )
 
 func topArtistsAction(ctx context.Context, c *cli.Command) error {
 	logger := zerolog.Ctx(ctx)
-	dbPath := c.String("db")
-	verbose := c.Bool("verbose")
 
-	if verbose {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	} else {
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
 	}
+	defer iox.Close(db, logger)
 
-	logger.Debug().
-		Str("db_path", dbPath).
-		Msg("Connecting to database")
-
-	db, err := ksqlite.New(ctx, dbPath, ksql.Config{})
+	filter, err := filterFromFlags(ctx, c, db)
 	if err != nil {
-		return fmt.Errorf("ksqlite.New: %w", err)
+		return fmt.Errorf("filterFromFlags: %w", err)
 	}
-	defer iox.Close(db, logger)
 
 	spotifySQLite := spotify.NewSQLite(db)
 
-	artists, err := spotifySQLite.GetTopArtistsByPlayTime(ctx)
+	artists, err := spotifySQLite.GetTopArtistsByPlayTime(ctx, filter)
 	if err != nil {
 		return fmt.Errorf("spotifySQLite.GetTopArtistsByPlayTime: %w", err)
 	}
 
-	_, _ = fmt.Printf("\nTop Artists by Play Time:\n\n")
-	_, _ = fmt.Printf("%-30s %-12s %-15s\n", "Artist", "Play Count", "Total Time")
-	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 60))
+	trends, err := getArtistTrends(ctx, spotifySQLite, filter, trendMonths)
+	if err != nil {
+		return fmt.Errorf("getArtistTrends: %w", err)
+	}
 
-	for _, artist := range artists {
-		duration := time.Duration(artist.TotalPlayTime) * time.Millisecond
-		hours := int(duration.Hours())
-		minutes := int(duration.Minutes()) % 60
+	palette := termchart.NewPalette(os.Stdout)
+	maxPlayTime := 0.0
+	if len(artists) > 0 {
+		maxPlayTime = float64(artists[0].TotalPlayTime)
+	}
 
-		_, _ = fmt.Printf("%-30s %-12d %dh %dm\n",
+	_, _ = fmt.Printf("\n%s\n\n", palette.Bold("Top Artists by Play Time:"))
+	_, _ = fmt.Printf("%-30s %-12s %-15s %-*s %s\n", "Artist", "Play Count", "Total Time", barWidth, "", "Last 12 Months")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 100))
+
+	for _, artist := range artists {
+		_, _ = fmt.Printf("%-30s %-12d %-15s %s %s\n",
 			truncateString(artist.Artist, 30),
 			artist.PlayCount,
-			hours,
-			minutes,
+			formatPlayTime(artist.TotalPlayTime),
+			palette.Green(termchart.Bar(float64(artist.TotalPlayTime), maxPlayTime, barWidth)),
+			palette.Cyan(termchart.Sparkline(trends[artist.Artist])),
 		)
 	}
 
@@ -65,47 +83,50 @@ func topArtistsAction(ctx context.Context, c *cli.Command) error {
 
 func topTracksAction(ctx context.Context, c *cli.Command) error {
 	logger := zerolog.Ctx(ctx)
-	dbPath := c.String("db")
-	verbose := c.Bool("verbose")
 
-	if verbose {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	} else {
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
 	}
+	defer iox.Close(db, logger)
 
-	logger.Debug().
-		Str("db_path", dbPath).
-		Msg("Connecting to database")
-
-	db, err := ksqlite.New(ctx, dbPath, ksql.Config{})
+	filter, err := filterFromFlags(ctx, c, db)
 	if err != nil {
-		return fmt.Errorf("ksqlite.New: %w", err)
+		return fmt.Errorf("filterFromFlags: %w", err)
 	}
-	defer iox.Close(db, logger)
 
 	spotifySQLite := spotify.NewSQLite(db)
 
-	tracks, err := spotifySQLite.GetTopTracksByPlayTime(ctx)
+	tracks, err := spotifySQLite.GetTopTracksByPlayTime(ctx, filter)
 	if err != nil {
 		return fmt.Errorf("spotifySQLite.GetTopTracksByPlayTime: %w", err)
 	}
 
-	_, _ = fmt.Printf("\nTop Tracks by Play Count:\n\n")
+	playlistTracks := make([]playlist.Track, 0, len(tracks))
+	for _, track := range tracks {
+		playlistTracks = append(playlistTracks, playlist.Track{Title: track.Track, Artist: track.Artist, Location: track.TrackURI})
+	}
+	if err := exportPlaylist(ctx, c, "Top Tracks", playlistTracks); err != nil {
+		return fmt.Errorf("exportPlaylist: %w", err)
+	}
+
+	palette := termchart.NewPalette(os.Stdout)
+	maxPlayTime := 0.0
+	if len(tracks) > 0 {
+		maxPlayTime = float64(tracks[0].TotalPlayTimeMS)
+	}
+
+	_, _ = fmt.Printf("\n%s\n\n", palette.Bold("Top Tracks by Play Count:"))
 	_, _ = fmt.Printf("%-40s %-30s %-12s %-15s\n", "Track", "Artist", "Play Count", "Total Time")
-	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 100))
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 120))
 
 	for _, track := range tracks {
-		duration := time.Duration(track.TotalPlayTimeMS) * time.Millisecond
-		hours := int(duration.Hours())
-		minutes := int(duration.Minutes()) % 60
-
-		_, _ = fmt.Printf("%-40s %-30s %-12d %dh %dm\n",
+		_, _ = fmt.Printf("%-40s %-30s %-12d %-15s %s\n",
 			truncateString(track.Track, 40),
 			truncateString(track.Artist, 30),
 			track.PlayCount,
-			hours,
-			minutes,
+			formatPlayTime(track.TotalPlayTimeMS),
+			palette.Green(termchart.Bar(float64(track.TotalPlayTimeMS), maxPlayTime, barWidth)),
 		)
 	}
 
@@ -114,41 +135,41 @@ func topTracksAction(ctx context.Context, c *cli.Command) error {
 
 func topAlbumsAction(ctx context.Context, c *cli.Command) error {
 	logger := zerolog.Ctx(ctx)
-	dbPath := c.String("db")
-	verbose := c.Bool("verbose")
 
-	if verbose {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	} else {
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
 	}
+	defer iox.Close(db, logger)
 
-	logger.Debug().
-		Str("db_path", dbPath).
-		Msg("Connecting to database")
-
-	db, err := ksqlite.New(ctx, dbPath, ksql.Config{})
+	filter, err := filterFromFlags(ctx, c, db)
 	if err != nil {
-		return fmt.Errorf("ksqlite.New: %w", err)
+		return fmt.Errorf("filterFromFlags: %w", err)
 	}
-	defer iox.Close(db, logger)
 
 	spotifySQLite := spotify.NewSQLite(db)
 
-	albums, err := spotifySQLite.GetTopAlbumsByPlayCount(ctx)
+	albums, err := spotifySQLite.GetTopAlbumsByPlayCount(ctx, filter)
 	if err != nil {
 		return fmt.Errorf("spotifySQLite.GetTopAlbumsByPlayCount: %w", err)
 	}
 
-	_, _ = fmt.Printf("\nTop Albums by Play Count:\n\n")
+	palette := termchart.NewPalette(os.Stdout)
+	maxCount := 0.0
+	if len(albums) > 0 {
+		maxCount = float64(albums[0].Count)
+	}
+
+	_, _ = fmt.Printf("\n%s\n\n", palette.Bold("Top Albums by Play Count:"))
 	_, _ = fmt.Printf("%-40s %-30s %-12s\n", "Album", "Artist", "Play Count")
-	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 85))
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 106))
 
 	for _, album := range albums {
-		_, _ = fmt.Printf("%-40s %-30s %-12d\n",
+		_, _ = fmt.Printf("%-40s %-30s %-12d %s\n",
 			truncateString(album.Album, 40),
 			truncateString(album.Artist, 30),
 			album.Count,
+			palette.Green(termchart.Bar(float64(album.Count), maxCount, barWidth)),
 		)
 	}
 
@@ -157,48 +178,708 @@ func topAlbumsAction(ctx context.Context, c *cli.Command) error {
 
 func mostSkippedTracksAction(ctx context.Context, c *cli.Command) error {
 	logger := zerolog.Ctx(ctx)
-	dbPath := c.String("db")
-	verbose := c.Bool("verbose")
 
-	if verbose {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	} else {
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
 	}
+	defer iox.Close(db, logger)
 
-	logger.Debug().
-		Str("db_path", dbPath).
-		Msg("Connecting to database")
-
-	db, err := ksqlite.New(ctx, dbPath, ksql.Config{})
+	filter, err := filterFromFlags(ctx, c, db)
 	if err != nil {
-		return fmt.Errorf("ksqlite.New: %w", err)
+		return fmt.Errorf("filterFromFlags: %w", err)
 	}
-	defer iox.Close(db, logger)
 
 	spotifySQLite := spotify.NewSQLite(db)
 
-	skippedTracks, err := spotifySQLite.GetMostSkippedTracks(ctx)
+	skippedTracks, err := spotifySQLite.GetMostSkippedTracks(ctx, filter)
 	if err != nil {
 		return fmt.Errorf("spotifySQLite.GetMostSkippedTracks: %w", err)
 	}
 
-	_, _ = fmt.Printf("\nMost Skipped Tracks (minimum 5 plays):\n\n")
+	playlistTracks := make([]playlist.Track, 0, len(skippedTracks))
+	for _, track := range skippedTracks {
+		playlistTracks = append(playlistTracks, playlist.Track{Title: track.TrackName, Artist: track.ArtistName, Location: track.TrackURI})
+	}
+	if err := exportPlaylist(ctx, c, "Most Skipped Tracks", playlistTracks); err != nil {
+		return fmt.Errorf("exportPlaylist: %w", err)
+	}
+
+	palette := termchart.NewPalette(os.Stdout)
+
+	_, _ = fmt.Printf("\n%s\n\n", palette.Bold("Most Skipped Tracks (minimum 5 plays):"))
 	_, _ = fmt.Printf("%-40s %-30s %-12s %-12s\n", "Track", "Artist", "Skip Count", "Skip Rate")
-	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 97))
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 118))
 
 	for _, track := range skippedTracks {
-		_, _ = fmt.Printf("%-40s %-30s %-12d %.1f%%\n",
+		_, _ = fmt.Printf("%-40s %-30s %-12d %-12s %s\n",
 			truncateString(track.TrackName, 40),
 			truncateString(track.ArtistName, 30),
 			track.SkipCount,
-			track.SkipRate*100,
+			fmt.Sprintf("%.1f%%", track.SkipRate*100),
+			palette.Red(termchart.Bar(track.SkipRate, 1, barWidth)),
+		)
+	}
+
+	return nil
+}
+
+func devicesAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	interval, err := spotify.ParseInterval(c.String("interval"))
+	if err != nil {
+		return fmt.Errorf("spotify.ParseInterval: %w", err)
+	}
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	filter, err := filterFromFlags(ctx, c, db)
+	if err != nil {
+		return fmt.Errorf("filterFromFlags: %w", err)
+	}
+
+	spotifySQLite := spotify.NewSQLite(db)
+
+	devices, err := spotifySQLite.GetPlayTimeByDevice(ctx, interval, filter)
+	if err != nil {
+		return fmt.Errorf("spotifySQLite.GetPlayTimeByDevice: %w", err)
+	}
+
+	_, _ = fmt.Printf("\nPlay Time by Device per %s:\n\n", interval)
+	_, _ = fmt.Printf("%-10s %-10s %-12s %-20s %-12s %-15s\n", "Period", "Device", "OS", "Manufacturer", "Play Count", "Total Time")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 90))
+
+	for _, device := range devices {
+		_, _ = fmt.Printf("%-10s %-10s %-12s %-20s %-12d %s\n",
+			device.Period,
+			device.DeviceClass,
+			truncateString(device.OS, 12),
+			truncateString(device.Manufacturer, 20),
+			device.PlayCount,
+			formatPlayTime(device.TotalPlayTimeMS),
+		)
+	}
+
+	return nil
+}
+
+func countriesAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	filter, err := filterFromFlags(ctx, c, db)
+	if err != nil {
+		return fmt.Errorf("filterFromFlags: %w", err)
+	}
+
+	spotifySQLite := spotify.NewSQLite(db)
+
+	countries, err := spotifySQLite.GetPlayTimeByCountry(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("spotifySQLite.GetPlayTimeByCountry: %w", err)
+	}
+
+	_, _ = fmt.Printf("\nPlay Time by Country:\n\n")
+	_, _ = fmt.Printf("%-10s %-12s %-15s\n", "Country", "Play Count", "Total Time")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 40))
+
+	for _, country := range countries {
+		_, _ = fmt.Printf("%-10s %-12d %s\n",
+			country.Country,
+			country.PlayCount,
+			formatPlayTime(country.TotalPlayTimeMS),
+		)
+	}
+
+	streams, err := spotifySQLite.GetCountryStreams(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("spotifySQLite.GetCountryStreams: %w", err)
+	}
+
+	timeline := spotify.BuildCountryTimeline(streams, int(c.Int("min-streams")))
+
+	_, _ = fmt.Printf("\nTravel Timeline:\n\n")
+	_, _ = fmt.Printf("%-10s %-12s %-12s %-8s %-10s %-15s\n", "Country", "From", "To", "Days", "Streams", "Total Time")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 75))
+
+	for _, period := range timeline {
+		_, _ = fmt.Printf("%-10s %-12s %-12s %-8d %-10d %s\n",
+			period.Country,
+			period.Start.Format(time.DateOnly),
+			period.End.Format(time.DateOnly),
+			int(period.End.Sub(period.Start).Hours()/24)+1,
+			period.StreamCount,
+			formatPlayTime(period.TotalPlayTimeMS),
+		)
+	}
+
+	return nil
+}
+
+func anomaliesAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	filter, err := filterFromFlags(ctx, c, db)
+	if err != nil {
+		return fmt.Errorf("filterFromFlags: %w", err)
+	}
+
+	spotifySQLite := spotify.NewSQLite(db)
+
+	streams, err := spotifySQLite.GetActivityStreams(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("spotifySQLite.GetActivityStreams: %w", err)
+	}
+
+	minScore := c.Float("min-score")
+	limit := int(c.Int("limit"))
+	anomalies := spotify.DetectAnomalies(streams, spotify.DefaultAnomalyOptions)
+
+	_, _ = fmt.Printf("\nAnomalies (minimum score %.2f):\n\n", minScore)
+	_, _ = fmt.Printf("%-17s %-20s %-15s %-6s %-6s %s\n", "Time", "Kind", "User", "Score", "Count", "Details")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 120))
+
+	shown := 0
+	for i := range anomalies {
+		if anomalies[i].Score < minScore || shown >= limit {
+			break
+		}
+		shown++
+
+		_, _ = fmt.Printf("%-17s %-20s %-15s %-6.2f %-6d %s\n",
+			anomalies[i].TS.Format("2006-01-02 15:04"),
+			anomalies[i].Kind,
+			truncateString(anomalies[i].Username, 15),
+			anomalies[i].Score,
+			anomalies[i].Occurrences,
+			anomalies[i].Description,
+		)
+	}
+
+	return nil
+}
+
+func compareAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	names := c.StringSlice("user")
+	if len(names) != 2 {
+		return fmt.Errorf("%w: compare needs exactly two --user flags, got %d", errInvalidFlags, len(names))
+	}
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	filter, err := filterFromFlags(ctx, c, db)
+	if err != nil {
+		return fmt.Errorf("filterFromFlags: %w", err)
+	}
+
+	// Profiles are resolved first, so a profile and its own username are
+	// caught too.
+	if filter.Usernames[0] == filter.Usernames[1] {
+		return fmt.Errorf("%w: compare needs two different users, %s and %s are both %s",
+			errInvalidFlags, names[0], names[1], filter.Usernames[0])
+	}
+
+	spotifySQLite := spotify.NewSQLite(db)
+
+	stats, err := spotifySQLite.GetArtistPlayTimeByUser(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("spotifySQLite.GetArtistPlayTimeByUser: %w", err)
+	}
+
+	comparison := spotify.CompareUsers(filter.Usernames[0], filter.Usernames[1], stats)
+	displayNames := map[string]string{
+		filter.Usernames[0]: names[0],
+		filter.Usernames[1]: names[1],
+	}
+	limit := int(c.Int("limit"))
+
+	_, _ = fmt.Printf("\nTaste Overlap of %s and %s:\n\n", names[0], names[1])
+	_, _ = fmt.Printf("%-30s %d\n", "Shared artists", len(comparison.Shared))
+	_, _ = fmt.Printf("%-30s %.1f%%\n", "Jaccard similarity", comparison.Jaccard*100)
+	_, _ = fmt.Printf("%-30s %.1f%%\n", "Cosine similarity (play time)", comparison.Cosine*100)
+
+	_, _ = fmt.Printf("\nShared Top Artists:\n\n")
+	_, _ = fmt.Printf("%-30s %-15s %-15s %-20s %-12s\n", "Artist", truncateString(names[0], 15), truncateString(names[1], 15), "Discovered By", "On")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 95))
+
+	for _, artist := range comparison.Shared[:min(limit, len(comparison.Shared))] {
+		discoveredOn := artist.FirstPlayedA
+		if artist.DiscoveredBy == filter.Usernames[1] {
+			discoveredOn = artist.FirstPlayedB
+		}
+
+		_, _ = fmt.Printf("%-30s %-15s %-15s %-20s %s\n",
+			truncateString(artist.Artist, 30),
+			formatPlayTime(artist.TotalPlayTimeA),
+			formatPlayTime(artist.TotalPlayTimeB),
+			truncateString(displayNames[artist.DiscoveredBy], 20),
+			discoveredOn.Format(time.DateOnly),
+		)
+	}
+
+	for i, only := range [][]spotify.UserArtistStats{comparison.OnlyA, comparison.OnlyB} {
+		_, _ = fmt.Printf("\nArtists Only %s Listens To:\n\n", names[i])
+		_, _ = fmt.Printf("%-30s %-12s %-15s\n", "Artist", "Play Count", "Total Time")
+		_, _ = fmt.Printf("%s\n", strings.Repeat("-", 60))
+
+		for _, artist := range only[:min(limit, len(only))] {
+			_, _ = fmt.Printf("%-30s %-12d %s\n",
+				truncateString(artist.Artist, 30),
+				artist.PlayCount,
+				formatPlayTime(artist.TotalPlayTimeMS),
+			)
+		}
+	}
+
+	return nil
+}
+
+func diffAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	rawPeriods := c.StringSlice("period")
+	if len(rawPeriods) != 2 {
+		return fmt.Errorf("%w: diff needs exactly two --period flags, got %d", errInvalidFlags, len(rawPeriods))
+	}
+
+	periods := make([]spotify.Period, 0, len(rawPeriods))
+	for _, rawPeriod := range rawPeriods {
+		period, err := spotify.ParsePeriod(rawPeriod)
+		if err != nil {
+			return fmt.Errorf("spotify.ParsePeriod: %w", err)
+		}
+		periods = append(periods, period)
+	}
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	filter, err := filterFromFlags(ctx, c, db)
+	if err != nil {
+		return fmt.Errorf("filterFromFlags: %w", err)
+	}
+	filter.Limit = int(c.Int("limit"))
+
+	spotifySQLite := spotify.NewSQLite(db)
+
+	var stats [2]spotify.PeriodSummary
+	for i, period := range periods {
+		if stats[i], err = spotifySQLite.GetPeriodSummary(ctx, filter.WithPeriod(period)); err != nil {
+			return fmt.Errorf("spotifySQLite.GetPeriodSummary %s: %w", period.Label, err)
+		}
+	}
+	totals := [2]spotify.Totals{stats[0].Totals, stats[1].Totals}
+
+	before, after := periods[0].Label, periods[1].Label
+
+	if c.String("export-playlist") != "" {
+		tracks, err := newTopTracks(ctx, spotifySQLite, filter.WithPeriod(periods[1]), stats[0].Tracks)
+		if err != nil {
+			return fmt.Errorf("newTopTracks: %w", err)
+		}
+		if err := exportPlaylist(ctx, c, "New in "+after, tracks); err != nil {
+			return fmt.Errorf("exportPlaylist: %w", err)
+		}
+	}
+
+	_, _ = fmt.Printf("\nListening in %s vs %s:\n\n", before, after)
+	_, _ = fmt.Printf("%-15s %-15s %-15s %-15s\n", "", truncateString(before, 15), truncateString(after, 15), "Change")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 60))
+	_, _ = fmt.Printf("%-15s %-15s %-15s %s\n", "Total Time",
+		formatPlayTime(totals[0].TotalPlayTimeMS), formatPlayTime(totals[1].TotalPlayTimeMS),
+		formatChange(totals[0].TotalPlayTimeMS, totals[1].TotalPlayTimeMS))
+	_, _ = fmt.Printf("%-15s %-15d %-15d %s\n", "Play Count",
+		totals[0].PlayCount, totals[1].PlayCount, formatChange(totals[0].PlayCount, totals[1].PlayCount))
+	_, _ = fmt.Printf("%-15s %-15d %-15d %s\n", "Artists",
+		totals[0].ArtistCount, totals[1].ArtistCount, formatChange(totals[0].ArtistCount, totals[1].ArtistCount))
+	_, _ = fmt.Printf("%-15s %-15d %-15d %s\n", "Tracks",
+		totals[0].TrackCount, totals[1].TrackCount, formatChange(totals[0].TrackCount, totals[1].TrackCount))
+
+	printRankChanges("Top Artists by Play Time", spotify.DiffRanks(stats[0].Artists, stats[1].Artists), formatPlayTime)
+	printRankChanges("Top Tracks by Play Time", spotify.DiffRanks(stats[0].Tracks, stats[1].Tracks), formatPlayTime)
+	printRankChanges("Top Albums by Play Count", spotify.DiffRanks(stats[0].Albums, stats[1].Albums), func(count int64) string {
+		return strconv.FormatInt(count, 10)
+	})
+
+	return nil
+}
+
+// newTopTracks returns the top tracks of the streams matching filter which
+// aren't among ranked, the top tracks of another period, as playlist tracks.
+func newTopTracks(ctx context.Context, spotifySQLite *spotify.SQLite, filter spotify.Filter, ranked []spotify.RankedItem) ([]playlist.Track, error) {
+	tracks, err := spotifySQLite.GetTopTracksByPlayTime(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("spotifySQLite.GetTopTracksByPlayTime: %w", err)
+	}
+
+	known := make(map[string]bool, len(ranked))
+	for _, item := range ranked {
+		known[item.Key] = true
+	}
+
+	var playlistTracks []playlist.Track
+	for i, item := range spotify.RankTracks(tracks) {
+		if known[item.Key] {
+			continue
+		}
+		playlistTracks = append(playlistTracks, playlist.Track{Title: tracks[i].Track, Artist: tracks[i].Artist, Location: tracks[i].TrackURI})
+	}
+
+	return playlistTracks, nil
+}
+
+// printRankChanges prints the movers of a top list between two periods.
+func printRankChanges(title string, changes []spotify.RankChange, formatValue func(int64) string) {
+	_, _ = fmt.Printf("\n%s:\n\n", title)
+	_, _ = fmt.Printf("%-6s %-8s %-50s %-12s %-12s\n", "Rank", "Move", "Name", "Before", "After")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 92))
+
+	for _, change := range changes {
+		rank, move := strconv.Itoa(change.RankAfter), ""
+		valueBefore, valueAfter := formatValue(change.ValueBefore), formatValue(change.ValueAfter)
+
+		switch {
+		case change.IsNew():
+			move, valueBefore = "NEW", "-"
+		case change.IsDropped():
+			rank, move, valueAfter = "-", "OUT", "-"
+		case change.Delta() > 0:
+			move = fmt.Sprintf("↑ %d", change.Delta())
+		case change.Delta() < 0:
+			move = fmt.Sprintf("↓ %d", -change.Delta())
+		default:
+			move = "="
+		}
+
+		_, _ = fmt.Printf("%-6s %-8s %-50s %-12s %-12s\n",
+			rank,
+			move,
+			truncateString(change.Label, 50),
+			valueBefore,
+			valueAfter,
+		)
+	}
+}
+
+// formatChange formats the relative change from before to after.
+func formatChange(before, after int64) string {
+	if before == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%%", float64(after-before)/float64(before)*100)
+}
+
+func diversityAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	interval, err := spotify.ParseInterval(c.String("interval"))
+	if err != nil {
+		return fmt.Errorf("spotify.ParseInterval: %w", err)
+	}
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	filter, err := filterFromFlags(ctx, c, db)
+	if err != nil {
+		return fmt.Errorf("filterFromFlags: %w", err)
+	}
+
+	spotifySQLite := spotify.NewSQLite(db)
+
+	artistPlayTimes, err := spotifySQLite.GetArtistPlayTimeByPeriod(ctx, interval, filter)
+	if err != nil {
+		return fmt.Errorf("spotifySQLite.GetArtistPlayTimeByPeriod: %w", err)
+	}
+
+	trackPlayTimes, err := spotifySQLite.GetTrackPlayTimeByPeriod(ctx, interval, filter)
+	if err != nil {
+		return fmt.Errorf("spotifySQLite.GetTrackPlayTimeByPeriod: %w", err)
+	}
+
+	trackDiversities := make(map[string]spotify.Diversity)
+	for _, diversity := range spotify.ComputeDiversity(trackPlayTimes) {
+		trackDiversities[diversity.Period] = diversity
+	}
+
+	_, _ = fmt.Printf("\nListening Diversity per %s:\n\n", interval)
+	_, _ = fmt.Printf("%-10s | %-8s %-8s %-6s %-7s | %-8s %-8s %-6s %-7s\n",
+		"", "Artists", "", "", "", "Tracks", "", "", "")
+	_, _ = fmt.Printf("%-10s | %-8s %-8s %-6s %-7s | %-8s %-8s %-6s %-7s\n",
+		"Period", "Count", "Entropy", "Gini", "Top 10", "Count", "Entropy", "Gini", "Top 10")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 82))
+
+	for _, artists := range spotify.ComputeDiversity(artistPlayTimes) {
+		tracks := trackDiversities[artists.Period]
+
+		_, _ = fmt.Printf("%-10s | %-8d %-8.2f %-6.2f %-6.1f%% | %-8d %-8.2f %-6.2f %-6.1f%%\n",
+			artists.Period,
+			artists.Count, artists.Entropy, artists.Gini, artists.Top10Share*100,
+			tracks.Count, tracks.Entropy, tracks.Gini, tracks.Top10Share*100,
 		)
 	}
 
 	return nil
 }
 
+func obsessionsAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	opts := spotify.DefaultObsessionOptions
+	opts.MinPlays = int(c.Int("min-plays"))
+	opts.Window = time.Duration(c.Int("window-days")) * 24 * time.Hour
+	opts.MinRepeats = int(c.Int("min-repeats"))
+	if opts.MinPlays < 1 || opts.Window <= 0 || opts.MinRepeats < 2 {
+		return fmt.Errorf("%w: --min-plays and --window-days must be positive and --min-repeats at least 2", errInvalidFlags)
+	}
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	filter, err := filterFromFlags(ctx, c, db)
+	if err != nil {
+		return fmt.Errorf("filterFromFlags: %w", err)
+	}
+
+	spotifySQLite := spotify.NewSQLite(db)
+
+	streams, err := spotifySQLite.GetTrackStreams(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("spotifySQLite.GetTrackStreams: %w", err)
+	}
+
+	obsessions := spotify.DetectObsessions(streams, opts)
+
+	// A track can be an obsession more than once, it is listed once.
+	var playlistTracks []playlist.Track
+	seen := make(map[string]bool)
+	for i := range obsessions {
+		key := obsessions[i].Track + "\x00" + obsessions[i].Artist
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		playlistTracks = append(playlistTracks, playlist.Track{
+			Title:    obsessions[i].Track,
+			Artist:   obsessions[i].Artist,
+			Location: obsessions[i].TrackURI,
+		})
+	}
+	if err := exportPlaylist(ctx, c, "Obsessions", playlistTracks); err != nil {
+		return fmt.Errorf("exportPlaylist: %w", err)
+	}
+
+	_, _ = fmt.Printf("\nObsessions (%d+ plays in %d days or %d+ in a row):\n\n",
+		opts.MinPlays, c.Int("window-days"), opts.MinRepeats)
+	_, _ = fmt.Printf("%-10s %-10s %-6s %-6s %-6s %-15s %-30s %s\n",
+		"Started", "Faded", "Plays", "Peak", "Run", "User", "Track", "Artist")
+	_, _ = fmt.Printf("%s\n", strings.Repeat("-", 120))
+
+	for i := range obsessions {
+		_, _ = fmt.Printf("%-10s %-10s %-6d %-6d %-6d %-15s %-30s %s\n",
+			obsessions[i].Start.Format(time.DateOnly),
+			obsessions[i].End.Format(time.DateOnly),
+			obsessions[i].Plays,
+			obsessions[i].PeakPlays,
+			obsessions[i].LongestRun,
+			truncateString(obsessions[i].Username, 15),
+			truncateString(obsessions[i].Track, 30),
+			truncateString(obsessions[i].Artist, 30),
+		)
+	}
+
+	return nil
+}
+
+func timelineAction(ctx context.Context, c *cli.Command) error {
+	logger := zerolog.Ctx(ctx)
+
+	interval, err := spotify.ParseInterval(c.String("interval"))
+	if err != nil {
+		return fmt.Errorf("spotify.ParseInterval: %w", err)
+	}
+
+	db, err := database.OpenFromFlags(ctx, c)
+	if err != nil {
+		return fmt.Errorf("database.OpenFromFlags: %w", err)
+	}
+	defer iox.Close(db, logger)
+
+	filter, err := filterFromFlags(ctx, c, db)
+	if err != nil {
+		return fmt.Errorf("filterFromFlags: %w", err)
+	}
+
+	spotifySQLite := spotify.NewSQLite(db)
+
+	periods, err := spotifySQLite.GetPlayTimeByPeriod(ctx, interval, filter)
+	if err != nil {
+		return fmt.Errorf("spotifySQLite.GetPlayTimeByPeriod: %w", err)
+	}
+
+	palette := termchart.NewPalette(os.Stdout)
+	minutes := make([]float64, 0, len(periods))
+	maxMinutes := 0.0
+	for _, period := range periods {
+		minutes = append(minutes, float64(period.TotalPlayTimeMS)/float64(time.Minute/time.Millisecond))
+		maxMinutes = max(maxMinutes, minutes[len(minutes)-1])
+	}
+
+	_, _ = fmt.Printf("\n%s\n\n", palette.Bold(fmt.Sprintf("Minutes Listened per %s:", interval)))
+	_, _ = fmt.Printf("%s\n\n", palette.Cyan(termchart.Sparkline(minutes)))
+
+	for i, period := range periods {
+		_, _ = fmt.Printf("%-10s %s %8.0f min\n",
+			period.Period,
+			palette.Green(termchart.Bar(minutes[i], maxMinutes, int(c.Int("width")))),
+			minutes[i],
+		)
+	}
+
+	return nil
+}
+
+// getArtistTrends gets the monthly play time of every artist over the last
+// calendar months up to the last month with streams, oldest first. Months
+// without streams are zero.
+func getArtistTrends(ctx context.Context, spotifySQLite *spotify.SQLite, filter spotify.Filter, months int) (map[string][]float64, error) {
+	playTimes, err := spotifySQLite.GetArtistPlayTimeByPeriod(ctx, spotify.IntervalMonth, filter)
+	if err != nil {
+		return nil, fmt.Errorf("spotifySQLite.GetArtistPlayTimeByPeriod: %w", err)
+	}
+
+	var last string
+	for _, playTime := range playTimes {
+		last = max(last, playTime.Period)
+	}
+	if last == "" {
+		return map[string][]float64{}, nil
+	}
+
+	lastMonth, err := time.Parse(monthLayout, last)
+	if err != nil {
+		return nil, fmt.Errorf("time.Parse: %w", err)
+	}
+
+	periods := make([]string, months)
+	for i := range periods {
+		periods[i] = lastMonth.AddDate(0, i-months+1, 0).Format(monthLayout)
+	}
+
+	index := make(map[string]int, len(periods))
+	for i, period := range periods {
+		index[period] = i
+	}
+
+	trends := make(map[string][]float64)
+	for _, playTime := range playTimes {
+		i, ok := index[playTime.Period]
+		if !ok {
+			continue
+		}
+		if trends[playTime.Key] == nil {
+			trends[playTime.Key] = make([]float64, len(periods))
+		}
+		trends[playTime.Key][i] = float64(playTime.TotalPlayTimeMS)
+	}
+
+	return trends, nil
+}
+
+// exportPlaylist writes tracks as a playlist titled title to the file given
+// by the --export-playlist flag, if any, in the format of its extension.
+func exportPlaylist(ctx context.Context, c *cli.Command, title string, tracks []playlist.Track) error {
+	logger := zerolog.Ctx(ctx)
+
+	path := c.String("export-playlist")
+	if path == "" {
+		return nil
+	}
+
+	format, err := playlist.FormatFromPath(path)
+	if err != nil {
+		return fmt.Errorf("playlist.FormatFromPath: %w", err)
+	}
+
+	f, err := os.Create(path) //nolint:gosec // The path is given by the user on purpose.
+	if err != nil {
+		return fmt.Errorf("os.Create: %w", err)
+	}
+	defer iox.Close(f, logger)
+
+	if err := playlist.Write(f, format, playlist.Playlist{Title: title, Tracks: tracks}); err != nil {
+		return fmt.Errorf("playlist.Write: %w", err)
+	}
+
+	withoutURI := 0
+	for _, track := range tracks {
+		if track.Location == "" {
+			withoutURI++
+		}
+	}
+	if withoutURI > 0 && format == playlist.FormatM3U8 {
+		logger.Warn().
+			Int("count", withoutURI).
+			Msg("Left tracks without a Spotify track URI out of the playlist")
+	}
+
+	logger.Info().
+		Int("tracks", len(tracks)).
+		Str("file", path).
+		Msg("Successfully exported playlist")
+
+	return nil
+}
+
+// filterFromFlags builds the statistics filter from the shared flags,
+// resolving profile names given with --user to their usernames and keeping
+// the sources given with --source.
+func filterFromFlags(ctx context.Context, c *cli.Command, db ksql.Provider) (spotify.Filter, error) {
+	usernames, err := decibel.NewUserService(db).ResolveUsernames(ctx, c.StringSlice("user"))
+	if err != nil {
+		return spotify.Filter{}, fmt.Errorf("decibel.NewUserService(db).ResolveUsernames: %w", err)
+	}
+
+	return spotify.Filter{Usernames: usernames, Sources: c.StringSlice("source")}, nil
+}
+
+// formatPlayTime formats a play time in milliseconds as hours and minutes.
+func formatPlayTime(ms int64) string {
+	duration := time.Duration(ms) * time.Millisecond
+	return fmt.Sprintf("%dh %dm", int(duration.Hours()), int(duration.Minutes())%60)
+}
+
 // truncateString cuts a string if it's longer than maxLen and adds "..." at the
 // end.
 func truncateString(s string, maxLen int) string {
@@ -0,0 +1,16 @@
+package youtubemusic
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/cadoween/decibel/cmd/youtubemusic/seeder"
+)
+
+var Commands = []*cli.Command{
+	{
+		Name:        "seeder",
+		Usage:       "YouTube Music history data seeder",
+		Description: "Seed your YouTube Music history from Google Takeout in the local database, next to your other services",
+		Commands:    seeder.Commands,
+	},
+}
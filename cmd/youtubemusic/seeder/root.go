@@ -0,0 +1,13 @@
+package seeder
+
+import "github.com/urfave/cli/v3"
+
+var Commands = []*cli.Command{
+	{
+		Name:        "run",
+		Usage:       "Run YouTube Music history data seeder",
+		Description: "Reads the YouTube Music entries of watch-history.json from Google Takeout and seeds them into the SQLite database with an estimated play time",
+		Action:      runAction,
+		Flags:       runFlags,
+	},
+}
@@ -0,0 +1,332 @@
+// Package applemusic reads the play activity of Apple's privacy data export
+// and maps it into listens, so statistics can span Apple Music and other
+// services.
+package applemusic
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+// Source is the source recorded for streams and imports of Apple Music
+// exports.
+const Source = "applemusic"
+
+// FileName is the name of the play activity file in Apple's export.
+const FileName = "Apple Music Play Activity.csv"
+
+var (
+	ErrMissingColumn    = errors.New("missing column")
+	ErrInvalidRow       = errors.New("invalid row")
+	ErrMissingTimestamp = errors.New("missing timestamp")
+)
+
+// playEnd is the event type of the rows recording a finished play, other
+// events such as PLAY_START or LYRIC_DISPLAY duplicate or don't describe
+// plays.
+const playEnd = "PLAY_END"
+
+// endReasons maps the end reasons of Apple Music to the ones found in the
+// Spotify streaming history, so reason_end statistics compare across
+// services.
+var endReasons = map[string]string{
+	"NATURAL_END_OF_TRACK":                      "trackdone",
+	"TRACK_SKIPPED_FORWARDS":                    "fwdbtn",
+	"TRACK_SKIPPED_BACKWARDS":                   "backbtn",
+	"MANUALLY_SELECTED_PLAYBACK_OF_A_DIFF_ITEM": "clickrow",
+	"PLAYBACK_MANUALLY_PAUSED":                  "endplay",
+	"PLAYBACK_STOPPED_DUE_TO_SESSION_TIMEOUT":   "endplay",
+	"EXITED_APPLICATION":                        "endplay",
+	"FAILED_TO_LOAD":                            "unexpected-exit",
+}
+
+// Play is a track played on Apple Music. TS is when the play ended.
+type Play struct {
+	TS        time.Time
+	Artist    string
+	Album     string
+	Track     string
+	MSPlayed  int
+	EndReason string
+	Offline   bool
+	// BuildVersion is the client that played the track, e.g.
+	// "Music/1.2 iOS/16.1 model/iPhone14,2 build/20B82".
+	BuildVersion string
+	IPAddress    string
+}
+
+// Skipped reports whether the track was skipped forwards or backwards.
+func (p Play) Skipped() bool {
+	return strings.HasPrefix(p.EndReason, "TRACK_SKIPPED")
+}
+
+// ReadFile reads the plays of the play activity file at path.
+func ReadFile(path string) ([]Play, error) {
+	f, err := os.Open(path) //nolint:gosec // The path is given by the user on purpose.
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer iox.Close(f)
+
+	return ReadCSV(f)
+}
+
+// columns are the indexes of the columns of the play activity file, -1 when
+// missing. Column names changed across exports, so several are tried.
+type columns struct {
+	eventType, start, end, received, artist, album, track,
+	playDuration, startPosition, endPosition, endReason,
+	offline, buildVersion, ipAddress int
+}
+
+func findColumns(header []string) (columns, error) {
+	find := func(names ...string) int {
+		for _, name := range names {
+			if i := slices.IndexFunc(header, func(column string) bool {
+				return strings.EqualFold(strings.TrimSpace(column), name)
+			}); i >= 0 {
+				return i
+			}
+		}
+		return -1
+	}
+
+	c := columns{
+		eventType:     find("Event Type"),
+		start:         find("Event Start Timestamp"),
+		end:           find("Event End Timestamp"),
+		received:      find("Event Received Timestamp"),
+		artist:        find("Artist Name", "Container Artist Name"),
+		album:         find("Album Name", "Container Album Name"),
+		track:         find("Song Name", "Content Name"),
+		playDuration:  find("Play Duration Milliseconds"),
+		startPosition: find("Start Position In Milliseconds"),
+		endPosition:   find("End Position In Milliseconds"),
+		endReason:     find("End Reason Type"),
+		offline:       find("Offline"),
+		buildVersion:  find("Build Version"),
+		ipAddress:     find("Client IP Address"),
+	}
+
+	switch {
+	case c.track < 0:
+		return columns{}, fmt.Errorf("%w: Song Name", ErrMissingColumn)
+	case c.start < 0 && c.end < 0 && c.received < 0:
+		return columns{}, fmt.Errorf("%w: Event End Timestamp", ErrMissingColumn)
+	}
+
+	return c, nil
+}
+
+// ReadCSV reads the plays of a play activity file. Only the rows recording
+// the end of a play of a named track are kept, play activity also records
+// the start of plays, lyrics views and events without any track.
+func ReadCSV(r io.Reader) ([]Play, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reader.Read: %w", err)
+	}
+
+	c, err := findColumns(header)
+	if err != nil {
+		return nil, fmt.Errorf("findColumns: %w", err)
+	}
+
+	var plays []Play
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reader.Read: %w", err)
+		}
+
+		value := func(column int) string {
+			if column < 0 || column >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[column])
+		}
+
+		if eventType := value(c.eventType); c.eventType >= 0 && eventType != playEnd || value(c.track) == "" {
+			continue
+		}
+
+		play, err := parsePlay(value, c)
+		if err != nil {
+			return nil, fmt.Errorf("%w %d: %w", ErrInvalidRow, row, err)
+		}
+
+		plays = append(plays, play)
+	}
+
+	return plays, nil
+}
+
+func parsePlay(value func(int) string, c columns) (Play, error) {
+	play := Play{
+		Artist:       value(c.artist),
+		Album:        value(c.album),
+		Track:        value(c.track),
+		EndReason:    value(c.endReason),
+		Offline:      strings.EqualFold(value(c.offline), "true"),
+		BuildVersion: value(c.buildVersion),
+		IPAddress:    value(c.ipAddress),
+	}
+
+	var err error
+	if play.MSPlayed, err = parseMilliseconds(value(c.playDuration)); err != nil {
+		return Play{}, err
+	}
+	if play.MSPlayed == 0 {
+		// Older exports only have the positions the play started and ended at.
+		start, err := parseMilliseconds(value(c.startPosition))
+		if err != nil {
+			return Play{}, err
+		}
+		end, err := parseMilliseconds(value(c.endPosition))
+		if err != nil {
+			return Play{}, err
+		}
+		play.MSPlayed = max(end-start, 0)
+	}
+
+	end, err := parseTimestamp(value(c.end))
+	if err != nil {
+		return Play{}, err
+	}
+	start, err := parseTimestamp(value(c.start))
+	if err != nil {
+		return Play{}, err
+	}
+	received, err := parseTimestamp(value(c.received))
+	if err != nil {
+		return Play{}, err
+	}
+
+	switch {
+	case !end.IsZero():
+		play.TS = end
+	case !start.IsZero():
+		play.TS = start.Add(time.Duration(play.MSPlayed) * time.Millisecond)
+	case !received.IsZero():
+		play.TS = received
+	default:
+		return Play{}, ErrMissingTimestamp
+	}
+
+	return play, nil
+}
+
+// parseTimestamp parses the ISO 8601 timestamps of the export, e.g.
+// "2023-01-14T18:03:25.364Z". Empty values give the zero time.
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time.Parse: %w", err)
+	}
+
+	return ts.UTC(), nil
+}
+
+// parseMilliseconds parses a duration in milliseconds, which the export
+// sometimes writes with a decimal part. Empty and negative values give 0.
+func parseMilliseconds(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	ms, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("strconv.ParseFloat: %w", err)
+	}
+
+	return max(int(ms), 0), nil
+}
+
+// Platform turns a build version such as
+// "Music/1.2 iOS/16.1 model/iPhone14,2 build/20B82" into the platform form
+// spotify.ParseDevice understands, "iOS 16.1 (iPhone14,2)", or returns it
+// unchanged.
+func Platform(buildVersion string) string {
+	fields := strings.Fields(buildVersion)
+	if len(fields) < 2 {
+		return buildVersion
+	}
+
+	osName, osVersion, _ := strings.Cut(fields[1], "/")
+	platform := strings.TrimSpace(osName + " " + osVersion)
+
+	for _, field := range fields[2:] {
+		if model, ok := strings.CutPrefix(field, "model/"); ok {
+			return platform + " (" + model + ")"
+		}
+	}
+
+	return platform
+}
+
+// ToListens maps plays to listens of username.
+func ToListens(plays []Play, username string) []listen.Listen {
+	listens := make([]listen.Listen, 0, len(plays))
+	for _, play := range plays {
+		platform := Platform(play.BuildVersion)
+
+		reasonEnd, ok := endReasons[play.EndReason]
+		if !ok {
+			reasonEnd = strings.ToLower(play.EndReason)
+		}
+
+		listens = append(listens, listen.Listen{
+			EndedAt:   play.TS,
+			Username:  username,
+			Platform:  platform,
+			Played:    time.Duration(play.MSPlayed) * time.Millisecond,
+			IPAddr:    play.IPAddress,
+			UserAgent: play.BuildVersion,
+			Track:     play.Track,
+			Artist:    play.Artist,
+			Album:     play.Album,
+			ReasonEnd: reasonEnd,
+			Skipped:   play.Skipped(),
+			Offline:   play.Offline,
+			Source:    Source,
+		})
+	}
+
+	return listens
+}
+
+// FindFile returns path when it is a file, or the path of the play activity
+// file found anywhere under it when it is a directory, such as the unzipped
+// privacy data export.
+func FindFile(path string) (string, error) {
+	found, err := iox.FindFile(path, FileName)
+	if err != nil {
+		return "", fmt.Errorf("iox.FindFile: %w", err)
+	}
+
+	return found, nil
+}
@@ -0,0 +1,159 @@
+package applemusic_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/applemusic"
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+const header = "Apple Id Number,Artist Name,Build Version,Client IP Address,Content Name,End Position In Milliseconds," +
+	"End Reason Type,Event End Timestamp,Event Start Timestamp,Event Type,Offline,Play Duration Milliseconds," +
+	"Start Position In Milliseconds,Album Name\n"
+
+func TestReadCSV(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []applemusic.Play
+		wantErr error
+	}{
+		{
+			name: "keeps finished plays of tracks",
+			input: header +
+				`1,Björk,"Music/1.2 iOS/16.1 model/iPhone14,2 build/20B82",1.2.3.4,Jóga,300000,NATURAL_END_OF_TRACK,` +
+				"2024-03-01T22:05:00.000Z,2024-03-01T22:00:00.000Z,PLAY_END,false,300000,0,Homogenic\n" +
+				`1,Björk,,,Jóga,,,,2024-03-01T22:00:00.000Z,PLAY_START,,,,Homogenic` + "\n" +
+				`1,,,,,,,,2024-03-01T22:00:00.000Z,LYRIC_DISPLAY,,,,` + "\n" +
+				`1,Portishead,,,Roads,65000.5,TRACK_SKIPPED_FORWARDS,,2024-03-01T22:06:00.000Z,PLAY_END,true,,5000,Dummy` + "\n",
+			want: []applemusic.Play{
+				{
+					TS:           time.Date(2024, 3, 1, 22, 5, 0, 0, time.UTC),
+					Artist:       "Björk",
+					Album:        "Homogenic",
+					Track:        "Jóga",
+					MSPlayed:     300000,
+					EndReason:    "NATURAL_END_OF_TRACK",
+					BuildVersion: "Music/1.2 iOS/16.1 model/iPhone14,2 build/20B82",
+					IPAddress:    "1.2.3.4",
+				},
+				{
+					TS:        time.Date(2024, 3, 1, 22, 7, 0, 0, time.UTC),
+					Artist:    "Portishead",
+					Album:     "Dummy",
+					Track:     "Roads",
+					MSPlayed:  60000,
+					EndReason: "TRACK_SKIPPED_FORWARDS",
+					Offline:   true,
+				},
+			},
+		},
+		{
+			name:  "empty file",
+			input: "",
+		},
+		{
+			name:    "missing track column",
+			input:   "Artist Name,Event End Timestamp\n",
+			wantErr: applemusic.ErrMissingColumn,
+		},
+		{
+			name:    "invalid timestamp",
+			input:   "Song Name,Event End Timestamp\nJóga,yesterday\n",
+			wantErr: applemusic.ErrInvalidRow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := applemusic.ReadCSV(strings.NewReader(tt.input))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFindFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	_, err := applemusic.FindFile(dir)
+	require.ErrorIs(t, err, iox.ErrFileNotFound)
+
+	nested := filepath.Join(dir, "Apple Media Services information", "Apple Music Activity")
+	require.NoError(t, os.MkdirAll(nested, 0o750))
+	path := filepath.Join(nested, applemusic.FileName)
+	require.NoError(t, os.WriteFile(path, []byte(header), 0o600))
+
+	got, err := applemusic.FindFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, path, got)
+
+	got, err = applemusic.FindFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, got)
+}
+
+func TestPlatform(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "iOS 16.1 (iPhone14,2)", applemusic.Platform("Music/1.2 iOS/16.1 model/iPhone14,2 build/20B82"))
+	assert.Equal(t, "macOS 13.2", applemusic.Platform("Music/1.3 macOS/13.2 build/22D49"))
+	assert.Equal(t, "unknown", applemusic.Platform("unknown"))
+}
+
+func TestToListens(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2024, 3, 1, 22, 5, 0, 0, time.UTC)
+	got := applemusic.ToListens([]applemusic.Play{{
+		TS:           ts,
+		Artist:       "Björk",
+		Album:        "Homogenic",
+		Track:        "Jóga",
+		MSPlayed:     120000,
+		EndReason:    "TRACK_SKIPPED_FORWARDS",
+		BuildVersion: "Music/1.2 iPadOS/16.1 model/iPad13,4 build/20B82",
+	}}, "alice")
+
+	assert.Equal(t, []listen.Listen{{
+		EndedAt:   ts,
+		Username:  "alice",
+		Platform:  "iPadOS 16.1 (iPad13,4)",
+		Played:    2 * time.Minute,
+		UserAgent: "Music/1.2 iPadOS/16.1 model/iPad13,4 build/20B82",
+		Track:     "Jóga",
+		Artist:    "Björk",
+		Album:     "Homogenic",
+		ReasonEnd: "fwdbtn",
+		Skipped:   true,
+		Source:    applemusic.Source,
+	}}, got)
+
+	// The platform is one spotify.ParseDevice understands.
+	assert.Equal(t, spotify.Device{
+		Class:        spotify.DeviceClassTablet,
+		OS:           "iOS",
+		OSVersion:    "16.1",
+		Manufacturer: "Apple",
+	}, spotify.FromListens(got)[0].Device)
+}
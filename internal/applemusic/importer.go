@@ -0,0 +1,53 @@
+package applemusic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"strings"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/pkg/ext"
+)
+
+func init() {
+	listen.Register(Importer{})
+}
+
+// Importer reads the play activity of Apple's privacy data export as
+// listens.
+type Importer struct{}
+
+func (Importer) Format() listen.Format {
+	return listen.Format{
+		Name:        Source,
+		Source:      Source,
+		Description: "Apple Music Play Activity.csv of Apple's privacy data export",
+	}
+}
+
+// Detect recognizes CSV files with the event columns of the play activity.
+func (Importer) Detect(path string, head []byte) bool {
+	if !strings.EqualFold(filepath.Ext(path), ext.CSV) {
+		return false
+	}
+
+	header, _, _ := bytes.Cut(head, []byte("\n"))
+	return bytes.Contains(header, []byte("Event Type")) &&
+		(bytes.Contains(header, []byte("Song Name")) || bytes.Contains(header, []byte("Content Name")))
+}
+
+func (Importer) Open(_ context.Context, path string, opts listen.Options) (iter.Seq2[listen.Listen, error], error) {
+	if opts.Username == "" {
+		return nil, listen.ErrUsernameRequired
+	}
+
+	plays, err := ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ReadFile: %w", err)
+	}
+
+	return listen.All(ToListens(plays, opts.Username)), nil
+}
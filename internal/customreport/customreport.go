@@ -0,0 +1,302 @@
+// Package customreport loads reports defined in a configuration file, either
+// as SQL or as a structured aggregation of the streams, so new statistics
+// don't require any code.
+package customreport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cadoween/decibel/internal/query"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/tabular"
+)
+
+var (
+	ErrReportNotFound      = errors.New("report not found")
+	ErrInvalidDefinition   = errors.New("invalid report definition")
+	ErrInvalidColumnFormat = errors.New("invalid column format")
+	ErrMissingColumn       = errors.New("column not found in the result")
+)
+
+// ColumnFormat is the way the values of a column are displayed.
+type ColumnFormat string
+
+const (
+	// ColumnFormatRaw leaves values as returned by the database.
+	ColumnFormatRaw ColumnFormat = ""
+	// ColumnFormatDuration formats milliseconds as hours and minutes.
+	ColumnFormatDuration ColumnFormat = "duration"
+	// ColumnFormatPercent formats a ratio between 0 and 1 as a percentage.
+	ColumnFormatPercent ColumnFormat = "percent"
+	// ColumnFormatDate keeps the date of timestamps only.
+	ColumnFormatDate ColumnFormat = "date"
+)
+
+// Config is the content of a report configuration file.
+type Config struct {
+	Reports []Definition `json:"reports"`
+}
+
+// Definition describes a report. Exactly one of SQL and Query must be set.
+type Definition struct {
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// SQL is a read-only statement run as-is.
+	SQL string `json:"sql,omitempty"`
+	// Query is a structured aggregation of the streams, which unlike SQL
+	// can be filtered by user and period from the command line.
+	Query *Query `json:"query,omitempty"`
+
+	// Columns selects, orders, renames and formats the columns of the
+	// result. Every column is shown as-is when empty.
+	Columns []Column `json:"columns,omitempty"`
+	// Format is the default output format: table, csv or json.
+	Format string `json:"format,omitempty"`
+}
+
+// Query is the structured form of a report, see spotify.Aggregation.
+type Query struct {
+	GroupBy       []string `json:"group_by,omitempty"`
+	Metrics       []string `json:"metrics"`
+	Users         []string `json:"users,omitempty"`
+	Period        string   `json:"period,omitempty"`
+	Skipped       *bool    `json:"skipped,omitempty"`
+	Shuffle       *bool    `json:"shuffle,omitempty"`
+	Offline       *bool    `json:"offline,omitempty"`
+	MinPlayTimeMS int64    `json:"min_play_time_ms,omitempty"`
+	OrderBy       string   `json:"order_by,omitempty"`
+	Ascending     bool     `json:"ascending,omitempty"`
+	Limit         int      `json:"limit,omitempty"`
+}
+
+// Column is a column of the report output.
+type Column struct {
+	// Name is the name of the column in the query result.
+	Name string `json:"name"`
+	// Title is the header of the column, Name by default.
+	Title  string       `json:"title,omitempty"`
+	Format ColumnFormat `json:"format,omitempty"`
+}
+
+// DefaultPath returns the path of the configuration file used when none is
+// given: decibel/reports.json in the user configuration directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("os.UserConfigDir: %w", err)
+	}
+
+	return filepath.Join(dir, "decibel", "reports.json"), nil
+}
+
+// Load reads and validates the configuration file at path.
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // The path is given by the user on purpose.
+	if err != nil {
+		return Config{}, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	names := make(map[string]bool, len(config.Reports))
+	for _, definition := range config.Reports {
+		if err := definition.Validate(); err != nil {
+			return Config{}, fmt.Errorf("definition.Validate: %w", err)
+		}
+
+		if names[definition.Name] {
+			return Config{}, fmt.Errorf("%w: duplicate name %q", ErrInvalidDefinition, definition.Name)
+		}
+		names[definition.Name] = true
+	}
+
+	return config, nil
+}
+
+// Find returns the report named name.
+func (c Config) Find(name string) (Definition, error) {
+	for _, definition := range c.Reports {
+		if definition.Name == name {
+			return definition, nil
+		}
+	}
+
+	return Definition{}, fmt.Errorf("%w: %q", ErrReportNotFound, name)
+}
+
+// Validate checks the definition can be run.
+func (d Definition) Validate() error {
+	if strings.TrimSpace(d.Name) == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalidDefinition)
+	}
+
+	switch {
+	case d.SQL != "" && d.Query != nil:
+		return fmt.Errorf("%w: %q has both sql and query", ErrInvalidDefinition, d.Name)
+	case d.SQL != "":
+		if !query.IsReadOnly(d.SQL) {
+			return fmt.Errorf("%w: %q: %w", ErrInvalidDefinition, d.Name, query.ErrNotReadOnly)
+		}
+	case d.Query != nil:
+		if _, _, err := d.Query.aggregation().Query(spotify.Filter{}); err != nil {
+			return fmt.Errorf("%w: %q: %w", ErrInvalidDefinition, d.Name, err)
+		}
+		if d.Query.Period != "" {
+			if _, err := spotify.ParsePeriod(d.Query.Period); err != nil {
+				return fmt.Errorf("%w: %q: %w", ErrInvalidDefinition, d.Name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %q needs either sql or query", ErrInvalidDefinition, d.Name)
+	}
+
+	if d.Format != "" {
+		if _, err := tabular.ParseFormat(d.Format); err != nil {
+			return fmt.Errorf("%w: %q: %w", ErrInvalidDefinition, d.Name, err)
+		}
+	}
+
+	for _, column := range d.Columns {
+		switch column.Format {
+		case ColumnFormatRaw, ColumnFormatDuration, ColumnFormatPercent, ColumnFormatDate:
+		default:
+			return fmt.Errorf("%w: %q: %w: %q", ErrInvalidDefinition, d.Name, ErrInvalidColumnFormat, column.Format)
+		}
+	}
+
+	return nil
+}
+
+// Kind describes how the report is defined, "sql" or "query".
+func (d Definition) Kind() string {
+	if d.Query != nil {
+		return "query"
+	}
+	return "sql"
+}
+
+// Statement returns the SQL statement of the report and the arguments of its
+// placeholders. filter only applies to structured reports, its limit and
+// minimum play time overriding the ones of the definition when set.
+func (d Definition) Statement(filter spotify.Filter) (string, []any, error) {
+	if d.Query == nil {
+		return d.SQL, nil, nil
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = d.Query.Limit
+	}
+	if filter.MinPlayed == 0 {
+		filter.MinPlayed = time.Duration(d.Query.MinPlayTimeMS) * time.Millisecond
+	}
+
+	statement, args, err := d.Query.aggregation().Query(filter)
+	if err != nil {
+		return "", nil, fmt.Errorf("d.Query.aggregation().Query: %w", err)
+	}
+
+	return statement, args, nil
+}
+
+// Apply selects, renames and formats the columns of table according to the
+// definition.
+func (d Definition) Apply(table tabular.Table) (tabular.Table, error) {
+	if len(d.Columns) == 0 {
+		return table, nil
+	}
+
+	indexes := make([]int, len(d.Columns))
+	result := tabular.Table{Columns: make([]string, len(d.Columns))}
+
+	for i, column := range d.Columns {
+		index := slices.Index(table.Columns, column.Name)
+		if index < 0 {
+			return tabular.Table{}, fmt.Errorf("%w: %q", ErrMissingColumn, column.Name)
+		}
+
+		indexes[i] = index
+		result.Columns[i] = column.Name
+		if column.Title != "" {
+			result.Columns[i] = column.Title
+		}
+	}
+
+	result.Rows = make([][]any, len(table.Rows))
+	for i, row := range table.Rows {
+		result.Rows[i] = make([]any, len(d.Columns))
+		for j, column := range d.Columns {
+			result.Rows[i][j] = column.Format.apply(row[indexes[j]])
+		}
+	}
+
+	return result, nil
+}
+
+func (q Query) aggregation() spotify.Aggregation {
+	return spotify.Aggregation{
+		GroupBy:   q.GroupBy,
+		Metrics:   q.Metrics,
+		Skipped:   q.Skipped,
+		Shuffle:   q.Shuffle,
+		Offline:   q.Offline,
+		OrderBy:   q.OrderBy,
+		Ascending: q.Ascending,
+	}
+}
+
+// apply formats a single value, leaving the ones of an unexpected type
+// untouched.
+func (f ColumnFormat) apply(value any) any {
+	switch f {
+	case ColumnFormatDuration:
+		ms, ok := toFloat(value)
+		if !ok {
+			return value
+		}
+		duration := time.Duration(ms) * time.Millisecond
+		return fmt.Sprintf("%dh %dm", int(duration.Hours()), int(duration.Minutes())%60)
+	case ColumnFormatPercent:
+		ratio, ok := toFloat(value)
+		if !ok {
+			return value
+		}
+		return fmt.Sprintf("%.1f%%", ratio*100)
+	case ColumnFormatDate:
+		switch v := value.(type) {
+		case time.Time:
+			return v.UTC().Format(time.DateOnly)
+		case string:
+			if len(v) >= len(time.DateOnly) {
+				return v[:len(time.DateOnly)]
+			}
+		}
+		return value
+	case ColumnFormatRaw:
+		return value
+	default:
+		return value
+	}
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
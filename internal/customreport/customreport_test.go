@@ -0,0 +1,244 @@
+package customreport_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+
+	"github.com/cadoween/decibel/internal/customreport"
+	"github.com/cadoween/decibel/internal/query"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/tabular"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "reports.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfig(t, `{"reports": [
+		{"name": "skips", "query": {"group_by": ["artist"], "metrics": ["skips"], "limit": 10}},
+		{"name": "profiles", "sql": "SELECT * FROM profiles", "format": "csv"}
+	]}`)
+
+	config, err := customreport.Load(path)
+	require.NoError(t, err)
+	require.Len(t, config.Reports, 2)
+
+	definition, err := config.Find("profiles")
+	require.NoError(t, err)
+	assert.Equal(t, "sql", definition.Kind())
+	assert.Equal(t, "csv", definition.Format)
+
+	_, err = config.Find("missing")
+	require.ErrorIs(t, err, customreport.ErrReportNotFound)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+	}{
+		{
+			name:    "missing name",
+			content: `{"reports": [{"sql": "SELECT 1"}]}`,
+			wantErr: customreport.ErrInvalidDefinition,
+		},
+		{
+			name:    "duplicate name",
+			content: `{"reports": [{"name": "a", "sql": "SELECT 1"}, {"name": "a", "sql": "SELECT 2"}]}`,
+			wantErr: customreport.ErrInvalidDefinition,
+		},
+		{
+			name:    "neither sql nor query",
+			content: `{"reports": [{"name": "a"}]}`,
+			wantErr: customreport.ErrInvalidDefinition,
+		},
+		{
+			name:    "both sql and query",
+			content: `{"reports": [{"name": "a", "sql": "SELECT 1", "query": {"metrics": ["plays"]}}]}`,
+			wantErr: customreport.ErrInvalidDefinition,
+		},
+		{
+			name:    "writing sql",
+			content: `{"reports": [{"name": "a", "sql": "DELETE FROM profiles"}]}`,
+			wantErr: query.ErrNotReadOnly,
+		},
+		{
+			name:    "unknown dimension",
+			content: `{"reports": [{"name": "a", "query": {"group_by": ["genre"], "metrics": ["plays"]}}]}`,
+			wantErr: spotify.ErrUnknownDimension,
+		},
+		{
+			name:    "invalid period",
+			content: `{"reports": [{"name": "a", "query": {"metrics": ["plays"], "period": "last year"}}]}`,
+			wantErr: customreport.ErrInvalidDefinition,
+		},
+		{
+			name:    "invalid output format",
+			content: `{"reports": [{"name": "a", "sql": "SELECT 1", "format": "xml"}]}`,
+			wantErr: tabular.ErrInvalidFormat,
+		},
+		{
+			name:    "invalid column format",
+			content: `{"reports": [{"name": "a", "sql": "SELECT 1", "columns": [{"name": "1", "format": "money"}]}]}`,
+			wantErr: customreport.ErrInvalidColumnFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := customreport.Load(writeConfig(t, tt.content))
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestDefinition_Statement(t *testing.T) {
+	t.Parallel()
+
+	definition := customreport.Definition{
+		Name:  "top",
+		Query: &customreport.Query{GroupBy: []string{"artist"}, Metrics: []string{"plays"}, MinPlayTimeMS: 30000, Limit: 10},
+	}
+
+	statement, args, err := definition.Statement(spotify.Filter{Usernames: []string{"alice"}})
+	require.NoError(t, err)
+	assert.Contains(t, statement, "ms_played >= ? GROUP BY artist ORDER BY plays DESC LIMIT 10")
+	assert.Equal(t, []any{"alice", int64(30000)}, args)
+
+	statement, _, err = definition.Statement(spotify.Filter{Limit: 3})
+	require.NoError(t, err)
+	assert.Contains(t, statement, "LIMIT 3")
+
+	sqlDefinition := customreport.Definition{Name: "sql", SQL: "SELECT 1"}
+	statement, args, err = sqlDefinition.Statement(spotify.Filter{Usernames: []string{"alice"}})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1", statement)
+	assert.Empty(t, args)
+}
+
+func TestDefinition_Apply(t *testing.T) {
+	t.Parallel()
+
+	table := tabular.Table{
+		Columns: []string{"artist", "play_time_ms", "skip_rate", "first_played"},
+		Rows: [][]any{
+			{"Björk", int64(5_400_000), 0.125, "2024-03-01 10:00:00"},
+			{"Portishead", nil, 0.5, time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC)},
+		},
+	}
+
+	definition := customreport.Definition{
+		Name: "report",
+		SQL:  "SELECT 1",
+		Columns: []customreport.Column{
+			{Name: "first_played", Title: "First", Format: customreport.ColumnFormatDate},
+			{Name: "artist"},
+			{Name: "play_time_ms", Title: "Time", Format: customreport.ColumnFormatDuration},
+			{Name: "skip_rate", Title: "Skips", Format: customreport.ColumnFormatPercent},
+		},
+	}
+
+	got, err := definition.Apply(table)
+	require.NoError(t, err)
+	assert.Equal(t, tabular.Table{
+		Columns: []string{"First", "artist", "Time", "Skips"},
+		Rows: [][]any{
+			{"2024-03-01", "Björk", "1h 30m", "12.5%"},
+			{"2023-05-06", "Portishead", nil, "50.0%"},
+		},
+	}, got)
+
+	definition.Columns = []customreport.Column{{Name: "album"}}
+	_, err = definition.Apply(table)
+	require.ErrorIs(t, err, customreport.ErrMissingColumn)
+}
+
+// TestDefinition_RunsOnDatabase makes sure every dimension and metric builds
+// valid SQL on an actual database.
+func TestDefinition_RunsOnDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "decibel.db")
+
+	db, err := ksqlite.New(ctx, path, ksql.Config{})
+	require.NoError(t, err)
+
+	spotifySQLite := spotify.NewSQLite(db)
+	require.NoError(t, spotifySQLite.Migrate(ctx))
+
+	err = spotifySQLite.BulkInsertStreams(ctx, []spotify.Stream{
+		{
+			TS:                            time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC),
+			Username:                      "alice",
+			MasterMetadataAlbumArtistName: "Björk",
+			MasterMetadataTrackName:       "Jóga",
+			MasterMetadataAlbumAlbumName:  "Homogenic",
+			SpotifyTrackURI:               "spotify:track:1",
+			ConnCountry:                   "IS",
+			MSPlayed:                      300000,
+			Skipped:                       true,
+		},
+		{
+			TS:                            time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC),
+			Username:                      "alice",
+			MasterMetadataAlbumArtistName: "Björk",
+			MasterMetadataTrackName:       "Bachelorette",
+			MasterMetadataAlbumAlbumName:  "Homogenic",
+			SpotifyTrackURI:               "spotify:track:2",
+			ConnCountry:                   "IS",
+			MSPlayed:                      200000,
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	definition := customreport.Definition{
+		Name: "everything",
+		Query: &customreport.Query{
+			GroupBy: spotify.Dimensions(),
+			Metrics: spotify.Metrics(),
+		},
+	}
+	require.NoError(t, definition.Validate())
+
+	statement, args, err := definition.Statement(spotify.Filter{Usernames: []string{"alice"}})
+	require.NoError(t, err)
+
+	readOnly, err := query.Open(ctx, path)
+	require.NoError(t, err)
+	defer readOnly.Close()
+
+	table, err := readOnly.Run(ctx, statement, args...)
+	require.NoError(t, err)
+	assert.Len(t, table.Rows, 2)
+
+	definition.Query = &customreport.Query{GroupBy: []string{"artist"}, Metrics: []string{"plays", "skips", "tracks"}}
+	statement, args, err = definition.Statement(spotify.Filter{})
+	require.NoError(t, err)
+
+	table, err = readOnly.Run(ctx, statement, args...)
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"Björk", int64(2), int64(1), int64(2)}}, table.Rows)
+}
@@ -0,0 +1,63 @@
+// Package database opens the SQLite database commands read from.
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v3"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+var ErrNotFound = errors.New("database not found")
+
+// Open connects to the existing database at path and brings its schema up to
+// date. SQLite creates missing database files, which would only hide a
+// mistyped path behind empty results, so a missing file is ErrNotFound. The
+// returned database must be closed by the caller.
+func Open(ctx context.Context, path string) (ksql.DB, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return ksql.DB{}, fmt.Errorf("%w: %q, import an export first", ErrNotFound, path)
+	} else if err != nil {
+		return ksql.DB{}, fmt.Errorf("os.Stat: %w", err)
+	}
+
+	db, err := ksqlite.New(ctx, path, ksql.Config{})
+	if err != nil {
+		return ksql.DB{}, fmt.Errorf("ksqlite.New: %w", err)
+	}
+
+	// Databases written by older versions are brought to the schema the
+	// commands query.
+	if err := spotify.NewSQLite(db).Migrate(ctx); err != nil {
+		iox.Close(db, zerolog.Ctx(ctx))
+		return ksql.DB{}, fmt.Errorf("spotify.NewSQLite(db).Migrate: %w", err)
+	}
+
+	return db, nil
+}
+
+// OpenFromFlags sets the log level from the --verbose flag of c and opens the
+// database given by its --db flag, as Open.
+func OpenFromFlags(ctx context.Context, c *cli.Command) (ksql.DB, error) {
+	dbPath := c.String("db")
+
+	if c.Bool("verbose") {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	} else {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	}
+
+	zerolog.Ctx(ctx).Debug().
+		Str("db_path", dbPath).
+		Msg("Connecting to database")
+
+	return Open(ctx, dbPath)
+}
@@ -0,0 +1,36 @@
+package database_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/database"
+)
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "decibel.db")
+
+	_, err := database.Open(ctx, path)
+	require.ErrorIs(t, err, database.ErrNotFound)
+	assert.NoFileExists(t, path)
+
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	db, err := database.Open(ctx, path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var tables []struct {
+		Name string `ksql:"name"`
+	}
+	require.NoError(t, db.Query(ctx, &tables, "SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'spotify_streams'"))
+	assert.Len(t, tables, 1)
+}
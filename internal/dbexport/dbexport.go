@@ -0,0 +1,160 @@
+// Package dbexport writes the tables of a decibel database as files for
+// analysis in other tools, such as notebooks.
+package dbexport
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cadoween/decibel/internal/query"
+	"github.com/cadoween/decibel/pkg/ext"
+)
+
+var (
+	ErrInvalidFormat = errors.New("invalid export format")
+	ErrUnknownTable  = errors.New("unknown table")
+)
+
+// Format is the file format tables are exported in.
+type Format string
+
+const (
+	// FormatCSV writes a CSV file per table, with a header row of the
+	// columns.
+	FormatCSV Format = "csv"
+	// FormatParquet writes a Parquet file per table, with typed columns.
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat parses a format name, case insensitively.
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(s))); format {
+	case FormatCSV, FormatParquet:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+	}
+}
+
+// Extension returns the extension of the files written in the format.
+func (f Format) Extension() string {
+	if f == FormatParquet {
+		return ext.Parquet
+	}
+	return ext.CSV
+}
+
+// Write writes the rows of table to w in the format and returns their
+// number.
+func (f Format) Write(ctx context.Context, db query.DB, table string, w io.Writer) (int64, error) {
+	if f == FormatParquet {
+		return WriteParquet(ctx, db, table, w)
+	}
+	return WriteCSV(ctx, db, table, w)
+}
+
+// Tables returns the names of the tables of db, sorted, keeping only names
+// when any are given.
+func Tables(ctx context.Context, db query.DB, names []string) ([]string, error) {
+	schema, err := query.GetSchema(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("query.GetSchema: %w", err)
+	}
+
+	var tables []string
+	for _, table := range schema {
+		tables = append(tables, table.Name)
+	}
+
+	if len(names) == 0 {
+		return tables, nil
+	}
+
+	for _, name := range names {
+		if !slices.Contains(tables, name) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownTable, name)
+		}
+	}
+
+	return names, nil
+}
+
+// WriteCSV writes the rows of table to w as CSV, reading them one at a time,
+// and returns their number. Values are written with their declared type:
+// timestamps as RFC 3339 in UTC, booleans as true or false, and NULL as an
+// empty field.
+func WriteCSV(ctx context.Context, db query.DB, table string, w io.Writer) (int64, error) {
+	// The table name comes from the schema, quoting only guards against
+	// names SQLite can't take as-is.
+	rows, err := db.Rows(ctx, `SELECT * FROM "`+strings.ReplaceAll(table, `"`, `""`)+`"`)
+	if err != nil {
+		return 0, fmt.Errorf("db.Rows: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	cw := csv.NewWriter(w)
+
+	header := make([]string, 0, len(rows.Columns))
+	for _, column := range rows.Columns {
+		header = append(header, column.Name)
+	}
+	if err := cw.Write(header); err != nil {
+		return 0, fmt.Errorf("cw.Write: %w", err)
+	}
+
+	var count int64
+	record := make([]string, len(rows.Columns))
+	for rows.Next() {
+		for i, value := range rows.Values() {
+			record[i] = formatValue(value, rows.Columns[i].Type)
+		}
+		if err := cw.Write(record); err != nil {
+			return 0, fmt.Errorf("cw.Write: %w", err)
+		}
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return 0, fmt.Errorf("cw.Error: %w", err)
+	}
+
+	return count, nil
+}
+
+// formatValue formats a value scanned by database/sql from a column of the
+// declared type.
+func formatValue(value any, declaredType string) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case int64:
+		if strings.EqualFold(declaredType, "BOOLEAN") {
+			return strconv.FormatBool(v != 0)
+		}
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
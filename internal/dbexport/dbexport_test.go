@@ -0,0 +1,101 @@
+package dbexport_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+
+	"github.com/cadoween/decibel/internal/dbexport"
+	"github.com/cadoween/decibel/internal/query"
+)
+
+// newDatabase creates a database file with a few streams and profiles.
+func newDatabase(t *testing.T) string {
+	t.Helper()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "decibel.db")
+
+	db, err := ksqlite.New(ctx, path, ksql.Config{})
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, statement := range []string{
+		`CREATE TABLE spotify_streams (id INTEGER PRIMARY KEY, ts TIMESTAMP, username TEXT, ms_played INTEGER, skipped BOOLEAN, score REAL)`,
+		`INSERT INTO spotify_streams (ts, username, ms_played, skipped, score) VALUES
+			('2024-03-01 22:05:00 +0000 UTC', 'alice', 1000, 1, 0.5),
+			('2024-03-01 22:10:00.5 +0000 UTC', 'bob, "the builder"', 2000, 0, NULL)`,
+		`CREATE TABLE profiles (name TEXT, username TEXT)`,
+	} {
+		_, err := db.Exec(ctx, statement)
+		require.NoError(t, err)
+	}
+
+	return path
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := query.Open(ctx, newDatabase(t))
+	require.NoError(t, err)
+	defer db.Close()
+
+	var sb strings.Builder
+	count, err := dbexport.WriteCSV(ctx, db, "spotify_streams", &sb)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, `id,ts,username,ms_played,skipped,score
+1,2024-03-01T22:05:00Z,alice,1000,true,0.5
+2,2024-03-01T22:10:00.5Z,"bob, ""the builder""",2000,false,
+`, sb.String())
+
+	sb.Reset()
+	count, err = dbexport.WriteCSV(ctx, db, "profiles", &sb)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, "name,username\n", sb.String())
+}
+
+func TestTables(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := query.Open(ctx, newDatabase(t))
+	require.NoError(t, err)
+	defer db.Close()
+
+	tables, err := dbexport.Tables(ctx, db, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"profiles", "spotify_streams"}, tables)
+
+	tables, err = dbexport.Tables(ctx, db, []string{"spotify_streams"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spotify_streams"}, tables)
+
+	_, err = dbexport.Tables(ctx, db, []string{"streams"})
+	require.ErrorIs(t, err, dbexport.ErrUnknownTable)
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	format, err := dbexport.ParseFormat(" CSV ")
+	require.NoError(t, err)
+	assert.Equal(t, dbexport.FormatCSV, format)
+
+	format, err = dbexport.ParseFormat("Parquet")
+	require.NoError(t, err)
+	assert.Equal(t, dbexport.FormatParquet, format)
+	assert.Equal(t, ".parquet", format.Extension())
+
+	_, err = dbexport.ParseFormat("xlsx")
+	require.ErrorIs(t, err, dbexport.ErrInvalidFormat)
+}
@@ -0,0 +1,544 @@
+package dbexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cadoween/decibel/internal/query"
+)
+
+var ErrInvalidValue = errors.New("invalid value")
+
+// parquetMagic starts and ends Parquet files.
+const parquetMagic = "PAR1"
+
+// rowGroupRows is the number of rows buffered before they are written as a
+// row group, bounding the memory used whatever the size of the table.
+const rowGroupRows = 64 * 1024
+
+// Physical types, repetitions, converted types, encodings and page types of
+// the Parquet format specification.
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	repetitionOptional = 1
+
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageData = 0
+)
+
+// columnKind is how a column of a declared type is written.
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindInt64
+	kindDouble
+	kindBoolean
+	kindTimestamp
+)
+
+// kindOf returns the kind of a column from its declared type, following the
+// affinity rules of SQLite for the types it doesn't name explicitly.
+func kindOf(declaredType string) columnKind {
+	declaredType = strings.ToUpper(declaredType)
+	switch {
+	case declaredType == "BOOLEAN":
+		return kindBoolean
+	case strings.Contains(declaredType, "TIMESTAMP"), strings.Contains(declaredType, "DATETIME"):
+		return kindTimestamp
+	case strings.Contains(declaredType, "INT"):
+		return kindInt64
+	case strings.Contains(declaredType, "REAL"), strings.Contains(declaredType, "FLOA"), strings.Contains(declaredType, "DOUB"):
+		return kindDouble
+	default:
+		return kindString
+	}
+}
+
+// parquetColumn buffers the values of a column for the current row group.
+type parquetColumn struct {
+	name string
+	kind columnKind
+
+	// defined holds whether each row has a value, values the PLAIN
+	// encoding of the values of the rows that have one, and booleans the
+	// values of boolean columns, bit-packed once the row group is written.
+	defined  []bool
+	values   bytes.Buffer
+	booleans []bool
+}
+
+// physicalType returns the Parquet type the column is stored as.
+func (c *parquetColumn) physicalType() int32 {
+	switch c.kind {
+	case kindInt64, kindTimestamp:
+		return parquetInt64
+	case kindDouble:
+		return parquetDouble
+	case kindBoolean:
+		return parquetBoolean
+	default:
+		return parquetByteArray
+	}
+}
+
+// append adds the value of a row, scanned by database/sql from a column of
+// the declared type.
+func (c *parquetColumn) append(value any, declaredType string) error {
+	if value == nil {
+		c.defined = append(c.defined, false)
+		return nil
+	}
+
+	switch c.kind {
+	case kindInt64:
+		v, err := toInt64(value)
+		if err != nil {
+			return fmt.Errorf("%w: column %q: %w", ErrInvalidValue, c.name, err)
+		}
+		c.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+	case kindDouble:
+		v, err := toFloat64(value)
+		if err != nil {
+			return fmt.Errorf("%w: column %q: %w", ErrInvalidValue, c.name, err)
+		}
+		c.values.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)))
+	case kindBoolean:
+		v, err := toInt64(value)
+		if err != nil {
+			return fmt.Errorf("%w: column %q: %w", ErrInvalidValue, c.name, err)
+		}
+		c.booleans = append(c.booleans, v != 0)
+	case kindTimestamp:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("%w: column %q: %T %v isn't a timestamp", ErrInvalidValue, c.name, value, value)
+		}
+		c.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v.UnixMicro())))
+	default:
+		s := formatValue(value, declaredType)
+		c.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(s))))
+		c.values.WriteString(s)
+	}
+
+	c.defined = append(c.defined, true)
+	return nil
+}
+
+// page returns the data page of the buffered rows: their definition levels
+// then their values.
+func (c *parquetColumn) page() []byte {
+	// The levels are a single bit-packed run of the RLE/bit-packed hybrid
+	// encoding, whose header is the number of groups of 8 values, prefixed
+	// by their length.
+	packed := bitPack(c.defined)
+	levels := binary.AppendUvarint(nil, uint64(len(packed))<<1|1)
+	levels = append(levels, packed...)
+
+	var page bytes.Buffer
+	page.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(levels))))
+	page.Write(levels)
+
+	if c.kind == kindBoolean {
+		page.Write(bitPack(c.booleans))
+	} else {
+		page.Write(c.values.Bytes())
+	}
+
+	return page.Bytes()
+}
+
+// reset empties the buffers once the row group is written.
+func (c *parquetColumn) reset() {
+	c.defined = c.defined[:0]
+	c.values.Reset()
+	c.booleans = c.booleans[:0]
+}
+
+// columnChunk is the metadata of a column of a written row group.
+type columnChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+// rowGroup is the metadata of a written row group.
+type rowGroup struct {
+	chunks  []columnChunk
+	numRows int64
+}
+
+// WriteParquet writes the rows of table to w as an uncompressed Parquet
+// file, reading them one at a time and buffering them per row group, and
+// returns their number. Columns are optional and typed from their declared
+// type: TIMESTAMP as timestamps in microseconds adjusted to UTC, INTEGER as
+// int64, BOOLEAN as booleans, REAL as doubles, and others as UTF-8 strings.
+func WriteParquet(ctx context.Context, db query.DB, table string, w io.Writer) (int64, error) {
+	rows, err := db.Rows(ctx, `SELECT * FROM "`+strings.ReplaceAll(table, `"`, `""`)+`"`)
+	if err != nil {
+		return 0, fmt.Errorf("db.Rows: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns := make([]*parquetColumn, 0, len(rows.Columns))
+	for _, column := range rows.Columns {
+		columns = append(columns, &parquetColumn{name: column.Name, kind: kindOf(column.Type)})
+	}
+
+	cw := &countingWriter{w: w}
+	if _, err := io.WriteString(cw, parquetMagic); err != nil {
+		return 0, fmt.Errorf("io.WriteString: %w", err)
+	}
+
+	var (
+		count     int64
+		buffered  int64
+		rowGroups []rowGroup
+	)
+	flush := func() error {
+		group, err := writeRowGroup(cw, columns, buffered)
+		if err != nil {
+			return err
+		}
+		rowGroups = append(rowGroups, group)
+		buffered = 0
+		return nil
+	}
+
+	for rows.Next() {
+		for i, value := range rows.Values() {
+			if err := columns[i].append(value, rows.Columns[i].Type); err != nil {
+				return 0, err
+			}
+		}
+		count++
+		buffered++
+
+		if buffered == rowGroupRows {
+			if err := flush(); err != nil {
+				return 0, fmt.Errorf("writeRowGroup: %w", err)
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	if buffered > 0 {
+		if err := flush(); err != nil {
+			return 0, fmt.Errorf("writeRowGroup: %w", err)
+		}
+	}
+
+	footer := fileMetaData(columns, rowGroups, count)
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, parquetMagic...)
+	if _, err := cw.Write(footer); err != nil {
+		return 0, fmt.Errorf("cw.Write: %w", err)
+	}
+
+	return count, nil
+}
+
+// writeRowGroup writes the buffered rows of columns as a row group of a
+// single data page per column.
+func writeRowGroup(cw *countingWriter, columns []*parquetColumn, numRows int64) (rowGroup, error) {
+	group := rowGroup{numRows: numRows}
+	for _, column := range columns {
+		page := column.page()
+
+		var header thriftWriter
+		header.beginStruct()
+		header.i32Field(1, pageData)
+		header.i32Field(2, int32(len(page)))
+		header.i32Field(3, int32(len(page)))
+		header.structField(5)
+		header.i32Field(1, int32(numRows))
+		header.i32Field(2, encodingPlain)
+		header.i32Field(3, encodingRLE)
+		header.i32Field(4, encodingRLE)
+		header.endStruct()
+		header.endStruct()
+
+		chunk := columnChunk{offset: cw.n, numValues: numRows}
+		if _, err := cw.Write(header.buf.Bytes()); err != nil {
+			return rowGroup{}, fmt.Errorf("cw.Write: %w", err)
+		}
+		if _, err := cw.Write(page); err != nil {
+			return rowGroup{}, fmt.Errorf("cw.Write: %w", err)
+		}
+		chunk.size = cw.n - chunk.offset
+
+		group.chunks = append(group.chunks, chunk)
+		column.reset()
+	}
+
+	return group, nil
+}
+
+// fileMetaData returns the footer of the file describing its schema and
+// row groups.
+func fileMetaData(columns []*parquetColumn, rowGroups []rowGroup, numRows int64) []byte {
+	var t thriftWriter
+	t.beginStruct()
+	t.i32Field(1, 1)
+
+	t.listField(2, thriftStruct, len(columns)+1)
+	t.beginStruct()
+	t.binaryField(4, "schema")
+	t.i32Field(5, int32(len(columns)))
+	t.endStruct()
+	for _, column := range columns {
+		t.beginStruct()
+		t.i32Field(1, column.physicalType())
+		t.i32Field(3, repetitionOptional)
+		t.binaryField(4, column.name)
+		switch column.kind {
+		case kindString:
+			t.i32Field(6, convertedUTF8)
+			t.structField(10)
+			t.structField(1) // STRING
+			t.endStruct()
+			t.endStruct()
+		case kindTimestamp:
+			t.i32Field(6, convertedTimestampMicros)
+			t.structField(10)
+			t.structField(8) // TIMESTAMP
+			t.boolField(1, true)
+			t.structField(2)
+			t.structField(2) // MICROS
+			t.endStruct()
+			t.endStruct()
+			t.endStruct()
+			t.endStruct()
+		}
+		t.endStruct()
+	}
+
+	t.i64Field(3, numRows)
+
+	t.listField(4, thriftStruct, len(rowGroups))
+	for _, group := range rowGroups {
+		var totalSize int64
+		t.beginStruct()
+		t.listField(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			totalSize += chunk.size
+			t.beginStruct()
+			t.i64Field(2, chunk.offset)
+			t.structField(3)
+			t.i32Field(1, columns[i].physicalType())
+			t.listField(2, thriftI32, 2)
+			t.i32(encodingPlain)
+			t.i32(encodingRLE)
+			t.listField(3, thriftBinary, 1)
+			t.binary(columns[i].name)
+			t.i32Field(4, 0) // UNCOMPRESSED
+			t.i64Field(5, chunk.numValues)
+			t.i64Field(6, chunk.size)
+			t.i64Field(7, chunk.size)
+			t.i64Field(9, chunk.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64Field(2, totalSize)
+		t.i64Field(3, group.numRows)
+		t.endStruct()
+	}
+
+	t.binaryField(6, "decibel")
+	t.endStruct()
+
+	return t.buf.Bytes()
+}
+
+// Types of the Thrift compact protocol.
+const (
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Thrift structures of the Parquet metadata with
+// the compact protocol.
+type thriftWriter struct {
+	buf bytes.Buffer
+	// lastIDs holds the id of the last field written per nested struct,
+	// since field ids are written as a delta from it.
+	lastIDs []int16
+}
+
+func (t *thriftWriter) beginStruct() {
+	t.lastIDs = append(t.lastIDs, 0)
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf.WriteByte(0)
+	t.lastIDs = t.lastIDs[:len(t.lastIDs)-1]
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := &t.lastIDs[len(t.lastIDs)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.zigzag(int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) structField(id int16) {
+	t.field(id, thriftStruct)
+	t.beginStruct()
+}
+
+func (t *thriftWriter) boolField(id int16, v bool) {
+	if v {
+		t.field(id, thriftTrue)
+	} else {
+		t.field(id, thriftFalse)
+	}
+}
+
+func (t *thriftWriter) i32Field(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.i32(v)
+}
+
+func (t *thriftWriter) i64Field(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.zigzag(v)
+}
+
+func (t *thriftWriter) binaryField(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.binary(s)
+}
+
+func (t *thriftWriter) listField(id int16, elemType byte, size int) {
+	t.field(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		t.buf.Write(binary.AppendUvarint(nil, uint64(size)))
+	}
+}
+
+func (t *thriftWriter) i32(v int32) {
+	t.zigzag(int64(v))
+}
+
+func (t *thriftWriter) binary(s string) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) zigzag(v int64) {
+	t.buf.Write(binary.AppendVarint(nil, v))
+}
+
+// countingWriter counts the bytes written, giving the offsets of pages.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("cw.w.Write: %w", err)
+	}
+	return n, nil
+}
+
+// bitPack packs values LSB first, padding the last byte with zeros.
+func bitPack(values []bool) []byte {
+	packed := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return packed
+}
+
+// toInt64 converts a value SQLite stored in an integer column.
+func toInt64(value any) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("%v isn't an integer", v)
+		}
+		return int64(v), nil
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("strconv.ParseInt: %w", err)
+		}
+		return n, nil
+	case []byte:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("strconv.ParseInt: %w", err)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("%T %v isn't an integer", value, value)
+	}
+}
+
+// toFloat64 converts a value SQLite stored in a real column.
+func toFloat64(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("strconv.ParseFloat: %w", err)
+		}
+		return n, nil
+	case []byte:
+		n, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("strconv.ParseFloat: %w", err)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("%T %v isn't a number", value, value)
+	}
+}
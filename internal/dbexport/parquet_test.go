@@ -0,0 +1,294 @@
+package dbexport_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/dbexport"
+	"github.com/cadoween/decibel/internal/query"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+func TestWriteParquet(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := query.Open(ctx, newDatabase(t))
+	require.NoError(t, err)
+	defer db.Close()
+
+	var buf bytes.Buffer
+	count, err := dbexport.WriteParquet(ctx, db, "spotify_streams", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	file := readParquet(t, buf.Bytes())
+	assert.Equal(t, int64(2), file.numRows)
+	assert.Equal(t, []parquetField{
+		{name: "id", physicalType: 2},
+		{name: "ts", physicalType: 2, logicalType: 8},
+		{name: "username", physicalType: 6, logicalType: 1},
+		{name: "ms_played", physicalType: 2},
+		{name: "skipped", physicalType: 0},
+		{name: "score", physicalType: 5},
+	}, file.fields)
+	assert.Equal(t, map[string][]any{
+		"id": {int64(1), int64(2)},
+		"ts": {
+			time.Date(2024, 3, 1, 22, 5, 0, 0, time.UTC).UnixMicro(),
+			time.Date(2024, 3, 1, 22, 10, 0, 500_000_000, time.UTC).UnixMicro(),
+		},
+		"username":  {"alice", `bob, "the builder"`},
+		"ms_played": {int64(1000), int64(2000)},
+		"skipped":   {true, false},
+		"score":     {0.5, nil},
+	}, file.columns)
+
+	buf.Reset()
+	count, err = dbexport.WriteParquet(ctx, db, "profiles", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	file = readParquet(t, buf.Bytes())
+	assert.Equal(t, int64(0), file.numRows)
+	assert.Equal(t, []parquetField{
+		{name: "name", physicalType: 6, logicalType: 1},
+		{name: "username", physicalType: 6, logicalType: 1},
+	}, file.fields)
+	assert.Empty(t, file.columns)
+}
+
+// TestWriteParquet_Golden pins the bytes of the files WriteParquet writes.
+// The golden file follows the field ids and enums of parquet.thrift; when it
+// is rewritten with -update, check it with a reference reader such as
+// pyarrow.parquet.read_table before committing it.
+func TestWriteParquet_Golden(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := query.Open(ctx, newDatabase(t))
+	require.NoError(t, err)
+	defer db.Close()
+
+	var buf bytes.Buffer
+	_, err = dbexport.WriteParquet(ctx, db, "spotify_streams", &buf)
+	require.NoError(t, err)
+
+	path := filepath.Join("testdata", "spotify_streams.parquet")
+	if *update {
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, buf.Bytes())
+}
+
+// parquetField is a column of the schema of a Parquet file, with the id of
+// the field set in its logical type, if any.
+type parquetField struct {
+	name         string
+	physicalType int64
+	logicalType  int16
+}
+
+// parquetFile is what readParquet reads back from a file.
+type parquetFile struct {
+	numRows int64
+	fields  []parquetField
+	columns map[string][]any
+}
+
+// readParquet reads the files WriteParquet writes: uncompressed, PLAIN
+// encoded data pages of optional columns.
+func readParquet(t *testing.T, data []byte) parquetFile {
+	t.Helper()
+
+	require.Equal(t, "PAR1", string(data[:4]))
+	require.Equal(t, "PAR1", string(data[len(data)-4:]))
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	r := &thriftReader{data: data[len(data)-8-footerLen : len(data)-8]}
+	meta := r.value(t, 12).(map[int16]any)
+	require.Equal(t, len(r.data), r.pos)
+
+	file := parquetFile{numRows: meta[3].(int64)}
+
+	schema := meta[2].([]any)
+	require.Equal(t, int64(len(schema)-1), schema[0].(map[int16]any)[5])
+	for _, element := range schema[1:] {
+		element := element.(map[int16]any)
+		assert.Equal(t, int64(1), element[3], "OPTIONAL")
+
+		field := parquetField{name: string(element[4].([]byte)), physicalType: element[1].(int64)}
+		if logical, ok := element[10].(map[int16]any); ok {
+			for id := range logical {
+				field.logicalType = id
+			}
+		}
+		file.fields = append(file.fields, field)
+	}
+
+	for _, group := range meta[4].([]any) {
+		if file.columns == nil {
+			file.columns = make(map[string][]any)
+		}
+		for i, chunk := range group.(map[int16]any)[1].([]any) {
+			chunkMeta := chunk.(map[int16]any)[3].(map[int16]any)
+			field := file.fields[i]
+			file.columns[field.name] = append(file.columns[field.name], readPage(t, data, chunkMeta[9].(int64), field)...)
+		}
+	}
+
+	return file
+}
+
+// readPage reads the values of the data page at offset.
+func readPage(t *testing.T, data []byte, offset int64, field parquetField) []any {
+	t.Helper()
+
+	r := &thriftReader{data: data, pos: int(offset)}
+	header := r.value(t, 12).(map[int16]any)
+	numValues := int(header[5].(map[int16]any)[1].(int64))
+	page := data[r.pos : r.pos+int(header[3].(int64))]
+
+	// The definition levels are a single bit-packed run.
+	levelsLen := int(binary.LittleEndian.Uint32(page))
+	levels := &thriftReader{data: page[4 : 4+levelsLen]}
+	runHeader := levels.uvarint()
+	require.Equal(t, uint64(1), runHeader&1)
+	defined := unpack(levels.data[levels.pos:], numValues)
+	values := page[4+levelsLen:]
+
+	var booleans []bool
+	if field.physicalType == 0 {
+		present := 0
+		for _, ok := range defined {
+			if ok {
+				present++
+			}
+		}
+		booleans = unpack(values, present)
+		values = values[(present+7)/8:]
+	}
+
+	var column []any
+	for _, ok := range defined {
+		if !ok {
+			column = append(column, nil)
+			continue
+		}
+
+		switch field.physicalType {
+		case 0:
+			column = append(column, booleans[0])
+			booleans = booleans[1:]
+		case 2:
+			column = append(column, int64(binary.LittleEndian.Uint64(values)))
+			values = values[8:]
+		case 5:
+			column = append(column, math.Float64frombits(binary.LittleEndian.Uint64(values)))
+			values = values[8:]
+		case 6:
+			n := binary.LittleEndian.Uint32(values)
+			column = append(column, string(values[4:4+n]))
+			values = values[4+n:]
+		default:
+			t.Fatalf("unexpected physical type %d", field.physicalType)
+		}
+	}
+	assert.Empty(t, values)
+
+	return column
+}
+
+// unpack reads n bits packed LSB first.
+func unpack(packed []byte, n int) []bool {
+	values := make([]bool, n)
+	for i := range values {
+		values[i] = packed[i/8]&(1<<(i%8)) != 0
+	}
+	return values
+}
+
+// thriftReader decodes Thrift structures of the compact protocol into maps
+// of field ids to values.
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) value(t *testing.T, typ byte) any {
+	t.Helper()
+
+	switch typ {
+	case 1, 2:
+		r.pos++
+		return r.data[r.pos-1] == 1
+	case 5, 6:
+		return r.varint()
+	case 8:
+		n := int(r.uvarint())
+		r.pos += n
+		return r.data[r.pos-n : r.pos]
+	case 9:
+		header := r.data[r.pos]
+		r.pos++
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]any, 0, size)
+		for range size {
+			list = append(list, r.value(t, header&0x0f))
+		}
+		return list
+	case 12:
+		fields := make(map[int16]any)
+		var id int16
+		for {
+			header := r.data[r.pos]
+			r.pos++
+			if header == 0 {
+				return fields
+			}
+			if delta := int16(header >> 4); delta != 0 {
+				id += delta
+			} else {
+				id = int16(r.varint())
+			}
+			switch fieldType := header & 0x0f; fieldType {
+			case 1, 2:
+				fields[id] = fieldType == 1
+			default:
+				fields[id] = r.value(t, fieldType)
+			}
+		}
+	default:
+		t.Fatalf("unexpected thrift type %d", typ)
+		return nil
+	}
+}
@@ -0,0 +1,466 @@
+// Package explore implements an interactive terminal UI to browse the
+// listening history, from artists down to their albums and tracks.
+package explore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/termchart"
+)
+
+// Store is the part of the statistics database the explorer reads from.
+type Store interface {
+	GetTopArtistsByPlayTime(ctx context.Context, filter spotify.Filter) ([]spotify.ArtistStats, error)
+	SearchArtists(ctx context.Context, term string, filter spotify.Filter) ([]spotify.ArtistStats, error)
+	GetArtistAlbums(ctx context.Context, artist string, filter spotify.Filter) ([]spotify.AlbumStats, error)
+	GetAlbumTracks(ctx context.Context, artist, album string, filter spotify.Filter) ([]spotify.TrackStats, error)
+	GetPlayTimeByPeriod(ctx context.Context, interval spotify.Interval, filter spotify.Filter) ([]spotify.PeriodStats, error)
+}
+
+// artistLimit is the number of artists listed when not searching.
+const artistLimit = 100
+
+type level int
+
+const (
+	levelArtists level = iota
+	levelAlbums
+	levelTracks
+)
+
+type row struct {
+	label      string
+	plays      int64
+	playTimeMS int64
+}
+
+// view is a list shown by the explorer, the artists at the root and the
+// albums or tracks drilled into on top of it.
+type view struct {
+	level  level
+	artist string
+	album  string
+	rows   []row
+	cursor int
+	offset int
+}
+
+type yearsMsg struct {
+	years []string
+	err   error
+}
+
+type rowsMsg struct {
+	seq  int
+	rows []row
+	err  error
+}
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10"))
+	crumbStyle    = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	barStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	faintStyle    = lipgloss.NewStyle().Faint(true)
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// Model is the bubbletea model of the explorer.
+type Model struct {
+	ctx    context.Context
+	store  Store
+	filter spotify.Filter
+
+	// years are the years with streams, year is the index of the selected one
+	// or -1 for all time.
+	years []string
+	year  int
+
+	search    string
+	searching bool
+	input     string
+
+	stack []view
+	// seq identifies the latest load so results of superseded loads are
+	// dropped.
+	seq     int
+	loading bool
+	err     error
+
+	width  int
+	height int
+}
+
+// New creates an explorer over the streams matching filter.
+func New(ctx context.Context, store Store, filter spotify.Filter) Model {
+	return Model{
+		ctx:     ctx,
+		store:   store,
+		filter:  filter,
+		year:    -1,
+		stack:   []view{{level: levelArtists}},
+		loading: true,
+		width:   100,
+		height:  30,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.loadYears(), m.loadRows(m.seq))
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.current().scroll(m.visibleRows())
+		return m, nil
+	case yearsMsg:
+		m.years, m.err = msg.years, msg.err
+		return m, nil
+	case rowsMsg:
+		if msg.seq != m.seq {
+			return m, nil
+		}
+		m.loading, m.err = false, msg.err
+		current := m.current()
+		current.rows = msg.rows
+		current.cursor = min(current.cursor, max(0, len(current.rows)-1))
+		current.scroll(m.visibleRows())
+		return m, nil
+	case tea.KeyMsg:
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		return m.updateBrowse(msg)
+	default:
+		return m, nil
+	}
+}
+
+func (m Model) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	current := m.current()
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		current.move(-1, m.visibleRows())
+	case "down", "j":
+		current.move(1, m.visibleRows())
+	case "pgup":
+		current.move(-m.visibleRows(), m.visibleRows())
+	case "pgdown":
+		current.move(m.visibleRows(), m.visibleRows())
+	case "home", "g":
+		current.move(-len(current.rows), m.visibleRows())
+	case "end", "G":
+		current.move(len(current.rows), m.visibleRows())
+	case "enter", "right", "l":
+		if current.level == levelTracks || len(current.rows) == 0 {
+			return m, nil
+		}
+		next := view{level: current.level + 1, artist: current.artist}
+		if current.level == levelArtists {
+			next.artist = current.rows[current.cursor].label
+		} else {
+			next.album = current.rows[current.cursor].label
+		}
+		m.stack = append(m.stack, next)
+		return m.reload()
+	case "esc", "left", "h", "backspace":
+		if len(m.stack) > 1 {
+			m.stack = m.stack[:len(m.stack)-1]
+			return m.reload()
+		}
+		if m.search != "" {
+			m.search = ""
+			*current = view{level: levelArtists}
+			return m.reload()
+		}
+	case "/":
+		m.searching, m.input = true, m.search
+	case "[":
+		if len(m.years) == 0 {
+			return m, nil
+		}
+		if m.year == -1 {
+			m.year = len(m.years) - 1
+		} else {
+			m.year = max(0, m.year-1)
+		}
+		return m.reload()
+	case "]":
+		if m.year == -1 {
+			return m, nil
+		}
+		if m.year++; m.year >= len(m.years) {
+			m.year = -1
+		}
+		return m.reload()
+	case "a":
+		if m.year == -1 {
+			return m, nil
+		}
+		m.year = -1
+		return m.reload()
+	}
+
+	return m, nil
+}
+
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.searching = false
+	case tea.KeyEnter:
+		m.searching = false
+		m.search = strings.TrimSpace(m.input)
+		m.stack = []view{{level: levelArtists}}
+		return m.reload()
+	case tea.KeyBackspace:
+		if runes := []rune(m.input); len(runes) > 0 {
+			m.input = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.input += string(msg.Runes)
+	default:
+	}
+
+	return m, nil
+}
+
+func (m Model) View() string {
+	var sb strings.Builder
+
+	period := "All time"
+	if m.year >= 0 {
+		period = m.years[m.year]
+	}
+	sb.WriteString(titleStyle.Render("decibel explore") + faintStyle.Render(" · "+period))
+	if len(m.filter.Usernames) > 0 {
+		sb.WriteString(faintStyle.Render(" · " + strings.Join(m.filter.Usernames, ", ")))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(crumbStyle.Render(m.breadcrumb()) + "\n")
+	switch {
+	case m.searching:
+		sb.WriteString("Search: " + m.input + "█\n")
+	case m.search != "":
+		sb.WriteString(faintStyle.Render("Search: "+m.search) + "\n")
+	default:
+		sb.WriteString("\n")
+	}
+
+	current := m.stack[len(m.stack)-1]
+	switch {
+	case m.err != nil:
+		sb.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n")
+	case m.loading && len(current.rows) == 0:
+		sb.WriteString(faintStyle.Render("Loading…") + "\n")
+	case len(current.rows) == 0:
+		sb.WriteString(faintStyle.Render("Nothing played in this period.") + "\n")
+	default:
+		sb.WriteString(m.renderRows(current))
+	}
+
+	sb.WriteString("\n" + faintStyle.Render(m.help()))
+
+	return sb.String()
+}
+
+func (m Model) renderRows(current view) string {
+	const (
+		rankWidth  = 4
+		playsWidth = 7
+		timeWidth  = 9
+		gaps       = 4
+	)
+
+	barWidth := max(10, m.width/5)
+	labelWidth := max(10, m.width-rankWidth-playsWidth-timeWidth-barWidth-gaps)
+
+	var maxPlayTime float64
+	for _, r := range current.rows {
+		maxPlayTime = max(maxPlayTime, float64(r.playTimeMS))
+	}
+
+	var sb strings.Builder
+	end := min(len(current.rows), current.offset+m.visibleRows())
+	for i := current.offset; i < end; i++ {
+		r := current.rows[i]
+		line := fmt.Sprintf("%*d %s %*d %*s ",
+			rankWidth, i+1,
+			runewidth.FillRight(runewidth.Truncate(r.label, labelWidth, "…"), labelWidth),
+			playsWidth, r.plays,
+			timeWidth, formatPlayTime(r.playTimeMS),
+		)
+		bar := termchart.Bar(float64(r.playTimeMS), maxPlayTime, barWidth)
+		if i == current.cursor {
+			sb.WriteString(selectedStyle.Render(line+bar) + "\n")
+		} else {
+			sb.WriteString(line + barStyle.Render(bar) + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+func (m Model) breadcrumb() string {
+	crumbs := []string{"Artists"}
+	for _, v := range m.stack[1:] {
+		if v.level == levelAlbums {
+			crumbs = append(crumbs, v.artist)
+		} else {
+			crumbs = append(crumbs, v.album)
+		}
+	}
+	return strings.Join(crumbs, " › ")
+}
+
+func (m Model) help() string {
+	if m.searching {
+		return "enter search · esc cancel"
+	}
+	return "↑/↓ move · enter open · esc back · / search · [/] previous/next year · a all time · q quit"
+}
+
+// visibleRows is the number of list rows fitting in the terminal, leaving
+// room for the header and the help line.
+func (m Model) visibleRows() int {
+	return max(1, m.height-5)
+}
+
+func (m *Model) current() *view {
+	return &m.stack[len(m.stack)-1]
+}
+
+// reload loads the rows of the current view with the selected period.
+func (m Model) reload() (tea.Model, tea.Cmd) {
+	m.seq++
+	m.loading, m.err = true, nil
+	return m, m.loadRows(m.seq)
+}
+
+func (m Model) periodFilter() spotify.Filter {
+	if m.year == -1 {
+		return m.filter
+	}
+
+	period, err := spotify.ParsePeriod(m.years[m.year])
+	if err != nil {
+		return m.filter
+	}
+	return m.filter.WithPeriod(period)
+}
+
+func (m Model) loadYears() tea.Cmd {
+	ctx, store, filter := m.ctx, m.store, m.filter
+	return func() tea.Msg {
+		periods, err := store.GetPlayTimeByPeriod(ctx, spotify.IntervalYear, filter)
+		if err != nil {
+			return yearsMsg{err: fmt.Errorf("store.GetPlayTimeByPeriod: %w", err)}
+		}
+
+		years := make([]string, 0, len(periods))
+		for _, period := range periods {
+			years = append(years, period.Period)
+		}
+		return yearsMsg{years: years}
+	}
+}
+
+func (m Model) loadRows(seq int) tea.Cmd {
+	ctx, store, filter, search := m.ctx, m.store, m.periodFilter(), m.search
+	current := *m.current()
+
+	return func() tea.Msg {
+		rows, err := loadRows(ctx, store, current, search, filter)
+		return rowsMsg{seq: seq, rows: rows, err: err}
+	}
+}
+
+func loadRows(ctx context.Context, store Store, v view, search string, filter spotify.Filter) ([]row, error) {
+	switch v.level {
+	case levelArtists:
+		var (
+			artists []spotify.ArtistStats
+			err     error
+		)
+		if search != "" {
+			if artists, err = store.SearchArtists(ctx, search, filter); err != nil {
+				return nil, fmt.Errorf("store.SearchArtists: %w", err)
+			}
+		} else {
+			filter.Limit = artistLimit
+			if artists, err = store.GetTopArtistsByPlayTime(ctx, filter); err != nil {
+				return nil, fmt.Errorf("store.GetTopArtistsByPlayTime: %w", err)
+			}
+		}
+
+		rows := make([]row, 0, len(artists))
+		for _, artist := range artists {
+			rows = append(rows, row{label: artist.Artist, plays: artist.PlayCount, playTimeMS: artist.TotalPlayTime})
+		}
+		return rows, nil
+	case levelAlbums:
+		albums, err := store.GetArtistAlbums(ctx, v.artist, filter)
+		if err != nil {
+			return nil, fmt.Errorf("store.GetArtistAlbums: %w", err)
+		}
+
+		rows := make([]row, 0, len(albums))
+		for _, album := range albums {
+			rows = append(rows, row{label: album.Album, plays: album.Count, playTimeMS: album.TotalPlayTimeMS})
+		}
+		return rows, nil
+	case levelTracks:
+		tracks, err := store.GetAlbumTracks(ctx, v.artist, v.album, filter)
+		if err != nil {
+			return nil, fmt.Errorf("store.GetAlbumTracks: %w", err)
+		}
+
+		rows := make([]row, 0, len(tracks))
+		for _, track := range tracks {
+			rows = append(rows, row{label: track.Track, plays: track.PlayCount, playTimeMS: track.TotalPlayTimeMS})
+		}
+		return rows, nil
+	default:
+		return nil, nil
+	}
+}
+
+// move moves the cursor by delta rows, scrolling to keep it visible.
+func (v *view) move(delta, visible int) {
+	if len(v.rows) == 0 {
+		return
+	}
+	v.cursor = min(max(0, v.cursor+delta), len(v.rows)-1)
+	v.scroll(visible)
+}
+
+func (v *view) scroll(visible int) {
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+visible {
+		v.offset = v.cursor - visible + 1
+	}
+}
+
+// formatPlayTime formats a play time in milliseconds as hours and minutes.
+func formatPlayTime(ms int64) string {
+	duration := time.Duration(ms) * time.Millisecond
+	return fmt.Sprintf("%dh %dm", int(duration.Hours()), int(duration.Minutes())%60)
+}
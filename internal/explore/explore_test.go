@@ -0,0 +1,167 @@
+package explore_test
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/explore"
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+// fakeStore serves fixed statistics and records the last filter it got.
+type fakeStore struct {
+	filter spotify.Filter
+	search string
+	artist string
+	album  string
+}
+
+func (s *fakeStore) GetTopArtistsByPlayTime(_ context.Context, filter spotify.Filter) ([]spotify.ArtistStats, error) {
+	s.filter = filter
+	return []spotify.ArtistStats{
+		{Artist: "Radiohead", PlayCount: 20, TotalPlayTime: 7200000},
+		{Artist: "Portishead", PlayCount: 10, TotalPlayTime: 3600000},
+	}, nil
+}
+
+func (s *fakeStore) SearchArtists(_ context.Context, term string, filter spotify.Filter) ([]spotify.ArtistStats, error) {
+	s.filter, s.search = filter, term
+	return []spotify.ArtistStats{{Artist: "Portishead", PlayCount: 10, TotalPlayTime: 3600000}}, nil
+}
+
+func (s *fakeStore) GetArtistAlbums(_ context.Context, artist string, filter spotify.Filter) ([]spotify.AlbumStats, error) {
+	s.filter, s.artist = filter, artist
+	return []spotify.AlbumStats{{Album: "Dummy", Artist: artist, Count: 10, TotalPlayTimeMS: 3600000}}, nil
+}
+
+func (s *fakeStore) GetAlbumTracks(_ context.Context, artist, album string, filter spotify.Filter) ([]spotify.TrackStats, error) {
+	s.filter, s.artist, s.album = filter, artist, album
+	return []spotify.TrackStats{{Track: "Roads", Artist: artist, PlayCount: 10, TotalPlayTimeMS: 3600000}}, nil
+}
+
+func (s *fakeStore) GetPlayTimeByPeriod(context.Context, spotify.Interval, spotify.Filter) ([]spotify.PeriodStats, error) {
+	return []spotify.PeriodStats{{Period: "2023"}, {Period: "2024"}}, nil
+}
+
+// run runs cmd and feeds the messages it produces back into the model.
+func run(t *testing.T, m tea.Model, cmd tea.Cmd) tea.Model {
+	t.Helper()
+
+	if cmd == nil {
+		return m
+	}
+
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, c := range msg {
+			m = run(t, m, c)
+		}
+		return m
+	default:
+		m, cmd = m.Update(msg)
+		return run(t, m, cmd)
+	}
+}
+
+func press(t *testing.T, m tea.Model, keys ...string) tea.Model {
+	t.Helper()
+
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		m = run(t, m, cmd)
+	}
+
+	return m
+}
+
+func TestModel(t *testing.T) {
+	t.Parallel()
+
+	newModel := func(store *fakeStore) tea.Model {
+		m := explore.New(context.Background(), store, spotify.Filter{Usernames: []string{"alice"}})
+		return run(t, m, m.Init())
+	}
+
+	t.Run("lists top artists", func(t *testing.T) {
+		t.Parallel()
+
+		store := &fakeStore{}
+		view := newModel(store).View()
+
+		assert.Contains(t, view, "All time · alice")
+		assert.Contains(t, view, "Radiohead")
+		assert.Contains(t, view, "2h 0m")
+		assert.Equal(t, []string{"alice"}, store.filter.Usernames)
+	})
+
+	t.Run("drills into albums and tracks and back", func(t *testing.T) {
+		t.Parallel()
+
+		store := &fakeStore{}
+		m := press(t, newModel(store), "down", "enter")
+
+		assert.Equal(t, "Portishead", store.artist)
+		assert.Contains(t, m.View(), "Artists › Portishead")
+		assert.Contains(t, m.View(), "Dummy")
+
+		m = press(t, m, "enter")
+		assert.Equal(t, "Dummy", store.album)
+		assert.Contains(t, m.View(), "Artists › Portishead › Dummy")
+		assert.Contains(t, m.View(), "Roads")
+
+		m = press(t, m, "esc", "esc")
+		assert.NotContains(t, m.View(), "›")
+		assert.Contains(t, m.View(), "Radiohead")
+	})
+
+	t.Run("steps through years", func(t *testing.T) {
+		t.Parallel()
+
+		store := &fakeStore{}
+		m := press(t, newModel(store), "[")
+
+		assert.Contains(t, m.View(), "decibel explore · 2024")
+		require.False(t, store.filter.From.IsZero())
+		assert.Equal(t, 2024, store.filter.From.Year())
+
+		m = press(t, m, "[")
+		assert.Equal(t, 2023, store.filter.From.Year())
+
+		m = press(t, m, "]", "]")
+		assert.Contains(t, m.View(), "All time")
+		assert.True(t, store.filter.From.IsZero())
+	})
+
+	t.Run("searches artists", func(t *testing.T) {
+		t.Parallel()
+
+		store := &fakeStore{}
+		m := press(t, newModel(store), "/", "p", "o", "r", "t")
+		assert.Contains(t, m.View(), "Search: port")
+
+		m = press(t, m, "enter")
+		assert.Equal(t, "port", store.search)
+		assert.NotContains(t, m.View(), "Radiohead")
+		assert.Contains(t, m.View(), "Portishead")
+
+		m = press(t, m, "esc")
+		assert.Contains(t, m.View(), "Radiohead")
+	})
+}
@@ -0,0 +1,226 @@
+// Package ingest imports the exports of every registered format into a
+// decibel database, so listens are skipped, stored and merged the same way
+// whichever command imports them.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/redact"
+
+	// Importers register themselves with listen.Register, merging needs the
+	// sources of all of them.
+	_ "github.com/cadoween/decibel/internal/applemusic"
+	_ "github.com/cadoween/decibel/internal/lastfm"
+	_ "github.com/cadoween/decibel/internal/listenbrainz"
+	_ "github.com/cadoween/decibel/internal/scrobblerlog"
+	_ "github.com/cadoween/decibel/internal/youtubemusic"
+)
+
+var ErrNoExport = errors.New("no export found")
+
+// Export is a file to import along with the importer reading it.
+type Export struct {
+	Path     string
+	Importer listen.Importer
+}
+
+// Options are given to ImportFiles.
+type Options struct {
+	listen.Options
+
+	// Path is recorded as the path of the import, such as the directory the
+	// files were found in.
+	Path string
+	// Redactor redacts IP addresses and user agents before they are stored.
+	Redactor redact.Redactor
+}
+
+// Result describes the listens of an ImportFiles call.
+type Result struct {
+	// Usernames are the usernames of the listens read, imported before or
+	// not, in order of first appearance.
+	Usernames []string
+	// Imported is the number of listens stored, and Skipped the number of
+	// listens already imported by a previous run.
+	Imported int
+	Skipped  int
+}
+
+// FindExports returns the export at path, or the exports found under it
+// when it is a directory. Files of a directory no importer reads are
+// skipped. Exports are read by forced when not nil, keeping the files of a
+// directory it detects, and by the importer detected from their content
+// otherwise.
+func FindExports(ctx context.Context, path string, forced listen.Importer) ([]Export, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.Stat: %w", err)
+	}
+
+	if !info.IsDir() {
+		if forced != nil {
+			return []Export{{Path: path, Importer: forced}}, nil
+		}
+
+		importer, err := listen.Detect(path)
+		if err != nil {
+			return nil, fmt.Errorf("listen.Detect: %w", err)
+		}
+		return []Export{{Path: path, Importer: importer}}, nil
+	}
+
+	var exports []Export
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		importer, err := listen.Detect(file)
+		if errors.Is(err, listen.ErrUnknownFormat) {
+			zerolog.Ctx(ctx).Debug().Str("file", file).Msg("Skipping file of unknown format")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("listen.Detect: %w", err)
+		}
+
+		if forced == nil || importer.Format().Name == forced.Format().Name {
+			exports = append(exports, Export{Path: file, Importer: importer})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("filepath.WalkDir: %w", err)
+	}
+
+	if len(exports) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoExport, path)
+	}
+
+	return exports, nil
+}
+
+// ImportFiles stores the listens of files read by importer that weren't
+// imported yet, and records their import under opts.Path. Files of a format
+// are best imported together, as listens already imported are skipped by
+// time whatever the order of the files.
+func ImportFiles(
+	ctx context.Context,
+	spotifySQLite *spotify.SQLite,
+	importer listen.Importer,
+	files []string,
+	opts Options,
+) (Result, error) {
+	logger := zerolog.Ctx(ctx)
+
+	var listens []listen.Listen
+	for _, file := range files {
+		logger.Debug().Str("file", file).Msg("Reading export")
+
+		seq, err := importer.Open(ctx, file, opts.Options)
+		if err != nil {
+			return Result{}, fmt.Errorf("importer.Open %s: %w", file, err)
+		}
+
+		for l, err := range seq {
+			if err != nil {
+				return Result{}, fmt.Errorf("importer.Open %s: %w", file, err)
+			}
+			listens = append(listens, l)
+		}
+	}
+
+	streams := spotify.FromListens(listens)
+
+	var result Result
+	for i := range streams {
+		if !slices.Contains(result.Usernames, streams[i].Username) {
+			result.Usernames = append(result.Usernames, streams[i].Username)
+		}
+	}
+
+	if opts.Redactor.Enabled() {
+		logger.Debug().
+			Str("ip_mode", string(opts.Redactor.IPMode)).
+			Str("user_agent_mode", string(opts.Redactor.UserAgentMode)).
+			Msg("Redacting streams")
+
+		for i := range streams {
+			streams[i].IPAddrDecrypted = opts.Redactor.IP(streams[i].IPAddrDecrypted)
+			streams[i].UserAgentDecrypted = opts.Redactor.UserAgent(streams[i].UserAgentDecrypted)
+		}
+	}
+
+	found := len(streams)
+	streams, err := spotifySQLite.ImportStreams(ctx, importer.Format().Source, opts.Path, streams, time.Now().UTC())
+	if err != nil {
+		return Result{}, fmt.Errorf("spotifySQLite.ImportStreams: %w", err)
+	}
+
+	result.Imported = len(streams)
+	result.Skipped = found - len(streams)
+	if result.Skipped > 0 {
+		logger.Info().
+			Str("format", importer.Format().Name).
+			Int("count", result.Skipped).
+			Msg("Skipped listens already imported by a previous run")
+	}
+
+	return result, nil
+}
+
+// Merge completes the imports of formats: it estimates the play times the
+// listens of formats may lack, then merges the listens of every registered
+// format that may duplicate the listens of another source. It runs once
+// every export is imported, whichever side came first.
+func Merge(ctx context.Context, spotifySQLite *spotify.SQLite, formats ...listen.Format) error {
+	logger := zerolog.Ctx(ctx)
+
+	var estimated []string
+	for _, format := range formats {
+		if !format.MissingPlayTimes || slices.Contains(estimated, format.Source) {
+			continue
+		}
+		estimated = append(estimated, format.Source)
+
+		if err := spotifySQLite.EstimatePlayTimes(ctx, format.Source); err != nil {
+			return fmt.Errorf("spotifySQLite.EstimatePlayTimes: %w", err)
+		}
+	}
+
+	for _, source := range scrobbleSources() {
+		merged, err := spotifySQLite.MergeScrobbles(ctx, source)
+		if err != nil {
+			return fmt.Errorf("spotifySQLite.MergeScrobbles: %w", err)
+		}
+		if merged > 0 {
+			logger.Info().Int64("count", merged).Str("source", source).Msg("Merged listens duplicating a listen of another source")
+		}
+	}
+
+	return nil
+}
+
+// scrobbleSources returns the sources of the registered formats whose
+// listens may duplicate the ones of another source.
+func scrobbleSources() []string {
+	var sources []string
+	for _, importer := range listen.Importers() {
+		if format := importer.Format(); format.Scrobbles && !slices.Contains(sources, format.Source) {
+			sources = append(sources, format.Source)
+		}
+	}
+	return sources
+}
@@ -0,0 +1,166 @@
+package ingest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+
+	"github.com/cadoween/decibel/internal/ingest"
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/internal/scrobblerlog"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/internal/youtubemusic"
+	"github.com/cadoween/decibel/pkg/redact"
+)
+
+const (
+	extendedJSON = `[{"ts":"2024-03-01T22:05:00Z","username":"alice","platform":"android","ms_played":305000,` +
+		`"ip_addr_decrypted":"192.168.1.42","master_metadata_track_name":"Jóga",` +
+		`"master_metadata_album_artist_name":"Björk","master_metadata_album_album_name":"Homogenic"},` +
+		`{"ts":"2024-03-01T23:00:00Z","username":"alice","ms_played":60000,"master_metadata_track_name":null,` +
+		`"episode_name":"Daily News","incognito_mode":true}]`
+	scrobblerLog = "#AUDIOSCROBBLER/1.1\n#TZ/UTC\n#CLIENT/Rockbox\n" +
+		"Björk\tHomogenic\tJóga\t3\t305\tL\t1709330400\n"
+)
+
+// newExports writes a Spotify history and a scrobbler log of the same play
+// to a directory.
+func newExports(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Streaming_History_Audio_2024.json"), []byte(extendedJSON), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".scrobbler.log"), []byte(scrobblerLog), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not an export"), 0o600))
+
+	return dir
+}
+
+func TestFindExports(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := newExports(t)
+
+	exports, err := ingest.FindExports(ctx, dir, nil)
+	require.NoError(t, err)
+	require.Len(t, exports, 2)
+	assert.Equal(t, scrobblerlog.Source, exports[0].Importer.Format().Name)
+	assert.Equal(t, spotify.SourceSpotify, exports[1].Importer.Format().Name)
+
+	importer, err := listen.Lookup(spotify.SourceSpotify)
+	require.NoError(t, err)
+
+	exports, err = ingest.FindExports(ctx, dir, importer)
+	require.NoError(t, err)
+	assert.Equal(t, []ingest.Export{{Path: filepath.Join(dir, "Streaming_History_Audio_2024.json"), Importer: importer}}, exports)
+
+	_, err = ingest.FindExports(ctx, t.TempDir(), nil)
+	require.ErrorIs(t, err, ingest.ErrNoExport)
+}
+
+func TestImportFiles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := newExports(t)
+
+	db, err := ksqlite.New(ctx, filepath.Join(t.TempDir(), "decibel.db"), ksql.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	spotifySQLite := spotify.NewSQLite(db)
+	require.NoError(t, spotifySQLite.Migrate(ctx))
+
+	spotifyImporter, err := listen.Lookup(spotify.SourceSpotify)
+	require.NoError(t, err)
+	logImporter, err := listen.Lookup(scrobblerlog.Source)
+	require.NoError(t, err)
+
+	history := []string{filepath.Join(dir, "Streaming_History_Audio_2024.json")}
+	opts := ingest.Options{Path: dir, Redactor: redact.Redactor{IPMode: redact.ModeTruncate}}
+
+	result, err := ingest.ImportFiles(ctx, spotifySQLite, spotifyImporter, history, opts)
+	require.NoError(t, err)
+	assert.Equal(t, ingest.Result{Usernames: []string{"alice"}, Imported: 2}, result)
+
+	// Importing the same files again skips every listen.
+	result, err = ingest.ImportFiles(ctx, spotifySQLite, spotifyImporter, history, opts)
+	require.NoError(t, err)
+	assert.Equal(t, ingest.Result{Usernames: []string{"alice"}, Skipped: 2}, result)
+
+	logOpts := ingest.Options{Options: listen.Options{Username: "alice", Location: time.UTC}, Path: dir}
+	result, err = ingest.ImportFiles(ctx, spotifySQLite, logImporter, []string{filepath.Join(dir, ".scrobbler.log")}, logOpts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Imported)
+
+	// The scrobble of the Spotify stream is merged into it.
+	require.NoError(t, ingest.Merge(ctx, spotifySQLite, logImporter.Format()))
+
+	var streams []struct {
+		Source    string  `ksql:"source"`
+		IPAddr    string  `ksql:"ip_addr_decrypted"`
+		Episode   *string `ksql:"episode_name"`
+		Incognito bool    `ksql:"incognito_mode"`
+	}
+	require.NoError(t, db.Query(ctx, &streams, "SELECT source, ip_addr_decrypted, episode_name, incognito_mode FROM spotify_streams ORDER BY ts"))
+	require.Len(t, streams, 2)
+
+	assert.Equal(t, spotify.SourceSpotify, streams[0].Source)
+	assert.Equal(t, "192.168.1.0/24", streams[0].IPAddr)
+	assert.Nil(t, streams[0].Episode)
+
+	require.NotNil(t, streams[1].Episode)
+	assert.Equal(t, "Daily News", *streams[1].Episode)
+	assert.True(t, streams[1].Incognito)
+}
+
+func TestImportFiles_OverlappingTakeouts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := ksqlite.New(ctx, filepath.Join(t.TempDir(), "decibel.db"), ksql.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	spotifySQLite := spotify.NewSQLite(db)
+	require.NoError(t, spotifySQLite.Migrate(ctx))
+
+	importer, err := listen.Lookup(youtubemusic.Source)
+	require.NoError(t, err)
+
+	entry := func(track, ts string) string {
+		return `{"header":"YouTube Music","title":"Watched ` + track + `","titleUrl":"https://music.youtube.com/watch?v=` + track +
+			`","subtitles":[{"name":"Portishead - Topic"}],"time":"` + ts + `"}`
+	}
+	a, b, c := entry("Roads", "2024-03-01T22:00:00Z"), entry("Sour Times", "2024-03-01T22:05:00Z"), entry("Glory Box", "2024-03-01T22:09:00Z")
+
+	// The later Takeout holds the history of the earlier one, whose last
+	// listen now has a next one to estimate its play time from.
+	for _, history := range []string{"[" + b + "," + a + "]", "[" + c + "," + b + "," + a + "]"} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, youtubemusic.FileName)
+		require.NoError(t, os.WriteFile(path, []byte(history), 0o600))
+
+		opts := ingest.Options{Options: listen.Options{Username: "alice"}, Path: dir}
+		_, err := ingest.ImportFiles(ctx, spotifySQLite, importer, []string{path}, opts)
+		require.NoError(t, err)
+	}
+
+	var tracks []struct {
+		Track string `ksql:"master_metadata_track_name"`
+	}
+	require.NoError(t, db.Query(ctx, &tracks, "SELECT master_metadata_track_name FROM spotify_streams ORDER BY ts"))
+	require.Len(t, tracks, 3)
+	assert.Equal(t, "Roads", tracks[0].Track)
+	assert.Equal(t, "Sour Times", tracks[1].Track)
+	assert.Equal(t, "Glory Box", tracks[2].Track)
+}
@@ -0,0 +1,76 @@
+package lastfm
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"strings"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/pkg/ext"
+)
+
+func init() {
+	listen.Register(Importer{})
+}
+
+// Importer reads Last.fm exports as listens.
+type Importer struct{}
+
+func (Importer) Format() listen.Format {
+	return listen.Format{
+		Name:             Source,
+		Source:           Source,
+		Description:      "Last.fm scrobbles, as CSV or user.getRecentTracks JSON pages",
+		Scrobbles:        true,
+		MissingPlayTimes: true,
+	}
+}
+
+// Detect recognizes CSV files starting with a header naming the scrobble
+// columns or with a scrobble as written by lastfm-to-csv, and JSON files of
+// recent tracks.
+func (Importer) Detect(path string, head []byte) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ext.CSV:
+		line, _, _ := bytes.Cut(head, []byte("\n"))
+		first, err := csv.NewReader(bytes.NewReader(line)).Read()
+		if err != nil {
+			return false
+		}
+
+		columns, hasHeader, err := csvColumns(first)
+		if err != nil {
+			return false
+		}
+		if hasHeader {
+			return true
+		}
+
+		if len(first) != columns.time+1 {
+			return false
+		}
+		_, err = parseTime(strings.TrimSpace(first[columns.time]))
+		return err == nil
+	case ext.JSON:
+		return bytes.Contains(head, []byte(`"recenttracks"`)) || bytes.Contains(head, []byte(`"#text"`))
+	default:
+		return false
+	}
+}
+
+func (Importer) Open(_ context.Context, path string, opts listen.Options) (iter.Seq2[listen.Listen, error], error) {
+	if opts.Username == "" {
+		return nil, listen.ErrUsernameRequired
+	}
+
+	scrobbles, err := ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ReadFile: %w", err)
+	}
+
+	return listen.All(ToListens(scrobbles, opts.Username)), nil
+}
@@ -0,0 +1,351 @@
+// Package lastfm reads Last.fm scrobble exports and maps them into listens,
+// so statistics can span Last.fm and Spotify.
+package lastfm
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+// Source is the source recorded for streams and imports of Last.fm exports.
+const Source = "lastfm"
+
+var (
+	ErrUnsupportedFile = errors.New("unsupported file, expected a .csv or .json Last.fm export")
+	ErrInvalidTime     = errors.New("invalid scrobble time")
+	ErrMissingColumn   = errors.New("missing column")
+)
+
+// timeLayouts are the layouts of scrobble times found in CSV exports, tried
+// in order after Unix timestamps.
+var timeLayouts = []string{
+	"02 Jan 2006 15:04",
+	"02 Jan 2006, 15:04",
+	"2 Jan 2006 15:04",
+	time.DateTime,
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+}
+
+// Scrobble is a track played, as recorded by Last.fm. TS is when the track
+// started playing.
+type Scrobble struct {
+	TS     time.Time
+	Artist string
+	Album  string
+	Track  string
+}
+
+// ReadFile reads the scrobbles of a CSV or JSON export, depending on the
+// extension of path.
+func ReadFile(path string) ([]Scrobble, error) {
+	f, err := os.Open(path) //nolint:gosec // The path is given by the user on purpose.
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer iox.Close(f)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ReadCSV(f)
+	case ".json":
+		return ReadJSON(f)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFile, path)
+	}
+}
+
+// ReadCSV reads scrobbles from a CSV export. Files with a header row are
+// matched by column name, such as the uts, artist, album and track columns
+// of most export tools. Files without one are read as artist, album, track
+// and date columns, as written by lastfm-to-csv. Rows without a time, like
+// a track playing during the export, are skipped.
+func ReadCSV(r io.Reader) ([]Scrobble, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reader.ReadAll: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns, hasHeader, err := csvColumns(records[0])
+	if err != nil {
+		return nil, fmt.Errorf("csvColumns: %w", err)
+	}
+	if hasHeader {
+		records = records[1:]
+	}
+
+	scrobbles := make([]Scrobble, 0, len(records))
+	for i, record := range records {
+		value := func(column int) string {
+			if column < 0 || column >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[column])
+		}
+
+		if value(columns.time) == "" {
+			continue
+		}
+
+		ts, err := parseTime(value(columns.time))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+
+		scrobbles = append(scrobbles, Scrobble{
+			TS:     ts,
+			Artist: value(columns.artist),
+			Album:  value(columns.album),
+			Track:  value(columns.track),
+		})
+	}
+
+	return scrobbles, nil
+}
+
+type csvColumnIndexes struct {
+	time, artist, album, track int
+}
+
+// csvColumns finds the columns of a CSV export from its first row, or
+// falls back to the column order of lastfm-to-csv when it isn't a header.
+func csvColumns(first []string) (csvColumnIndexes, bool, error) {
+	find := func(names ...string) int {
+		for _, name := range names {
+			if i := slices.IndexFunc(first, func(column string) bool {
+				return strings.EqualFold(strings.TrimSpace(column), name)
+			}); i >= 0 {
+				return i
+			}
+		}
+		return -1
+	}
+
+	columns := csvColumnIndexes{
+		time:   find("uts", "timestamp", "utc_time", "date", "time", "played_at"),
+		artist: find("artist", "artist_name"),
+		album:  find("album", "album_name"),
+		track:  find("track", "track_name", "name", "title"),
+	}
+
+	if columns.artist < 0 && columns.track < 0 {
+		return csvColumnIndexes{artist: 0, album: 1, track: 2, time: 3}, false, nil
+	}
+
+	for name, index := range map[string]int{"time": columns.time, "artist": columns.artist, "track": columns.track} {
+		if index < 0 {
+			return csvColumnIndexes{}, false, fmt.Errorf("%w: %s", ErrMissingColumn, name)
+		}
+	}
+
+	return columns, true, nil
+}
+
+// ReadJSON reads scrobbles from a JSON export. It accepts the pages of the
+// user.getRecentTracks API as saved by most export tools, either as a single
+// page or an array of pages, and flat arrays of tracks. Tracks playing
+// during the export are skipped.
+func ReadJSON(r io.Reader) ([]Scrobble, error) {
+	var data json.RawMessage
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, fmt.Errorf("json.NewDecoder(r).Decode: %w", err)
+	}
+
+	tracks, err := collectTracks(data)
+	if err != nil {
+		return nil, fmt.Errorf("collectTracks: %w", err)
+	}
+
+	scrobbles := make([]Scrobble, 0, len(tracks))
+	for _, track := range tracks {
+		if track.Attr.NowPlaying == "true" || track.Date == "" {
+			continue
+		}
+
+		ts, err := parseTime(string(track.Date))
+		if err != nil {
+			return nil, err
+		}
+
+		scrobbles = append(scrobbles, Scrobble{
+			TS:     ts,
+			Artist: string(track.Artist),
+			Album:  string(track.Album),
+			Track:  string(cmp.Or(track.Name, track.Track)),
+		})
+	}
+
+	return scrobbles, nil
+}
+
+// jsonTrack is a track of the recent tracks API, or of a flat export.
+type jsonTrack struct {
+	Artist text     `json:"artist"`
+	Album  text     `json:"album"`
+	Name   text     `json:"name"`
+	Track  text     `json:"track"`
+	Date   jsonTime `json:"date"`
+	Attr   struct {
+		NowPlaying string `json:"nowplaying"`
+	} `json:"@attr"`
+}
+
+// collectTracks walks pages and arrays down to the tracks they contain.
+func collectTracks(data json.RawMessage) ([]jsonTrack, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+
+	if data[0] == '[' {
+		var elements []json.RawMessage
+		if err := json.Unmarshal(data, &elements); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+
+		var tracks []jsonTrack
+		for _, element := range elements {
+			elementTracks, err := collectTracks(element)
+			if err != nil {
+				return nil, err
+			}
+			tracks = append(tracks, elementTracks...)
+		}
+		return tracks, nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	// A page, holding its tracks under "recenttracks" and then "track".
+	for _, key := range []string{"recenttracks", "track"} {
+		if nested, ok := object[key]; ok && isContainer(nested, key) {
+			return collectTracks(nested)
+		}
+	}
+
+	var track jsonTrack
+	if err := json.Unmarshal(data, &track); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	return []jsonTrack{track}, nil
+}
+
+// isContainer reports whether the value of key holds tracks rather than
+// being a field of a track, "track" being the name of the track in some
+// flat exports.
+func isContainer(value json.RawMessage, key string) bool {
+	value = bytes.TrimSpace(value)
+	if key == "recenttracks" {
+		return true
+	}
+	return len(value) > 0 && (value[0] == '[' || value[0] == '{' && bytes.Contains(value, []byte(`"artist"`)))
+}
+
+// text is a name given either as a string or as an object with a "#text"
+// or "name" field, as the API does for artists and albums.
+type text string
+
+func (t *text) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = text(s)
+		return nil
+	}
+
+	var object struct {
+		Text string `json:"#text"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	*t = text(cmp.Or(object.Text, object.Name))
+	return nil
+}
+
+// jsonTime is a scrobble time given as a Unix timestamp, as a number or a
+// string, or as an object with a "uts" field.
+type jsonTime string
+
+func (t *jsonTime) UnmarshalJSON(data []byte) error {
+	var object struct {
+		UTS  string `json:"uts"`
+		Text string `json:"#text"`
+	}
+	if err := json.Unmarshal(data, &object); err == nil {
+		*t = jsonTime(cmp.Or(object.UTS, object.Text))
+		return nil
+	}
+
+	*t = jsonTime(strings.Trim(string(data), `"`))
+	return nil
+}
+
+// parseTime parses a Unix timestamp in seconds or milliseconds, or a time
+// in one of timeLayouts, as UTC.
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		// Timestamps past 1e11 seconds are in the year 5138, so they must be
+		// milliseconds.
+		if unix > 1e11 {
+			return time.UnixMilli(unix).UTC(), nil
+		}
+		return time.Unix(unix, 0).UTC(), nil
+	}
+
+	for _, layout := range timeLayouts {
+		if ts, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return ts.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidTime, s)
+}
+
+// ToListens maps scrobbles to listens of username, without play time since
+// scrobbles don't record it.
+func ToListens(scrobbles []Scrobble, username string) []listen.Listen {
+	listens := make([]listen.Listen, 0, len(scrobbles))
+	for _, scrobble := range scrobbles {
+		listens = append(listens, listen.Listen{
+			EndedAt:  scrobble.TS,
+			Username: username,
+			Platform: "Last.fm",
+			Track:    scrobble.Track,
+			Artist:   scrobble.Artist,
+			Album:    scrobble.Album,
+			Source:   Source,
+		})
+	}
+
+	return listens
+}
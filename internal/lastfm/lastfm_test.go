@@ -0,0 +1,178 @@
+package lastfm_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/lastfm"
+	"github.com/cadoween/decibel/internal/listen"
+)
+
+func TestReadCSV(t *testing.T) {
+	t.Parallel()
+
+	want := []lastfm.Scrobble{
+		{TS: time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC), Artist: "Björk", Album: "Homogenic", Track: "Jóga"},
+		{TS: time.Date(2024, 3, 1, 22, 5, 0, 0, time.UTC), Artist: "Portishead", Album: "", Track: "Roads"},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []lastfm.Scrobble
+		wantErr error
+	}{
+		{
+			name: "header with unix timestamps",
+			input: "uts,utc_time,artist,artist_mbid,album,album_mbid,track,track_mbid\n" +
+				"1709330400,\"01 Mar 2024, 22:00\",Björk,,Homogenic,,Jóga,\n" +
+				"1709330700,\"01 Mar 2024, 22:05\",Portishead,,,,Roads,\n",
+			want: want,
+		},
+		{
+			name: "no header",
+			input: "Björk,Homogenic,Jóga,01 Mar 2024 22:00\n" +
+				"Portishead,,Roads,01 Mar 2024 22:05\n" +
+				"Massive Attack,Mezzanine,Teardrop,\n",
+			want: want,
+		},
+		{
+			name:  "header with dates",
+			input: "Date,Artist,Track,Album\n2024-03-01 22:00:00,Björk,Jóga,Homogenic\n2024-03-01T22:05:00Z,Portishead,Roads,\n",
+			want:  want,
+		},
+		{
+			name:    "missing time column",
+			input:   "artist,track\nBjörk,Jóga\n",
+			wantErr: lastfm.ErrMissingColumn,
+		},
+		{
+			name:    "invalid time",
+			input:   "Björk,Homogenic,Jóga,yesterday\n",
+			wantErr: lastfm.ErrInvalidTime,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := lastfm.ReadCSV(strings.NewReader(tt.input))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	t.Parallel()
+
+	page := `{"recenttracks": {"track": [
+		{"artist": {"#text": "Björk", "mbid": ""}, "album": {"#text": "Homogenic"}, "name": "Jóga",
+		 "@attr": {"nowplaying": "true"}},
+		{"artist": {"#text": "Björk", "mbid": ""}, "album": {"#text": "Homogenic"}, "name": "Jóga",
+		 "date": {"uts": "1709330400", "#text": "01 Mar 2024, 22:00"}}
+	]}}`
+
+	want := []lastfm.Scrobble{
+		{TS: time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC), Artist: "Björk", Album: "Homogenic", Track: "Jóga"},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []lastfm.Scrobble
+	}{
+		{name: "api page", input: page, want: want},
+		{name: "array of api pages", input: "[" + page + "," + page + "]", want: append(want, want...)},
+		{
+			name:  "array of pages of tracks",
+			input: `[{"track": [{"artist": {"name": "Björk"}, "album": "Homogenic", "name": "Jóga", "date": {"uts": "1709330400"}}]}]`,
+			want:  want,
+		},
+		{
+			name:  "flat tracks",
+			input: `[{"artist": "Björk", "album": "Homogenic", "track": "Jóga", "date": 1709330400000}]`,
+			want:  want,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := lastfm.ReadJSON(strings.NewReader(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "scrobbles.txt")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	_, err := lastfm.ReadFile(path)
+	require.ErrorIs(t, err, lastfm.ErrUnsupportedFile)
+
+	path = filepath.Join(dir, "scrobbles.CSV")
+	require.NoError(t, os.WriteFile(path, []byte("Björk,Homogenic,Jóga,01 Mar 2024 22:00\n"), 0o600))
+
+	got, err := lastfm.ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func TestToListens(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+	got := lastfm.ToListens([]lastfm.Scrobble{{TS: ts, Artist: "Björk", Album: "Homogenic", Track: "Jóga"}}, "alice")
+
+	assert.Equal(t, []listen.Listen{{
+		EndedAt:  ts,
+		Username: "alice",
+		Platform: "Last.fm",
+		Track:    "Jóga",
+		Artist:   "Björk",
+		Album:    "Homogenic",
+		Source:   lastfm.Source,
+	}}, got)
+}
+
+func TestImporter_Detect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		head string
+		want bool
+	}{
+		{path: "scrobbles.csv", head: "uts,artist,album,track\n1641045540,Massive Attack,Mezzanine,Teardrop\n", want: true},
+		{path: "scrobbles.CSV", head: "Massive Attack,Mezzanine,Teardrop,31 Jan 2022 12:00\n", want: true},
+		{path: "scrobbles.csv", head: "Massive Attack,Mezzanine,Teardrop,yesterday\n"},
+		{path: "Apple Music Play Activity.csv", head: "Apple Id Number,Artist Name,Content Name,Event Type\n"},
+		{path: "recenttracks.json", head: `{"recenttracks":{"track":[]}}`, want: true},
+		{path: "watch-history.json", head: `[{"title":"Watched Teardrop","time":"2024-03-01T22:00:00Z"}]`},
+		{path: "scrobbles.txt", head: "uts,artist,album,track\n"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, lastfm.Importer{}.Detect(tt.path, []byte(tt.head)), tt.head)
+	}
+}
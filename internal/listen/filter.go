@@ -0,0 +1,29 @@
+package listen
+
+import "time"
+
+// Filter narrows down the listens used to compute statistics. The zero value
+// matches every listen.
+type Filter struct {
+	// Usernames keeps the listens of the given usernames only.
+	Usernames []string
+	// Sources keeps the listens imported from the given sources only, such
+	// as "spotify".
+	Sources []string
+	// From and To keep the listens played within [From, To) only, zero
+	// values leave the range open.
+	From time.Time
+	To   time.Time
+	// MinPlayed keeps the listens played for at least this long only.
+	MinPlayed time.Duration
+	// Limit overrides the number of rows returned by top lists, and caps the
+	// number of listens queried.
+	Limit int
+}
+
+// WithPeriod returns a copy of the filter restricted to period.
+func (f Filter) WithPeriod(period Period) Filter {
+	f.From = period.From
+	f.To = period.To
+	return f
+}
@@ -0,0 +1,147 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+// HeadSize is the number of bytes at the start of a file given to
+// Importer.Detect.
+const HeadSize = 4096
+
+var (
+	ErrUnknownFormat    = errors.New("unknown export format")
+	ErrUsernameRequired = errors.New("username required, the export doesn't record one")
+)
+
+// Importer reads the listens of the exports of a format. Importers register
+// themselves with Register, usually from the init function of their
+// package, so any of them can be detected from the content of a file.
+type Importer interface {
+	// Format describes the exports read by the importer.
+	Format() Format
+	// Detect reports whether the file at path, whose content starts with
+	// head, is an export the importer reads. It must not read the file.
+	Detect(path string, head []byte) bool
+	// Open reads the export at path. Its listens are given in order by the
+	// returned sequence, which may read the export while iterated.
+	Open(ctx context.Context, path string, opts Options) (iter.Seq2[Listen, error], error)
+}
+
+// Format describes the exports read by an importer.
+type Format struct {
+	// Name identifies the format, e.g. "lastfm".
+	Name string
+	// Source is the source recorded for the listens of the format.
+	Source      string
+	Description string
+	// Scrobbles is set when listens may duplicate the listens of another
+	// source, such as scrobbles of plays made on another service, to merge
+	// them into the listens of that source once both are imported.
+	Scrobbles bool
+	// MissingPlayTimes is set when listens may be imported without play
+	// time, to be estimated once imported.
+	MissingPlayTimes bool
+}
+
+// Options are given to Importer.Open.
+type Options struct {
+	// Username is the username to store listens under, for exports that
+	// don't record one.
+	Username string
+	// Location is the time zone of exports recording local times without
+	// one, time.Local when nil.
+	Location *time.Location
+}
+
+var registry struct {
+	sync.RWMutex
+	importers []Importer
+}
+
+// Register makes importer available to Detect and Lookup. It panics when an
+// importer of the same format name is already registered, like
+// database/sql drivers.
+func Register(importer Importer) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	name := importer.Format().Name
+	for _, registered := range registry.importers {
+		if registered.Format().Name == name {
+			panic("listen: Register called twice for importer " + name)
+		}
+	}
+
+	registry.importers = append(registry.importers, importer)
+}
+
+// Importers returns the registered importers, sorted by format name.
+func Importers() []Importer {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	importers := slices.Clone(registry.importers)
+	slices.SortFunc(importers, func(a, b Importer) int {
+		return strings.Compare(a.Format().Name, b.Format().Name)
+	})
+
+	return importers
+}
+
+// Lookup returns the registered importer of the format name.
+func Lookup(name string) (Importer, error) {
+	for _, importer := range Importers() {
+		if importer.Format().Name == name {
+			return importer, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+}
+
+// Detect returns the registered importer reading the file at path, from the
+// first HeadSize bytes of its content.
+func Detect(path string) (Importer, error) {
+	f, err := os.Open(path) //nolint:gosec // The path is given by the user on purpose.
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer iox.Close(f)
+
+	head := make([]byte, HeadSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("io.ReadFull: %w", err)
+	}
+
+	for _, importer := range Importers() {
+		if importer.Detect(path, head[:n]) {
+			return importer, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+}
+
+// All returns a sequence of listens, for importers reading their exports at
+// once.
+func All(listens []Listen) iter.Seq2[Listen, error] {
+	return func(yield func(Listen, error) bool) {
+		for _, l := range listens {
+			if !yield(l, nil) {
+				return
+			}
+		}
+	}
+}
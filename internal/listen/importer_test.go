@@ -0,0 +1,109 @@
+package listen_test
+
+import (
+	"bytes"
+	"context"
+	"iter"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/listen"
+)
+
+// fakeImporter reads files starting with its prefix, one listen per line.
+type fakeImporter struct {
+	name   string
+	prefix string
+}
+
+func init() {
+	listen.Register(fakeImporter{name: "fake-b", prefix: "#FAKE-B"})
+	listen.Register(fakeImporter{name: "fake-a", prefix: "#FAKE-A"})
+}
+
+func (i fakeImporter) Format() listen.Format {
+	return listen.Format{Name: i.name, Source: i.name}
+}
+
+func (i fakeImporter) Detect(_ string, head []byte) bool {
+	return bytes.HasPrefix(head, []byte(i.prefix))
+}
+
+func (i fakeImporter) Open(_ context.Context, path string, opts listen.Options) (iter.Seq2[listen.Listen, error], error) {
+	content, err := os.ReadFile(path) //nolint:gosec // The path is a test file.
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Test importer.
+	}
+
+	var listens []listen.Listen
+	for _, line := range bytes.Split(bytes.TrimSpace(content), []byte("\n"))[1:] {
+		listens = append(listens, listen.Listen{Track: string(line), Username: opts.Username, Source: i.name})
+	}
+
+	return listen.All(listens), nil
+}
+
+func TestImporters(t *testing.T) {
+	t.Parallel()
+
+	var names []string
+	for _, importer := range listen.Importers() {
+		names = append(names, importer.Format().Name)
+	}
+	assert.Equal(t, []string{"fake-a", "fake-b"}, names)
+
+	importer, err := listen.Lookup("fake-b")
+	require.NoError(t, err)
+	assert.Equal(t, "fake-b", importer.Format().Name)
+
+	_, err = listen.Lookup("spotify")
+	require.ErrorIs(t, err, listen.ErrUnknownFormat)
+
+	assert.Panics(t, func() {
+		listen.Register(fakeImporter{name: "fake-a"})
+	})
+}
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	importer, err := listen.Detect(write("b.txt", "#FAKE-B\nRoads\nTeardrop\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "fake-b", importer.Format().Name)
+
+	path := write("a.txt", "#FAKE-A\nJóga\n")
+	importer, err = listen.Detect(path)
+	require.NoError(t, err)
+	assert.Equal(t, "fake-a", importer.Format().Name)
+
+	seq, err := importer.Open(context.Background(), path, listen.Options{Username: "alice", Location: time.UTC})
+	require.NoError(t, err)
+
+	var got []listen.Listen
+	for l, err := range seq {
+		require.NoError(t, err)
+		got = append(got, l)
+	}
+	assert.Equal(t, []listen.Listen{{Track: "Jóga", Username: "alice", Source: "fake-a"}}, got)
+
+	_, err = listen.Detect(write("empty.txt", ""))
+	require.ErrorIs(t, err, listen.ErrUnknownFormat)
+
+	_, err = listen.Detect(write("other.txt", "Jóga\n"))
+	require.ErrorIs(t, err, listen.ErrUnknownFormat)
+
+	_, err = listen.Detect(filepath.Join(dir, "missing.txt"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
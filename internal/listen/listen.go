@@ -0,0 +1,63 @@
+// Package listen is the service-neutral model of the listening history:
+// every service is imported as listens, and statistics read them back
+// whichever service they came from.
+package listen
+
+import (
+	"context"
+	"time"
+)
+
+// Listen is a play of a track on any service. Services record either when a
+// play started or when it ended, listens keep the end like Spotify does and
+// derive the start from the play time.
+type Listen struct {
+	EndedAt  time.Time
+	Username string
+	// Source is the service the listen was imported from, e.g. "spotify" or
+	// "lastfm".
+	Source string
+	Track  string
+	Artist string
+	Album  string
+	// TrackURI is the Spotify URI of the track, when the service knows it.
+	TrackURI string
+	// Played is zero when the service doesn't record it, which the store
+	// may estimate afterwards.
+	Played  time.Duration
+	Skipped bool
+	Shuffle bool
+	Offline bool
+	// ReasonStart and ReasonEnd use the Spotify reasons, e.g. "trackdone"
+	// or "fwdbtn".
+	ReasonStart string
+	ReasonEnd   string
+	// Platform and UserAgent describe the device the listen was played on,
+	// and are normalized into a device by the store.
+	Platform  string
+	UserAgent string
+	Country   string
+	IPAddr    string
+	// Episode, Show and EpisodeURI are set instead of the track for podcast
+	// episodes, by services recording them.
+	Episode    string
+	Show       string
+	EpisodeURI string
+	// Incognito is set for listens played in a private session.
+	Incognito bool
+	// OfflineTimestamp is the Spotify offline_timestamp of listens played
+	// offline.
+	OfflineTimestamp int64
+}
+
+// StartedAt returns the time the track started playing.
+func (l Listen) StartedAt() time.Time {
+	return l.EndedAt.Add(-l.Played)
+}
+
+// Store holds the listens of every service in a single history, which
+// exporters read back whichever service the listens came from.
+type Store interface {
+	// QueryListens returns the listens matching filter, oldest first.
+	QueryListens(ctx context.Context, filter Filter) ([]Listen, error)
+}
@@ -0,0 +1,77 @@
+package listen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+// Period is a half-open time range [From, To).
+type Period struct {
+	From  time.Time
+	To    time.Time
+	Label string
+}
+
+// periodLayouts are the layouts accepted for each side of a period, along
+// with the duration of the period they describe.
+var periodLayouts = []struct {
+	layout string
+	next   func(time.Time) time.Time
+}{
+	{layout: time.DateOnly, next: func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+	{layout: "2006-01", next: func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+	{layout: "2006", next: func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+}
+
+// ParsePeriod parses a year ("2023"), a month ("2023-05"), a day
+// ("2023-05-17") or an inclusive range of those ("2023-01..2023-06"). Either
+// side of a range can be left empty to leave it open.
+func ParsePeriod(s string) (Period, error) {
+	s = strings.TrimSpace(s)
+	period := Period{Label: s}
+
+	start, end, isRange := strings.Cut(s, "..")
+	if !isRange {
+		end = start
+	}
+
+	if start != "" {
+		from, _, err := parsePeriodBound(start)
+		if err != nil {
+			return Period{}, err
+		}
+		period.From = from
+	}
+
+	if end != "" {
+		_, to, err := parsePeriodBound(end)
+		if err != nil {
+			return Period{}, err
+		}
+		period.To = to
+	}
+
+	if period.From.IsZero() && period.To.IsZero() {
+		return Period{}, fmt.Errorf("%w: %q", ErrInvalidPeriod, s)
+	}
+	if !period.From.IsZero() && !period.To.IsZero() && !period.From.Before(period.To) {
+		return Period{}, fmt.Errorf("%w: %q ends before it starts", ErrInvalidPeriod, s)
+	}
+
+	return period, nil
+}
+
+// parsePeriodBound returns the start and the end of the period described by
+// s, e.g. 2023-05-01 and 2023-06-01 for "2023-05".
+func parsePeriodBound(s string) (time.Time, time.Time, error) {
+	for _, layout := range periodLayouts {
+		if t, err := time.Parse(layout.layout, strings.TrimSpace(s)); err == nil {
+			return t, layout.next(t), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%w: %q", ErrInvalidPeriod, s)
+}
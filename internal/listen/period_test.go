@@ -0,0 +1,53 @@
+package listen_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/listen"
+)
+
+func TestParsePeriod(t *testing.T) {
+	t.Parallel()
+
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		input   string
+		want    listen.Period
+		wantErr error
+	}{
+		{input: "2023", want: listen.Period{From: date(2023, 1, 1), To: date(2024, 1, 1), Label: "2023"}},
+		{input: "2023-05", want: listen.Period{From: date(2023, 5, 1), To: date(2023, 6, 1), Label: "2023-05"}},
+		{input: "2023-05-17", want: listen.Period{From: date(2023, 5, 17), To: date(2023, 5, 18), Label: "2023-05-17"}},
+		{
+			input: "2023-01..2023-06-30",
+			want:  listen.Period{From: date(2023, 1, 1), To: date(2023, 7, 1), Label: "2023-01..2023-06-30"},
+		},
+		{input: "2024..", want: listen.Period{From: date(2024, 1, 1), Label: "2024.."}},
+		{input: "..2020", want: listen.Period{To: date(2021, 1, 1), Label: "..2020"}},
+		{input: "2024..2023", wantErr: listen.ErrInvalidPeriod},
+		{input: "last year", wantErr: listen.ErrInvalidPeriod},
+		{input: "..", wantErr: listen.ErrInvalidPeriod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := listen.ParsePeriod(tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
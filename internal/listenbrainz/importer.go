@@ -0,0 +1,46 @@
+package listenbrainz
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"iter"
+
+	"github.com/cadoween/decibel/internal/listen"
+)
+
+func init() {
+	listen.Register(Importer{})
+}
+
+// Importer reads ListenBrainz exports as listens.
+type Importer struct{}
+
+func (Importer) Format() listen.Format {
+	return listen.Format{
+		Name:             Source,
+		Source:           Source,
+		Description:      "ListenBrainz listen export, as JSON or JSON Lines, or a file written by listenbrainz export",
+		Scrobbles:        true,
+		MissingPlayTimes: true,
+	}
+}
+
+// Detect recognizes files of listens, whatever their extension since JSON
+// Lines exports come as .jsonl.
+func (Importer) Detect(_ string, head []byte) bool {
+	return bytes.Contains(head, []byte(`"listened_at"`)) && bytes.Contains(head, []byte(`"track_metadata"`))
+}
+
+func (Importer) Open(_ context.Context, path string, opts listen.Options) (iter.Seq2[listen.Listen, error], error) {
+	if opts.Username == "" {
+		return nil, listen.ErrUsernameRequired
+	}
+
+	listens, err := ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ReadFile: %w", err)
+	}
+
+	return listen.All(ToListens(listens, opts.Username)), nil
+}
@@ -0,0 +1,226 @@
+// Package listenbrainz reads listens exported from ListenBrainz and writes
+// streams in its import format, so history can move between decibel and
+// ListenBrainz offline.
+package listenbrainz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+// Source is the source recorded for streams and imports of ListenBrainz
+// exports.
+const Source = "listenbrainz"
+
+const (
+	// ListenTypeImport is the listen type of listens imported in bulk.
+	ListenTypeImport = "import"
+	// MaxListensPerPayload is the number of listens the ListenBrainz API
+	// accepts in a single submission.
+	MaxListensPerPayload = 1000
+
+	spotifyTrackURI = "spotify:track:"
+	spotifyTrackURL = "https://open.spotify.com/track/"
+)
+
+var ErrInvalidListen = errors.New("invalid listen")
+
+// Listen is a listen as exported and imported by ListenBrainz. ListenedAt is
+// when the track started playing, in Unix seconds.
+type Listen struct {
+	ListenedAt    int64         `json:"listened_at"`
+	TrackMetadata TrackMetadata `json:"track_metadata"`
+}
+
+type TrackMetadata struct {
+	ArtistName     string         `json:"artist_name"`
+	TrackName      string         `json:"track_name"`
+	ReleaseName    string         `json:"release_name,omitempty"`
+	AdditionalInfo AdditionalInfo `json:"additional_info"`
+}
+
+type AdditionalInfo struct {
+	SpotifyID        string `json:"spotify_id,omitempty"`
+	MusicService     string `json:"music_service,omitempty"`
+	SubmissionClient string `json:"submission_client,omitempty"`
+	// DurationMS is the duration of the track, which some clients send in
+	// seconds as Duration instead.
+	DurationMS int64 `json:"duration_ms,omitempty"`
+	Duration   int64 `json:"duration,omitempty"`
+}
+
+// Payload is the body of a listen submission.
+type Payload struct {
+	ListenType string   `json:"listen_type"`
+	Payload    []Listen `json:"payload"`
+}
+
+// ReadFile reads the listens of the export at path.
+func ReadFile(path string) ([]Listen, error) {
+	f, err := os.Open(path) //nolint:gosec // The path is given by the user on purpose.
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer iox.Close(f)
+
+	return Read(f)
+}
+
+// Read reads listens from the JSON array of the listen export, from JSON
+// Lines of listens as found in newer export archives, or from submission
+// payloads as written by Write.
+func Read(r io.Reader) ([]Listen, error) {
+	decoder := json.NewDecoder(r)
+
+	var listens []Listen
+	for {
+		var value json.RawMessage
+		err := decoder.Decode(&value)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoder.Decode: %w", err)
+		}
+
+		decoded, err := decode(value)
+		if err != nil {
+			return nil, err
+		}
+		listens = append(listens, decoded...)
+	}
+
+	for i, listen := range listens {
+		if listen.ListenedAt <= 0 || listen.TrackMetadata.TrackName == "" {
+			return nil, fmt.Errorf("%w: listen %d has no listened_at or track_name", ErrInvalidListen, i+1)
+		}
+	}
+
+	return listens, nil
+}
+
+// decode decodes a top-level JSON value, either an array of listens, a
+// payload or a single listen.
+func decode(value json.RawMessage) ([]Listen, error) {
+	if strings.HasPrefix(strings.TrimSpace(string(value)), "[") {
+		var listens []Listen
+		if err := json.Unmarshal(value, &listens); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		return listens, nil
+	}
+
+	var payload struct {
+		Payload []Listen `json:"payload"`
+		Listen
+	}
+	if err := json.Unmarshal(value, &payload); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	if payload.Payload != nil {
+		return payload.Payload, nil
+	}
+	return []Listen{payload.Listen}, nil
+}
+
+// Write writes listens as submission payloads of the import listen type, one
+// per line, each holding at most MaxListensPerPayload listens so every line
+// can be submitted as is. No listens are written as a single empty payload.
+func Write(w io.Writer, listens []Listen) error {
+	encoder := json.NewEncoder(w)
+
+	if len(listens) == 0 {
+		if err := encoder.Encode(Payload{ListenType: ListenTypeImport, Payload: []Listen{}}); err != nil {
+			return fmt.Errorf("encoder.Encode: %w", err)
+		}
+		return nil
+	}
+
+	for chunk := range slices.Chunk(listens, MaxListensPerPayload) {
+		if err := encoder.Encode(Payload{ListenType: ListenTypeImport, Payload: chunk}); err != nil {
+			return fmt.Errorf("encoder.Encode: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ToListens maps ListenBrainz listens to listens of username. Listens are
+// taken as played in full when the duration of the track is known, and are
+// otherwise left without play time for spotify.SQLite.EstimatePlayTimes to
+// fill in.
+func ToListens(submitted []Listen, username string) []listen.Listen {
+	listens := make([]listen.Listen, 0, len(submitted))
+	for _, l := range submitted {
+		metadata := l.TrackMetadata
+
+		played := time.Duration(metadata.AdditionalInfo.DurationMS) * time.Millisecond
+		if played == 0 {
+			played = time.Duration(metadata.AdditionalInfo.Duration) * time.Second
+		}
+
+		listens = append(listens, listen.Listen{
+			EndedAt:  time.Unix(l.ListenedAt, 0).UTC().Add(played),
+			Username: username,
+			Platform: "ListenBrainz",
+			Played:   played,
+			Track:    metadata.TrackName,
+			Artist:   metadata.ArtistName,
+			Album:    metadata.ReleaseName,
+			TrackURI: TrackURI(metadata.AdditionalInfo.SpotifyID),
+			Source:   Source,
+		})
+	}
+
+	return listens
+}
+
+// FromListens maps listens to ListenBrainz listens.
+func FromListens(listens []listen.Listen) []Listen {
+	submitted := make([]Listen, 0, len(listens))
+	for _, l := range listens {
+		submission := Listen{
+			ListenedAt: l.StartedAt().Unix(),
+			TrackMetadata: TrackMetadata{
+				ArtistName:  l.Artist,
+				TrackName:   l.Track,
+				ReleaseName: l.Album,
+				AdditionalInfo: AdditionalInfo{
+					SubmissionClient: "decibel",
+				},
+			},
+		}
+
+		if id, ok := strings.CutPrefix(l.TrackURI, spotifyTrackURI); ok {
+			submission.TrackMetadata.AdditionalInfo.SpotifyID = spotifyTrackURL + id
+			submission.TrackMetadata.AdditionalInfo.MusicService = "spotify.com"
+		}
+
+		submitted = append(submitted, submission)
+	}
+
+	return submitted
+}
+
+// TrackURI turns the Spotify track URL used by ListenBrainz, e.g.
+// "https://open.spotify.com/track/6rqhFgbbKwnb9MLmUQDhG6", into a Spotify
+// URI. Anything else gives an empty URI.
+func TrackURI(spotifyID string) string {
+	id, ok := strings.CutPrefix(spotifyID, spotifyTrackURL)
+	if !ok || id == "" {
+		return ""
+	}
+
+	id, _, _ = strings.Cut(id, "?")
+	return spotifyTrackURI + id
+}
@@ -0,0 +1,187 @@
+package listenbrainz_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/internal/listenbrainz"
+)
+
+const listenJSON = `{
+	"listened_at": 1709330400,
+	"recording_msid": "d23f4719-9212-49f0-ad08-ddbfbfc50d6f",
+	"user_name": "alice",
+	"track_metadata": {
+		"artist_name": "Björk",
+		"track_name": "Jóga",
+		"release_name": "Homogenic",
+		"additional_info": {
+			"spotify_id": "https://open.spotify.com/track/1",
+			"duration_ms": 300000,
+			"music_service": "spotify.com"
+		}
+	}
+}`
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	want := []listenbrainz.Listen{{
+		ListenedAt: 1709330400,
+		TrackMetadata: listenbrainz.TrackMetadata{
+			ArtistName:  "Björk",
+			TrackName:   "Jóga",
+			ReleaseName: "Homogenic",
+			AdditionalInfo: listenbrainz.AdditionalInfo{
+				SpotifyID:    "https://open.spotify.com/track/1",
+				MusicService: "spotify.com",
+				DurationMS:   300000,
+			},
+		},
+	}}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []listenbrainz.Listen
+		wantErr error
+	}{
+		{name: "array", input: "[" + listenJSON + "]", want: want},
+		{name: "json lines", input: strings.ReplaceAll(listenJSON, "\n", "") + "\n" + strings.ReplaceAll(listenJSON, "\n", ""), want: append(want, want...)},
+		{name: "payload", input: `{"listen_type": "import", "payload": [` + listenJSON + `]}`, want: want},
+		{name: "empty", input: ""},
+		{name: "missing track", input: `[{"listened_at": 1709330400, "track_metadata": {}}]`, wantErr: listenbrainz.ErrInvalidListen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := listenbrainz.Read(strings.NewReader(tt.input))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestToListens(t *testing.T) {
+	t.Parallel()
+
+	listens, err := listenbrainz.Read(strings.NewReader("[" + listenJSON + `,
+		{"listened_at": 1709340000, "track_metadata": {"artist_name": "Portishead", "track_name": "Roads",
+		 "additional_info": {"duration": 300}}}]`))
+	require.NoError(t, err)
+
+	start := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+	assert.Equal(t, []listen.Listen{
+		{
+			EndedAt:  start.Add(5 * time.Minute),
+			Username: "alice",
+			Platform: "ListenBrainz",
+			Played:   5 * time.Minute,
+			Track:    "Jóga",
+			Artist:   "Björk",
+			Album:    "Homogenic",
+			TrackURI: "spotify:track:1",
+			Source:   listenbrainz.Source,
+		},
+		{
+			EndedAt:  time.Date(2024, 3, 2, 0, 45, 0, 0, time.UTC),
+			Username: "alice",
+			Platform: "ListenBrainz",
+			Played:   5 * time.Minute,
+			Track:    "Roads",
+			Artist:   "Portishead",
+			Source:   listenbrainz.Source,
+		},
+	}, listenbrainz.ToListens(listens, "alice"))
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	listens := listenbrainz.FromListens([]listen.Listen{
+		{
+			EndedAt:  time.Date(2024, 3, 1, 22, 5, 0, 0, time.UTC),
+			TrackURI: "spotify:track:1",
+			Track:    "Jóga",
+			Artist:   "Björk",
+			Album:    "Homogenic",
+			Played:   5 * time.Minute,
+		},
+		{
+			EndedAt: time.Date(2024, 3, 1, 22, 10, 0, 0, time.UTC),
+			Track:   "Roads",
+			Artist:  "Portishead",
+			Played:  time.Minute,
+		},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, listenbrainz.Write(&buf, listens))
+
+	assert.JSONEq(t, `{"listen_type": "import", "payload": [
+		{"listened_at": 1709330400, "track_metadata": {"artist_name": "Björk", "track_name": "Jóga", "release_name": "Homogenic",
+		 "additional_info": {"spotify_id": "https://open.spotify.com/track/1", "music_service": "spotify.com", "submission_client": "decibel"}}},
+		{"listened_at": 1709330940, "track_metadata": {"artist_name": "Portishead", "track_name": "Roads",
+		 "additional_info": {"submission_client": "decibel"}}}
+	]}`, buf.String())
+
+	// The written payload reads back as the same listens.
+	got, err := listenbrainz.Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, listens, got)
+
+	buf.Reset()
+	require.NoError(t, listenbrainz.Write(&buf, nil))
+	assert.JSONEq(t, `{"listen_type": "import", "payload": []}`, buf.String())
+}
+
+func TestWrite_Chunks(t *testing.T) {
+	t.Parallel()
+
+	listens := make([]listenbrainz.Listen, 2*listenbrainz.MaxListensPerPayload+1)
+	for i := range listens {
+		listens[i] = listenbrainz.Listen{
+			ListenedAt:    int64(1709330400 + i),
+			TrackMetadata: listenbrainz.TrackMetadata{ArtistName: "Björk", TrackName: "Jóga"},
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, listenbrainz.Write(&buf, listens))
+
+	// Every line is a payload the API accepts.
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	for i, want := range []int{listenbrainz.MaxListensPerPayload, listenbrainz.MaxListensPerPayload, 1} {
+		var payload listenbrainz.Payload
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &payload))
+		assert.Equal(t, listenbrainz.ListenTypeImport, payload.ListenType)
+		assert.Len(t, payload.Payload, want)
+	}
+
+	got, err := listenbrainz.Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, listens, got)
+}
+
+func TestTrackURI(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "spotify:track:6rqhFgbbKwnb9MLmUQDhG6", listenbrainz.TrackURI("https://open.spotify.com/track/6rqhFgbbKwnb9MLmUQDhG6?si=1"))
+	assert.Empty(t, listenbrainz.TrackURI("https://open.spotify.com/album/1"))
+	assert.Empty(t, listenbrainz.TrackURI(""))
+}
@@ -0,0 +1,69 @@
+package query
+
+import (
+	"slices"
+	"strings"
+)
+
+// Complete returns the table and column names completing the word at the
+// end of line, and the offset in line where that word starts. A word
+// qualified with a table name, such as "spotify_streams.ms", only completes
+// to the columns of that table.
+func Complete(tables []Table, line string) (int, []string) {
+	start := strings.LastIndexFunc(line, func(r rune) bool { return !isWordRune(r) }) + 1
+	word := line[start:]
+
+	var candidates []string
+	if table, column, ok := strings.Cut(word, "."); ok {
+		for _, t := range tables {
+			if !strings.EqualFold(t.Name, table) {
+				continue
+			}
+			for _, c := range t.Columns {
+				if hasPrefixFold(c, column) {
+					candidates = append(candidates, table+"."+c)
+				}
+			}
+		}
+	} else {
+		for _, t := range tables {
+			if hasPrefixFold(t.Name, word) {
+				candidates = append(candidates, t.Name)
+			}
+			for _, c := range t.Columns {
+				if hasPrefixFold(c, word) {
+					candidates = append(candidates, c)
+				}
+			}
+		}
+	}
+
+	slices.Sort(candidates)
+
+	return start, slices.Compact(candidates)
+}
+
+// CommonPrefix returns the longest prefix shared by all values.
+func CommonPrefix(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	prefix := values[0]
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '.' ||
+		(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
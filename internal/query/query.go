@@ -0,0 +1,284 @@
+// Package query runs ad-hoc read-only SQL against a decibel database.
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+	"github.com/vingarcia/ksql/sqldialect"
+
+	"github.com/cadoween/decibel/pkg/tabular"
+)
+
+var ErrNotReadOnly = errors.New("only SELECT, WITH, VALUES and EXPLAIN statements are allowed")
+
+// readOnlyKeywords are the keywords a statement may start with.
+var readOnlyKeywords = []string{"SELECT", "WITH", "VALUES", "EXPLAIN"}
+
+// uriEscaper escapes the characters with a meaning in SQLite URI filenames.
+var uriEscaper = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+
+// DB is a read-only connection to a decibel database. The embedded ksql.DB
+// and Run share the same connection, which SQLite opens read-only and with
+// query_only set, so statements can't change the database whatever they
+// start with.
+type DB struct {
+	ksql.DB
+
+	adapter ksql.DBAdapter
+}
+
+// Table is a table or view with its columns, in declaration order.
+type Table struct {
+	Name    string
+	Columns []string
+}
+
+// Open opens the SQLite database at path read-only. The database must exist.
+func Open(_ context.Context, path string) (DB, error) {
+	dsn := "file:" + uriEscaper.Replace(path) + "?mode=ro&_pragma=query_only(1)"
+
+	sqlDB, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return DB{}, fmt.Errorf("sql.Open: %w", err)
+	}
+
+	if err := sqlDB.Ping(); err != nil {
+		_ = sqlDB.Close()
+		return DB{}, fmt.Errorf("sqlDB.Ping: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	adapter := ksqlite.NewSQLAdapter(sqlDB)
+
+	db, err := ksql.NewWithAdapter(adapter, sqldialect.Sqlite3Dialect{})
+	if err != nil {
+		_ = sqlDB.Close()
+		return DB{}, fmt.Errorf("ksql.NewWithAdapter: %w", err)
+	}
+
+	return DB{DB: db, adapter: adapter}, nil
+}
+
+// Column is a column of the rows of a statement, with the type it is
+// declared with in the schema, empty for expressions.
+type Column struct {
+	Name string
+	Type string
+}
+
+// Rows reads the rows of a statement one at a time, so results larger than
+// memory can be processed. Rows must be closed.
+type Rows struct {
+	Columns []Column
+
+	rows   ksql.Rows
+	values []any
+	dest   []any
+	err    error
+}
+
+// Run runs a single read-only statement with the arguments of its
+// placeholders and returns all of its rows.
+func (db DB) Run(ctx context.Context, statement string, args ...any) (tabular.Table, error) {
+	rows, err := db.Rows(ctx, statement, args...)
+	if err != nil {
+		return tabular.Table{}, fmt.Errorf("db.Rows: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	table := tabular.Table{Columns: make([]string, 0, len(rows.Columns))}
+	for _, column := range rows.Columns {
+		table.Columns = append(table.Columns, column.Name)
+	}
+
+	for rows.Next() {
+		table.Rows = append(table.Rows, slices.Clone(rows.Values()))
+	}
+
+	if err := rows.Err(); err != nil {
+		return tabular.Table{}, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return table, nil
+}
+
+// Rows runs a single read-only statement with the arguments of its
+// placeholders and returns its rows, read as they are iterated.
+func (db DB) Rows(ctx context.Context, statement string, args ...any) (*Rows, error) {
+	if !IsReadOnly(statement) {
+		return nil, ErrNotReadOnly
+	}
+
+	rows, err := db.adapter.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.adapter.QueryContext: %w", err)
+	}
+
+	columns, err := columnsOf(rows)
+	if err != nil {
+		_ = rows.Close()
+		return nil, fmt.Errorf("columnsOf: %w", err)
+	}
+
+	r := &Rows{
+		Columns: columns,
+		rows:    rows,
+		values:  make([]any, len(columns)),
+		dest:    make([]any, len(columns)),
+	}
+	for i := range r.values {
+		r.dest[i] = &r.values[i]
+	}
+
+	return r, nil
+}
+
+// columnsOf returns the columns of rows, with their declared types when the
+// rows are database/sql ones as the SQLite adapter returns.
+func columnsOf(rows ksql.Rows) ([]Column, error) {
+	typed, ok := rows.(interface {
+		ColumnTypes() ([]*sql.ColumnType, error)
+	})
+	if !ok {
+		names, err := rows.Columns()
+		if err != nil {
+			return nil, fmt.Errorf("rows.Columns: %w", err)
+		}
+
+		columns := make([]Column, 0, len(names))
+		for _, name := range names {
+			columns = append(columns, Column{Name: name})
+		}
+		return columns, nil
+	}
+
+	types, err := typed.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("rows.ColumnTypes: %w", err)
+	}
+
+	columns := make([]Column, 0, len(types))
+	for _, columnType := range types {
+		columns = append(columns, Column{Name: columnType.Name(), Type: columnType.DatabaseTypeName()})
+	}
+	return columns, nil
+}
+
+// Next reads the next row, and reports whether there was one.
+func (r *Rows) Next() bool {
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+
+	if err := r.rows.Scan(r.dest...); err != nil {
+		r.err = fmt.Errorf("rows.Scan: %w", err)
+		return false
+	}
+
+	return true
+}
+
+// Values returns the values of the row read by Next, the ones database/sql
+// scans into an any. The slice is reused by the next call to Next.
+func (r *Rows) Values() []any {
+	return r.values
+}
+
+// Err returns the error that stopped Next, if any.
+func (r *Rows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	if err := r.rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Rows) Close() error {
+	if err := r.rows.Close(); err != nil {
+		return fmt.Errorf("rows.Close: %w", err)
+	}
+
+	return nil
+}
+
+// GetSchema returns the tables and views of the database behind provider,
+// without SQLite's internal tables.
+func GetSchema(ctx context.Context, provider ksql.Provider) ([]Table, error) {
+	var columns []struct {
+		TableName  string `ksql:"table_name"`
+		ColumnName string `ksql:"column_name"`
+	}
+
+	query := `
+		SELECT m.name AS table_name, p.name AS column_name
+		FROM sqlite_master m
+		JOIN pragma_table_info(m.name) p
+		WHERE m.type IN ('table', 'view') AND m.name NOT LIKE 'sqlite_%'
+		ORDER BY m.name, p.cid
+	`
+
+	if err := provider.Query(ctx, &columns, query); err != nil {
+		return nil, fmt.Errorf("provider.Query: %w", err)
+	}
+
+	var tables []Table
+	for _, column := range columns {
+		if len(tables) == 0 || tables[len(tables)-1].Name != column.TableName {
+			tables = append(tables, Table{Name: column.TableName})
+		}
+
+		last := &tables[len(tables)-1]
+		last.Columns = append(last.Columns, column.ColumnName)
+	}
+
+	return tables, nil
+}
+
+// IsReadOnly reports whether statement starts with a keyword of a statement
+// reading data. It is a first line of defense giving a readable error, the
+// connection itself refusing writes.
+func IsReadOnly(statement string) bool {
+	return slices.Contains(readOnlyKeywords, strings.ToUpper(firstKeyword(statement)))
+}
+
+// firstKeyword returns the first word of statement, skipping whitespace,
+// comments and opening parentheses.
+func firstKeyword(statement string) string {
+	s := statement
+	for {
+		s = strings.TrimLeft(s, " \t\r\n(")
+		switch {
+		case strings.HasPrefix(s, "--"):
+			end := strings.IndexByte(s, '\n')
+			if end < 0 {
+				return ""
+			}
+			s = s[end+1:]
+		case strings.HasPrefix(s, "/*"):
+			end := strings.Index(s, "*/")
+			if end < 0 {
+				return ""
+			}
+			s = s[end+2:]
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return (r < 'a' || r > 'z') && (r < 'A' || r > 'Z')
+			})
+			if end < 0 {
+				return s
+			}
+			return s[:end]
+		}
+	}
+}
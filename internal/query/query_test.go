@@ -0,0 +1,208 @@
+package query_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cadoween/decibel/internal/query"
+	"github.com/cadoween/decibel/internal/spotify/ksqltest"
+)
+
+// newDatabase creates a database file with a few streams.
+func newDatabase(t *testing.T) string {
+	t.Helper()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "decibel.db")
+
+	db, err := ksqlite.New(ctx, path, ksql.Config{})
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, statement := range []string{
+		`CREATE TABLE spotify_streams (id INTEGER PRIMARY KEY, username TEXT, ms_played INTEGER)`,
+		`INSERT INTO spotify_streams (username, ms_played) VALUES ('alice', 1000), ('bob', 2000)`,
+	} {
+		_, err := db.Exec(ctx, statement)
+		require.NoError(t, err)
+	}
+
+	return path
+}
+
+func TestDB_Run(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := query.Open(ctx, newDatabase(t))
+	require.NoError(t, err)
+	defer db.Close()
+
+	table, err := db.Run(ctx, "SELECT username, ms_played FROM spotify_streams ORDER BY id")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"username", "ms_played"}, table.Columns)
+	assert.Equal(t, [][]any{{"alice", int64(1000)}, {"bob", int64(2000)}}, table.Rows)
+
+	_, err = db.Run(ctx, "DELETE FROM spotify_streams")
+	require.ErrorIs(t, err, query.ErrNotReadOnly)
+
+	// Statements getting past the keyword check are refused by the
+	// connection itself.
+	_, err = db.Run(ctx, "WITH x AS (SELECT 1) DELETE FROM spotify_streams")
+	require.Error(t, err)
+
+	table, err = db.Run(ctx, "SELECT COUNT(*) AS n FROM spotify_streams")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{int64(2)}}, table.Rows)
+
+	table, err = db.Run(ctx, "SELECT username FROM spotify_streams WHERE ms_played > 5000")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"username"}, table.Columns)
+	assert.Empty(t, table.Rows)
+}
+
+func TestDB_Rows(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := query.Open(ctx, newDatabase(t))
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Rows(ctx, "SELECT username, ms_played * 2 AS doubled FROM spotify_streams ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	assert.Equal(t, []query.Column{{Name: "username", Type: "TEXT"}, {Name: "doubled"}}, rows.Columns)
+
+	var got [][]any
+	for rows.Next() {
+		got = append(got, slices.Clone(rows.Values()))
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, [][]any{{"alice", int64(2000)}, {"bob", int64(4000)}}, got)
+
+	_, err = db.Rows(ctx, "DELETE FROM spotify_streams")
+	require.ErrorIs(t, err, query.ErrNotReadOnly)
+}
+
+func TestOpen_MissingDatabase(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "missing.db")
+
+	_, err := query.Open(context.Background(), path)
+	require.Error(t, err)
+	assert.NoFileExists(t, path)
+}
+
+func TestGetSchema(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockProvider := ksqltest.NewMockProvider(ctrl)
+
+	// column has the shape of the rows GetSchema scans.
+	type column = struct {
+		TableName  string `ksql:"table_name"`
+		ColumnName string `ksql:"column_name"`
+	}
+
+	mockProvider.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Regex(`pragma_table_info`)).
+		DoAndReturn(func(_ context.Context, records any, _ string, _ ...any) error {
+			*(records.(*[]column)) = []column{
+				{"profiles", "name"},
+				{"profiles", "username"},
+				{"spotify_streams", "ts"},
+			}
+			return nil
+		})
+
+	tables, err := query.GetSchema(context.Background(), mockProvider)
+	require.NoError(t, err)
+	assert.Equal(t, []query.Table{
+		{Name: "profiles", Columns: []string{"name", "username"}},
+		{Name: "spotify_streams", Columns: []string{"ts"}},
+	}, tables)
+}
+
+func TestIsReadOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		statement string
+		want      bool
+	}{
+		{statement: "SELECT 1", want: true},
+		{statement: "  select * from profiles", want: true},
+		{statement: "WITH x AS (SELECT 1) SELECT * FROM x", want: true},
+		{statement: "(SELECT 1) UNION (SELECT 2)", want: true},
+		{statement: "-- top artists\nSELECT 1", want: true},
+		{statement: "/* comment */ EXPLAIN QUERY PLAN SELECT 1", want: true},
+		{statement: "VALUES (1)", want: true},
+		{statement: "DELETE FROM profiles", want: false},
+		{statement: "PRAGMA user_version = 0", want: false},
+		{statement: "ATTACH 'other.db' AS other", want: false},
+		{statement: "SELECTED", want: false},
+		{statement: "-- only a comment", want: false},
+		{statement: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.statement, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, query.IsReadOnly(tt.statement))
+		})
+	}
+}
+
+func TestComplete(t *testing.T) {
+	t.Parallel()
+
+	tables := []query.Table{
+		{Name: "profiles", Columns: []string{"name", "username", "created_at"}},
+		{Name: "spotify_streams", Columns: []string{"ts", "username", "spotify_track_uri"}},
+	}
+
+	tests := []struct {
+		name      string
+		line      string
+		wantStart int
+		want      []string
+	}{
+		{name: "table", line: "SELECT * FROM pro", wantStart: 14, want: []string{"profiles"}},
+		{name: "tables and columns", line: "SELECT spo", wantStart: 7, want: []string{"spotify_streams", "spotify_track_uri"}},
+		{name: "case insensitive", line: "select USER", wantStart: 7, want: []string{"username"}},
+		{name: "qualified column", line: "SELECT profiles.", wantStart: 7, want: []string{"profiles.created_at", "profiles.name", "profiles.username"}},
+		{name: "qualified prefix", line: "SELECT spotify_streams.t", wantStart: 7, want: []string{"spotify_streams.ts"}},
+		{name: "unknown table", line: "SELECT x.", wantStart: 7, want: nil},
+		{name: "no match", line: "SELECT zz", wantStart: 7, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			start, candidates := query.Complete(tables, tt.line)
+			assert.Equal(t, tt.wantStart, start)
+			assert.Equal(t, tt.want, candidates)
+		})
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "spotify_", query.CommonPrefix([]string{"spotify_streams", "spotify_track_uri"}))
+	assert.Equal(t, "name", query.CommonPrefix([]string{"name"}))
+	assert.Empty(t, query.CommonPrefix(nil))
+}
@@ -0,0 +1,95 @@
+// Package report renders a summary of the listening history as a single,
+// self-contained HTML file with inline CSS and SVG charts.
+package report
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+var tmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"playTime":      formatPlayTime,
+	"percent":       func(rate float64) string { return fmt.Sprintf("%.1f%%", rate*100) },
+	"timelineSVG":   timelineSVG,
+	"heatmapSVG":    heatmapSVG,
+	"artistBarSVG":  artistBarSVG,
+	"countryBarSVG": countryBarSVG,
+	"inc":           func(i int) int { return i + 1 },
+}).Parse(reportTemplate))
+
+// Report holds everything shown in the HTML report.
+type Report struct {
+	GeneratedAt time.Time
+	// Scope describes the streams the report covers, e.g. the users and
+	// period it was filtered on.
+	Scope       string
+	Interval    spotify.Interval
+	Totals      spotify.Totals
+	TopArtists  []spotify.ArtistStats
+	TopTracks   []spotify.TrackStats
+	TopAlbums   []spotify.AlbumStats
+	MostSkipped []spotify.TrackSkipStats
+	Countries   []spotify.CountryStats
+	Timeline    []spotify.PeriodStats
+	Heatmap     []spotify.HourStats
+}
+
+// Collect queries the statistics of the report.
+func Collect(ctx context.Context, spotifySQLite *spotify.SQLite, interval spotify.Interval, filter spotify.Filter) (Report, error) {
+	report := Report{
+		GeneratedAt: time.Now(),
+		Interval:    interval,
+	}
+
+	var err error
+	if report.Totals, err = spotifySQLite.GetTotals(ctx, filter); err != nil {
+		return Report{}, fmt.Errorf("spotifySQLite.GetTotals: %w", err)
+	}
+	if report.TopArtists, err = spotifySQLite.GetTopArtistsByPlayTime(ctx, filter); err != nil {
+		return Report{}, fmt.Errorf("spotifySQLite.GetTopArtistsByPlayTime: %w", err)
+	}
+	if report.TopTracks, err = spotifySQLite.GetTopTracksByPlayTime(ctx, filter); err != nil {
+		return Report{}, fmt.Errorf("spotifySQLite.GetTopTracksByPlayTime: %w", err)
+	}
+	if report.TopAlbums, err = spotifySQLite.GetTopAlbumsByPlayCount(ctx, filter); err != nil {
+		return Report{}, fmt.Errorf("spotifySQLite.GetTopAlbumsByPlayCount: %w", err)
+	}
+	if report.MostSkipped, err = spotifySQLite.GetMostSkippedTracks(ctx, filter); err != nil {
+		return Report{}, fmt.Errorf("spotifySQLite.GetMostSkippedTracks: %w", err)
+	}
+	if report.Countries, err = spotifySQLite.GetPlayTimeByCountry(ctx, filter); err != nil {
+		return Report{}, fmt.Errorf("spotifySQLite.GetPlayTimeByCountry: %w", err)
+	}
+	if report.Timeline, err = spotifySQLite.GetPlayTimeByPeriod(ctx, interval, filter); err != nil {
+		return Report{}, fmt.Errorf("spotifySQLite.GetPlayTimeByPeriod: %w", err)
+	}
+	if report.Heatmap, err = spotifySQLite.GetPlayTimeByHour(ctx, filter); err != nil {
+		return Report{}, fmt.Errorf("spotifySQLite.GetPlayTimeByHour: %w", err)
+	}
+
+	return report, nil
+}
+
+// Render writes the report as HTML to w.
+func (r Report) Render(w io.Writer) error {
+	if err := tmpl.Execute(w, r); err != nil {
+		return fmt.Errorf("tmpl.Execute: %w", err)
+	}
+
+	return nil
+}
+
+// formatPlayTime formats a play time in milliseconds as hours and minutes.
+func formatPlayTime(ms int64) string {
+	duration := time.Duration(ms) * time.Millisecond
+	return fmt.Sprintf("%dh %dm", int(duration.Hours()), int(duration.Minutes())%60)
+}
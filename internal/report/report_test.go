@@ -0,0 +1,66 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/report"
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestReport_Render(t *testing.T) {
+	t.Parallel()
+
+	r := report.Report{
+		GeneratedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Scope:       "alice, 2024",
+		Interval:    spotify.IntervalMonth,
+		Totals:      spotify.Totals{PlayCount: 42, TotalPlayTimeMS: 2 * 3600000, ArtistCount: 2, TrackCount: 3},
+		TopArtists: []spotify.ArtistStats{
+			{Artist: "<script>alert(1)</script>", PlayCount: 30, TotalPlayTime: 3600000},
+			{Artist: "Artist & Co", PlayCount: 12, TotalPlayTime: 1800000},
+		},
+		TopTracks:   []spotify.TrackStats{{Track: "Track1", Artist: "Artist & Co", PlayCount: 5, TotalPlayTimeMS: 900000}},
+		MostSkipped: []spotify.TrackSkipStats{{TrackName: "Track2", ArtistName: "Artist2", SkipCount: 3, SkipRate: 0.25}},
+		Timeline: []spotify.PeriodStats{
+			{Period: "2024-03", PlayCount: 20, TotalPlayTimeMS: 3600000},
+			{Period: "2024-04", PlayCount: 22, TotalPlayTimeMS: 3600000},
+		},
+		Heatmap: []spotify.HourStats{{Weekday: 1, Hour: 20, PlayCount: 42, TotalPlayTimeMS: 2 * 3600000}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Render(&buf))
+	html := buf.String()
+
+	assert.Contains(t, html, "alice, 2024 · Generated on May 1, 2024 at 10:00")
+	assert.Contains(t, html, "<strong>2h 0m</strong>")
+	assert.Contains(t, html, "Listening per month")
+	assert.Contains(t, html, "25.0%")
+	assert.Contains(t, html, "2024-04")
+	assert.Contains(t, html, "Mon 20:00: 2h 0m")
+	// Timeline, heatmap and top artists, countries are empty.
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("<svg")))
+
+	// Every value coming from the database is escaped.
+	assert.NotContains(t, html, "<script>")
+	assert.Contains(t, html, "&lt;script&gt;")
+	assert.Contains(t, html, "Artist &amp; Co")
+
+	// The report doesn't load anything from outside the file.
+	assert.NotContains(t, html, "src=")
+	assert.NotContains(t, html, "<link")
+}
+
+func TestReport_RenderEmpty(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Report{Interval: spotify.IntervalYear}.Render(&buf))
+
+	assert.Contains(t, buf.String(), "No streams.")
+}
@@ -0,0 +1,174 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+// bar is a labelled value of a bar chart.
+type bar struct {
+	label string
+	value int64
+}
+
+func artistBarSVG(artists []spotify.ArtistStats) template.HTML {
+	bars := make([]bar, 0, len(artists))
+	for _, artist := range artists {
+		bars = append(bars, bar{label: artist.Artist, value: artist.TotalPlayTime})
+	}
+	return barSVG(bars)
+}
+
+func countryBarSVG(countries []spotify.CountryStats) template.HTML {
+	bars := make([]bar, 0, len(countries))
+	for _, country := range countries {
+		bars = append(bars, bar{label: country.Country, value: country.TotalPlayTimeMS})
+	}
+	return barSVG(bars)
+}
+
+// barSVG draws horizontal bars of play times, one per row.
+func barSVG(bars []bar) template.HTML {
+	const (
+		width      = 600
+		rowHeight  = 24
+		labelWidth = 200
+		valueWidth = 80
+	)
+
+	maxValue := int64(1)
+	for _, b := range bars {
+		maxValue = max(maxValue, b.value)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg viewBox="0 0 %d %d" role="img">`, width, len(bars)*rowHeight)
+	for i, b := range bars {
+		y := i * rowHeight
+		w := max(1, float64(b.value)/float64(maxValue)*(width-labelWidth-valueWidth))
+		fmt.Fprintf(&sb, `<text class="label" x="0" y="%d">%s</text>`, y+16, escape(truncate(b.label, 30)))
+		fmt.Fprintf(&sb, `<rect class="bar" x="%d" y="%d" width="%.1f" height="%d" rx="2"><title>%s: %s</title></rect>`,
+			labelWidth, y+4, w, rowHeight-8, escape(b.label), formatPlayTime(b.value))
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%d">%s</text>`, labelWidth+w+6, y+16, formatPlayTime(b.value))
+	}
+	sb.WriteString(`</svg>`)
+
+	return template.HTML(sb.String()) //nolint:gosec // Labels are escaped above.
+}
+
+// timelineSVG draws play time per period as an area chart.
+func timelineSVG(periods []spotify.PeriodStats) template.HTML {
+	const (
+		width      = 1000
+		height     = 260
+		left       = 50
+		top        = 10
+		bottom     = 24
+		plotWidth  = width - left
+		plotHeight = height - top - bottom
+	)
+
+	if len(periods) == 0 {
+		return ""
+	}
+
+	maxValue := int64(1)
+	for _, period := range periods {
+		maxValue = max(maxValue, period.TotalPlayTimeMS)
+	}
+
+	step := 0.0
+	if len(periods) > 1 {
+		step = float64(plotWidth) / float64(len(periods)-1)
+	}
+	x := func(i int) float64 { return left + float64(i)*step }
+	y := func(ms float64) float64 { return top + plotHeight - ms/float64(maxValue)*plotHeight }
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg viewBox="0 0 %d %d" role="img">`, width, height)
+
+	for _, fraction := range []float64{0, 0.5, 1} {
+		gy := y(float64(maxValue) * fraction)
+		hours := int((time.Duration(float64(maxValue)*fraction) * time.Millisecond).Hours())
+		fmt.Fprintf(&sb, `<line class="grid" x1="%d" x2="%d" y1="%.1f" y2="%.1f"/>`, left, width, gy, gy)
+		fmt.Fprintf(&sb, `<text x="0" y="%.1f">%dh</text>`, gy+4, hours)
+	}
+
+	points := []string{fmt.Sprintf("%.1f,%.1f", x(0), y(0))}
+	for i, period := range periods {
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(float64(period.TotalPlayTimeMS))))
+	}
+	points = append(points, fmt.Sprintf("%.1f,%.1f", x(len(periods)-1), y(0)))
+	fmt.Fprintf(&sb, `<polygon class="area" points="%s"/>`, strings.Join(points, " "))
+
+	labelEvery := (len(periods) + 11) / 12
+	for i, period := range periods {
+		fmt.Fprintf(&sb, `<circle cx="%.1f" cy="%.1f" r="8" fill-opacity="0"><title>%s: %s, %d plays</title></circle>`,
+			x(i), y(float64(period.TotalPlayTimeMS)), escape(period.Period), formatPlayTime(period.TotalPlayTimeMS), period.PlayCount)
+		if i%labelEvery == 0 {
+			fmt.Fprintf(&sb, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, x(i), height-6, escape(period.Period))
+		}
+	}
+	sb.WriteString(`</svg>`)
+
+	return template.HTML(sb.String()) //nolint:gosec // Labels are escaped above.
+}
+
+// heatmapSVG draws play time per hour of each day of the week, weeks
+// starting on Monday.
+func heatmapSVG(cells []spotify.HourStats) template.HTML {
+	const (
+		cell = 36
+		left = 40
+		top  = 18
+	)
+
+	var grid [7][24]int64
+	maxValue := int64(1)
+	for _, c := range cells {
+		if c.Weekday < 0 || c.Weekday > 6 || c.Hour < 0 || c.Hour > 23 {
+			continue
+		}
+		grid[c.Weekday][c.Hour] = c.TotalPlayTimeMS
+		maxValue = max(maxValue, c.TotalPlayTimeMS)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg viewBox="0 0 %d %d" role="img">`, left+24*cell, top+7*cell)
+
+	for hour := 0; hour < 24; hour += 3 {
+		fmt.Fprintf(&sb, `<text x="%d" y="12" text-anchor="middle">%dh</text>`, left+hour*cell+cell/2, hour)
+	}
+
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+	for row, weekday := range weekdays {
+		name := weekday.String()[:3]
+		fmt.Fprintf(&sb, `<text x="0" y="%d">%s</text>`, top+row*cell+cell/2+4, name)
+		for hour := range 24 {
+			ms := grid[weekday][hour]
+			fmt.Fprintf(&sb, `<rect class="cell" x="%d" y="%d" width="%d" height="%d" rx="3" fill-opacity="%.2f"><title>%s %d:00: %s</title></rect>`,
+				left+hour*cell+1, top+row*cell+1, cell-2, cell-2,
+				0.08+0.92*float64(ms)/float64(maxValue), name, hour, formatPlayTime(ms))
+		}
+	}
+	sb.WriteString(`</svg>`)
+
+	return template.HTML(sb.String()) //nolint:gosec // Labels are escaped above.
+}
+
+func escape(s string) string {
+	return template.HTMLEscapeString(s)
+}
+
+// truncate cuts s to maxLen runes, ending it with an ellipsis.
+func truncate(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen-1]) + "…"
+}
@@ -0,0 +1,47 @@
+package scrobblerlog
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/cadoween/decibel/internal/listen"
+)
+
+func init() {
+	listen.Register(Importer{})
+}
+
+// Importer reads .scrobbler.log files as listens.
+type Importer struct{}
+
+func (Importer) Format() listen.Format {
+	return listen.Format{
+		Name:             Source,
+		Source:           Source,
+		Description:      ".scrobbler.log of Rockbox and other portable players",
+		Scrobbles:        true,
+		MissingPlayTimes: true,
+	}
+}
+
+// Detect recognizes files starting with the Audioscrobbler version header.
+func (Importer) Detect(_ string, head []byte) bool {
+	return bytes.HasPrefix(bytes.TrimPrefix(head, []byte("\ufeff")), []byte("#AUDIOSCROBBLER/"))
+}
+
+func (Importer) Open(_ context.Context, path string, opts listen.Options) (iter.Seq2[listen.Listen, error], error) {
+	if opts.Username == "" {
+		return nil, listen.ErrUsernameRequired
+	}
+
+	log, err := ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ReadFile: %w", err)
+	}
+
+	return listen.All(ToListens(log, opts.Username, cmp.Or(opts.Location, time.Local))), nil
+}
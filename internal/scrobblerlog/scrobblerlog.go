@@ -0,0 +1,254 @@
+// Package scrobblerlog reads and writes .scrobbler.log files, the
+// tab-separated Audioscrobbler format portable players such as Rockbox log
+// plays in while offline.
+package scrobblerlog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+// Source is the source recorded for streams and imports of .scrobbler.log
+// files.
+const Source = "scrobblerlog"
+
+const (
+	// FileName is the name portable players give the log.
+	FileName = ".scrobbler.log"
+
+	// RatingListened marks a track played for at least half its length,
+	// RatingSkipped one skipped before.
+	RatingListened = "L"
+	RatingSkipped  = "S"
+
+	version      = "#AUDIOSCROBBLER/1.1"
+	tzPrefix     = "#TZ/"
+	tzUTC        = "UTC"
+	clientPrefix = "#CLIENT/"
+	client       = "decibel"
+	minFields    = 7
+)
+
+var ErrInvalidEntry = errors.New("invalid entry")
+
+// Log is a parsed .scrobbler.log.
+type Log struct {
+	// Client is the player that wrote the log, e.g. "Rockbox sansae200".
+	Client string
+	// UTC tells whether the timestamps of the entries are in UTC. Players
+	// without a clock time zone write the local time as if it were UTC.
+	UTC     bool
+	Entries []Entry
+}
+
+// Entry is a line of the log. Timestamp is when the track started playing,
+// in Unix seconds.
+type Entry struct {
+	Artist      string
+	Album       string
+	Track       string
+	TrackNumber string
+	Duration    int
+	Rating      string
+	Timestamp   int64
+	MBID        string
+}
+
+// Skipped reports whether the track was skipped before half its length.
+func (e Entry) Skipped() bool {
+	return e.Rating == RatingSkipped
+}
+
+// ReadFile reads the log at path.
+func ReadFile(path string) (Log, error) {
+	f, err := os.Open(path) //nolint:gosec // The path is given by the user on purpose.
+	if err != nil {
+		return Log{}, fmt.Errorf("os.Open: %w", err)
+	}
+	defer iox.Close(f)
+
+	return Read(f)
+}
+
+// Read reads a log. Header lines start with a "#", empty lines are ignored.
+func Read(r io.Reader) (Log, error) {
+	var log Log
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "#") {
+			if tz, ok := strings.CutPrefix(text, tzPrefix); ok {
+				log.UTC = tz == tzUTC
+			}
+			if name, ok := strings.CutPrefix(text, clientPrefix); ok {
+				log.Client = name
+			}
+			continue
+		}
+
+		entry, err := parseEntry(text)
+		if err != nil {
+			return Log{}, fmt.Errorf("line %d: %w", line, err)
+		}
+		log.Entries = append(log.Entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Log{}, fmt.Errorf("scanner.Err: %w", err)
+	}
+
+	return log, nil
+}
+
+func parseEntry(line string) (Entry, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) < minFields {
+		return Entry{}, fmt.Errorf("%w: got %d fields, want at least %d", ErrInvalidEntry, len(fields), minFields)
+	}
+
+	entry := Entry{
+		Artist:      fields[0],
+		Album:       fields[1],
+		Track:       fields[2],
+		TrackNumber: fields[3],
+		Rating:      fields[5],
+	}
+	if len(fields) > minFields {
+		entry.MBID = fields[7]
+	}
+
+	if entry.Track == "" {
+		return Entry{}, fmt.Errorf("%w: missing track name", ErrInvalidEntry)
+	}
+
+	if fields[4] != "" {
+		duration, err := strconv.Atoi(fields[4])
+		if err != nil {
+			return Entry{}, fmt.Errorf("%w: duration %q", ErrInvalidEntry, fields[4])
+		}
+		entry.Duration = duration
+	}
+
+	timestamp, err := strconv.ParseInt(fields[6], 10, 64)
+	if err != nil || timestamp <= 0 {
+		return Entry{}, fmt.Errorf("%w: timestamp %q", ErrInvalidEntry, fields[6])
+	}
+	entry.Timestamp = timestamp
+
+	return entry, nil
+}
+
+// Write writes entries as a log with UTC timestamps.
+func Write(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "%s\n%s%s\n%s%s\n", version, tzPrefix, tzUTC, clientPrefix, client)
+	for _, entry := range entries {
+		fields := []string{
+			entry.Artist,
+			entry.Album,
+			entry.Track,
+			entry.TrackNumber,
+			strconv.Itoa(entry.Duration),
+			entry.Rating,
+			strconv.FormatInt(entry.Timestamp, 10),
+			entry.MBID,
+		}
+		for i, field := range fields {
+			fields[i] = sanitize(field)
+		}
+
+		fmt.Fprintln(bw, strings.Join(fields, "\t"))
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("bw.Flush: %w", err)
+	}
+
+	return nil
+}
+
+// sanitize replaces the tabs and line breaks the format can't escape.
+func sanitize(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
+
+// ToListens maps the entries of log to listens of username. Timestamps
+// without a time zone are read as local time of loc. Listened entries are
+// taken as played in full when their duration is known, and are otherwise
+// left without play time for spotify.SQLite.EstimatePlayTimes to fill in.
+// Skipped entries are kept as skipped listens without play time, the log
+// doesn't record when they were skipped.
+func ToListens(log Log, username string, loc *time.Location) []listen.Listen {
+	platform := "Portable player"
+	if log.Client != "" {
+		platform = log.Client
+	}
+
+	listens := make([]listen.Listen, 0, len(log.Entries))
+	for _, entry := range log.Entries {
+		start := time.Unix(entry.Timestamp, 0).UTC()
+		if !log.UTC {
+			start = time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc).UTC()
+		}
+
+		var played time.Duration
+		if !entry.Skipped() {
+			played = time.Duration(entry.Duration) * time.Second
+		}
+
+		listens = append(listens, listen.Listen{
+			EndedAt:  start.Add(played),
+			Username: username,
+			Platform: platform,
+			Played:   played,
+			Track:    entry.Track,
+			Artist:   entry.Artist,
+			Album:    entry.Album,
+			Skipped:  entry.Skipped(),
+			Source:   Source,
+		})
+	}
+
+	return listens
+}
+
+// FromListens maps listens to entries, with their play time as duration.
+func FromListens(listens []listen.Listen) []Entry {
+	entries := make([]Entry, 0, len(listens))
+	for _, l := range listens {
+		rating := RatingListened
+		if l.Skipped {
+			rating = RatingSkipped
+		}
+
+		entries = append(entries, Entry{
+			Artist:    l.Artist,
+			Album:     l.Album,
+			Track:     l.Track,
+			Duration:  int(l.Played / time.Second),
+			Rating:    rating,
+			Timestamp: l.StartedAt().Unix(),
+		})
+	}
+
+	return entries
+}
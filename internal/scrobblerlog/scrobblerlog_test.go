@@ -0,0 +1,131 @@
+package scrobblerlog_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/internal/scrobblerlog"
+)
+
+const log = "#AUDIOSCROBBLER/1.1\r\n" +
+	"#TZ/UNKNOWN\r\n" +
+	"#CLIENT/Rockbox sansae200 $Revision$\r\n" +
+	"Björk\tHomogenic\tJóga\t3\t305\tL\t1709330400\t2f6a6d3c-0f4c-4a84-a5f1-1f3e6f7e9d0a\r\n" +
+	"Portishead\tDummy\tRoads\t\t305\tS\t1709331000\r\n"
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	got, err := scrobblerlog.Read(strings.NewReader(log))
+	require.NoError(t, err)
+
+	assert.Equal(t, scrobblerlog.Log{
+		Client: "Rockbox sansae200 $Revision$",
+		Entries: []scrobblerlog.Entry{
+			{
+				Artist:      "Björk",
+				Album:       "Homogenic",
+				Track:       "Jóga",
+				TrackNumber: "3",
+				Duration:    305,
+				Rating:      scrobblerlog.RatingListened,
+				Timestamp:   1709330400,
+				MBID:        "2f6a6d3c-0f4c-4a84-a5f1-1f3e6f7e9d0a",
+			},
+			{
+				Artist:    "Portishead",
+				Album:     "Dummy",
+				Track:     "Roads",
+				Duration:  305,
+				Rating:    scrobblerlog.RatingSkipped,
+				Timestamp: 1709331000,
+			},
+		},
+	}, got)
+
+	_, err = scrobblerlog.Read(strings.NewReader("#AUDIOSCROBBLER/1.1\nBjörk\tHomogenic\tJóga\n"))
+	require.ErrorIs(t, err, scrobblerlog.ErrInvalidEntry)
+
+	_, err = scrobblerlog.Read(strings.NewReader("Björk\tHomogenic\tJóga\t3\t305\tL\tyesterday\n"))
+	require.ErrorIs(t, err, scrobblerlog.ErrInvalidEntry)
+}
+
+func TestToListens(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := scrobblerlog.Read(strings.NewReader(log))
+	require.NoError(t, err)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	// Timestamps of an #TZ/UNKNOWN log are the local time of the player.
+	start := time.Date(2024, 3, 1, 21, 0, 0, 0, time.UTC)
+	assert.Equal(t, []listen.Listen{
+		{
+			EndedAt:  start.Add(305 * time.Second),
+			Username: "alice",
+			Platform: "Rockbox sansae200 $Revision$",
+			Played:   305 * time.Second,
+			Track:    "Jóga",
+			Artist:   "Björk",
+			Album:    "Homogenic",
+			Source:   scrobblerlog.Source,
+		},
+		{
+			EndedAt:  start.Add(10 * time.Minute),
+			Username: "alice",
+			Platform: "Rockbox sansae200 $Revision$",
+			Track:    "Roads",
+			Artist:   "Portishead",
+			Album:    "Dummy",
+			Skipped:  true,
+			Source:   scrobblerlog.Source,
+		},
+	}, scrobblerlog.ToListens(parsed, "alice", paris))
+
+	parsed.UTC = true
+	assert.Equal(t, start.Add(time.Hour+305*time.Second), scrobblerlog.ToListens(parsed, "alice", paris)[0].EndedAt)
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	entries := scrobblerlog.FromListens([]listen.Listen{
+		{
+			EndedAt: time.Date(2024, 3, 1, 22, 5, 0, 0, time.UTC),
+			Track:   "Jóga",
+			Artist:  "Björk",
+			Album:   "Homogenic",
+			Played:  5 * time.Minute,
+		},
+		{
+			EndedAt: time.Date(2024, 3, 1, 22, 10, 0, 0, time.UTC),
+			Track:   "Roads\tLive",
+			Artist:  "Portishead",
+			Played:  60500 * time.Millisecond,
+			Skipped: true,
+		},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, scrobblerlog.Write(&buf, entries))
+
+	assert.Equal(t, "#AUDIOSCROBBLER/1.1\n#TZ/UTC\n#CLIENT/decibel\n"+
+		"Björk\tHomogenic\tJóga\t\t300\tL\t1709330400\t\n"+
+		"Portishead\t\tRoads Live\t\t60\tS\t1709330939\t\n", buf.String())
+
+	// The written log reads back as the same entries.
+	got, err := scrobblerlog.Read(&buf)
+	require.NoError(t, err)
+	assert.True(t, got.UTC)
+	assert.Equal(t, "decibel", got.Client)
+	assert.Len(t, got.Entries, 2)
+	assert.Equal(t, entries[0], got.Entries[0])
+}
@@ -0,0 +1,19 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed web
+var webFS embed.FS
+
+// dashboard holds the static files of the web dashboard. They don't load
+// anything from outside the server so it works offline.
+var dashboard = func() fs.FS {
+	sub, err := fs.Sub(webFS, "web")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}()
@@ -0,0 +1,437 @@
+// Package server exposes the statistics of a decibel database as a JSON REST
+// API, along with a dashboard built on top of it.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/vingarcia/ksql"
+
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+var errBadRequest = errors.New("bad request")
+
+// Server serves the statistics of a decibel database. It expects the database
+// schema to be already migrated.
+type Server struct {
+	spotifySQLite *spotify.SQLite
+	userService   *decibel.UserService
+	mux           *http.ServeMux
+}
+
+func New(sqlProvider ksql.Provider) *Server {
+	s := &Server{
+		spotifySQLite: spotify.NewSQLite(sqlProvider),
+		userService:   decibel.NewUserService(sqlProvider),
+		mux:           http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /api/v1/totals", s.handle(s.totals))
+	s.mux.HandleFunc("GET /api/v1/artists/top", s.handle(s.topArtists))
+	s.mux.HandleFunc("GET /api/v1/tracks/top", s.handle(s.topTracks))
+	s.mux.HandleFunc("GET /api/v1/albums/top", s.handle(s.topAlbums))
+	s.mux.HandleFunc("GET /api/v1/skips", s.handle(s.skips))
+	s.mux.HandleFunc("GET /api/v1/devices", s.handle(s.devices))
+	s.mux.HandleFunc("GET /api/v1/countries", s.handle(s.countries))
+	s.mux.HandleFunc("GET /api/v1/anomalies", s.handle(s.anomalies))
+	s.mux.HandleFunc("GET /api/v1/compare", s.handle(s.compare))
+	s.mux.HandleFunc("GET /api/v1/diff", s.handle(s.diff))
+	s.mux.HandleFunc("GET /api/v1/diversity", s.handle(s.diversity))
+	s.mux.HandleFunc("GET /api/v1/obsessions", s.handle(s.obsessions))
+	s.mux.HandleFunc("GET /api/v1/timeline", s.handle(s.timeline))
+	s.mux.HandleFunc("GET /api/v1/heatmap", s.handle(s.heatmap))
+	s.mux.HandleFunc("GET /api/v1/profiles", s.handle(s.profiles))
+	s.mux.Handle("GET /", http.FileServerFS(dashboard))
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handlerFunc computes the response of an endpoint from the request and the
+// filter built from its query parameters.
+type handlerFunc func(r *http.Request, filter spotify.Filter) (any, error)
+
+// handle turns a handlerFunc into an http.HandlerFunc writing its result as
+// JSON. Errors wrapping errBadRequest are reported to the client, any other
+// error is logged and reported as an internal error.
+func (s *Server) handle(h handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := zerolog.Ctx(r.Context())
+
+		filter, err := s.filterFromQuery(r)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		result, err := h(r, filter)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		// Empty lists are written as [] rather than null.
+		if v := reflect.ValueOf(result); v.Kind() == reflect.Slice && v.IsNil() {
+			result = []struct{}{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			logger.Error().Err(err).Str("path", r.URL.Path).Msg("Failed to write response")
+		}
+	}
+}
+
+// filterFromQuery builds the statistics filter from the user, source, period
+// and limit query parameters, resolving profile names to their usernames.
+func (s *Server) filterFromQuery(r *http.Request) (spotify.Filter, error) {
+	query := r.URL.Query()
+
+	usernames, err := s.userService.ResolveUsernames(r.Context(), query["user"])
+	if err != nil {
+		return spotify.Filter{}, fmt.Errorf("s.userService.ResolveUsernames: %w", err)
+	}
+	filter := spotify.Filter{Usernames: usernames, Sources: query["source"]}
+
+	// diff takes two periods, which it applies itself.
+	if periods := query["period"]; len(periods) == 1 {
+		period, err := spotify.ParsePeriod(periods[0])
+		if err != nil {
+			return spotify.Filter{}, fmt.Errorf("%w: %w", errBadRequest, err)
+		}
+		filter = filter.WithPeriod(period)
+	}
+
+	if filter.Limit, err = intParam(r, "limit", 0); err != nil {
+		return spotify.Filter{}, err
+	}
+
+	return filter, nil
+}
+
+func (s *Server) totals(r *http.Request, filter spotify.Filter) (any, error) {
+	result, err := s.spotifySQLite.GetTotals(r.Context(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetTotals: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Server) topArtists(r *http.Request, filter spotify.Filter) (any, error) {
+	result, err := s.spotifySQLite.GetTopArtistsByPlayTime(r.Context(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetTopArtistsByPlayTime: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Server) topTracks(r *http.Request, filter spotify.Filter) (any, error) {
+	result, err := s.spotifySQLite.GetTopTracksByPlayTime(r.Context(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetTopTracksByPlayTime: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Server) topAlbums(r *http.Request, filter spotify.Filter) (any, error) {
+	result, err := s.spotifySQLite.GetTopAlbumsByPlayCount(r.Context(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetTopAlbumsByPlayCount: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Server) skips(r *http.Request, filter spotify.Filter) (any, error) {
+	result, err := s.spotifySQLite.GetMostSkippedTracks(r.Context(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetMostSkippedTracks: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Server) devices(r *http.Request, filter spotify.Filter) (any, error) {
+	interval, err := intervalParam(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.spotifySQLite.GetPlayTimeByDevice(r.Context(), interval, filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetPlayTimeByDevice: %w", err)
+	}
+
+	return result, nil
+}
+
+type countriesResponse struct {
+	Countries []spotify.CountryStats  `json:"countries"`
+	Timeline  []spotify.CountryPeriod `json:"timeline"`
+}
+
+func (s *Server) countries(r *http.Request, filter spotify.Filter) (any, error) {
+	minStreams, err := intParam(r, "min_streams", 3)
+	if err != nil {
+		return nil, err
+	}
+
+	countries, err := s.spotifySQLite.GetPlayTimeByCountry(r.Context(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetPlayTimeByCountry: %w", err)
+	}
+
+	streams, err := s.spotifySQLite.GetCountryStreams(r.Context(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetCountryStreams: %w", err)
+	}
+
+	return countriesResponse{
+		Countries: countries,
+		Timeline:  spotify.BuildCountryTimeline(streams, minStreams),
+	}, nil
+}
+
+func (s *Server) anomalies(r *http.Request, filter spotify.Filter) (any, error) {
+	minScore := 0.5
+	if raw := r.URL.Query().Get("min_score"); raw != "" {
+		var err error
+		if minScore, err = strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("%w: invalid min_score %q", errBadRequest, raw)
+		}
+	}
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = 50
+	}
+
+	streams, err := s.spotifySQLite.GetActivityStreams(r.Context(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetActivityStreams: %w", err)
+	}
+
+	anomalies := make([]spotify.Anomaly, 0, limit)
+	for _, anomaly := range spotify.DetectAnomalies(streams, spotify.DefaultAnomalyOptions) {
+		if anomaly.Score < minScore || len(anomalies) >= limit {
+			break
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+
+	return anomalies, nil
+}
+
+func (s *Server) compare(r *http.Request, filter spotify.Filter) (any, error) {
+	if len(filter.Usernames) != 2 {
+		return nil, fmt.Errorf("%w: compare needs exactly two user parameters, got %d", errBadRequest, len(filter.Usernames))
+	}
+
+	// Profiles are resolved first, so a profile and its own username are
+	// caught too.
+	if filter.Usernames[0] == filter.Usernames[1] {
+		names := r.URL.Query()["user"]
+		return nil, fmt.Errorf("%w: compare needs two different users, %s and %s are both %s",
+			errBadRequest, names[0], names[1], filter.Usernames[0])
+	}
+
+	stats, err := s.spotifySQLite.GetArtistPlayTimeByUser(r.Context(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetArtistPlayTimeByUser: %w", err)
+	}
+
+	comparison := spotify.CompareUsers(filter.Usernames[0], filter.Usernames[1], stats)
+	if filter.Limit > 0 {
+		comparison.Shared = comparison.Shared[:min(filter.Limit, len(comparison.Shared))]
+		comparison.OnlyA = comparison.OnlyA[:min(filter.Limit, len(comparison.OnlyA))]
+		comparison.OnlyB = comparison.OnlyB[:min(filter.Limit, len(comparison.OnlyB))]
+	}
+
+	return comparison, nil
+}
+
+type diffResponse struct {
+	Before  string               `json:"before"`
+	After   string               `json:"after"`
+	Totals  [2]spotify.Totals    `json:"totals"`
+	Artists []spotify.RankChange `json:"artists"`
+	Tracks  []spotify.RankChange `json:"tracks"`
+	Albums  []spotify.RankChange `json:"albums"`
+}
+
+func (s *Server) diff(r *http.Request, filter spotify.Filter) (any, error) {
+	rawPeriods := r.URL.Query()["period"]
+	if len(rawPeriods) != 2 {
+		return nil, fmt.Errorf("%w: diff needs exactly two period parameters, got %d", errBadRequest, len(rawPeriods))
+	}
+
+	var summaries [2]spotify.PeriodSummary
+	for i, rawPeriod := range rawPeriods {
+		period, err := spotify.ParsePeriod(rawPeriod)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errBadRequest, err)
+		}
+
+		if summaries[i], err = s.spotifySQLite.GetPeriodSummary(r.Context(), filter.WithPeriod(period)); err != nil {
+			return nil, fmt.Errorf("s.spotifySQLite.GetPeriodSummary %s: %w", period.Label, err)
+		}
+	}
+
+	return diffResponse{
+		Before:  rawPeriods[0],
+		After:   rawPeriods[1],
+		Totals:  [2]spotify.Totals{summaries[0].Totals, summaries[1].Totals},
+		Artists: spotify.DiffRanks(summaries[0].Artists, summaries[1].Artists),
+		Tracks:  spotify.DiffRanks(summaries[0].Tracks, summaries[1].Tracks),
+		Albums:  spotify.DiffRanks(summaries[0].Albums, summaries[1].Albums),
+	}, nil
+}
+
+type diversityResponse struct {
+	Artists []spotify.Diversity `json:"artists"`
+	Tracks  []spotify.Diversity `json:"tracks"`
+}
+
+func (s *Server) diversity(r *http.Request, filter spotify.Filter) (any, error) {
+	interval, err := intervalParam(r)
+	if err != nil {
+		return nil, err
+	}
+
+	artistPlayTimes, err := s.spotifySQLite.GetArtistPlayTimeByPeriod(r.Context(), interval, filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetArtistPlayTimeByPeriod: %w", err)
+	}
+
+	trackPlayTimes, err := s.spotifySQLite.GetTrackPlayTimeByPeriod(r.Context(), interval, filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetTrackPlayTimeByPeriod: %w", err)
+	}
+
+	return diversityResponse{
+		Artists: spotify.ComputeDiversity(artistPlayTimes),
+		Tracks:  spotify.ComputeDiversity(trackPlayTimes),
+	}, nil
+}
+
+func (s *Server) obsessions(r *http.Request, filter spotify.Filter) (any, error) {
+	opts := spotify.DefaultObsessionOptions
+
+	var err error
+	if opts.MinPlays, err = intParam(r, "min_plays", opts.MinPlays); err != nil {
+		return nil, err
+	}
+	if opts.MinRepeats, err = intParam(r, "min_repeats", opts.MinRepeats); err != nil {
+		return nil, err
+	}
+	windowDays, err := intParam(r, "window_days", int(opts.Window/(24*time.Hour)))
+	if err != nil {
+		return nil, err
+	}
+	opts.Window = time.Duration(windowDays) * 24 * time.Hour
+
+	streams, err := s.spotifySQLite.GetTrackStreams(r.Context(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetTrackStreams: %w", err)
+	}
+
+	return spotify.DetectObsessions(streams, opts), nil
+}
+
+func (s *Server) timeline(r *http.Request, filter spotify.Filter) (any, error) {
+	interval, err := intervalParam(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.spotifySQLite.GetPlayTimeByPeriod(r.Context(), interval, filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetPlayTimeByPeriod: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Server) heatmap(r *http.Request, filter spotify.Filter) (any, error) {
+	result, err := s.spotifySQLite.GetPlayTimeByHour(r.Context(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.spotifySQLite.GetPlayTimeByHour: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Server) profiles(r *http.Request, _ spotify.Filter) (any, error) {
+	result, err := s.userService.ListProfiles(r.Context())
+	if err != nil {
+		return nil, fmt.Errorf("s.userService.ListProfiles: %w", err)
+	}
+
+	return result, nil
+}
+
+// intParam parses the positive integer query parameter name, def if missing.
+func intParam(r *http.Request, name string, def int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("%w: invalid %s %q", errBadRequest, name, raw)
+	}
+
+	return value, nil
+}
+
+// intervalParam parses the interval query parameter, month if missing.
+func intervalParam(r *http.Request) (spotify.Interval, error) {
+	raw := r.URL.Query().Get("interval")
+	if raw == "" {
+		return spotify.IntervalMonth, nil
+	}
+
+	interval, err := spotify.ParseInterval(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errBadRequest, err)
+	}
+
+	return interval, nil
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, message := http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+	switch {
+	case errors.Is(err, errBadRequest):
+		status, message = http.StatusBadRequest, err.Error()
+	case errors.Is(err, context.Canceled):
+		return
+	default:
+		zerolog.Ctx(r.Context()).Error().Err(err).Str("path", r.URL.Path).Msg("Failed to handle request")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: message})
+}
@@ -0,0 +1,207 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/server"
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/internal/spotify/ksqltest"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	topArtists := func(_ context.Context, records any, _ string, _ ...any) error {
+		*(records.(*[]spotify.ArtistStats)) = []spotify.ArtistStats{
+			{Artist: "artist1", PlayCount: 10, TotalPlayTime: 1000},
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		mock       func(*ksqltest.MockProvider)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "serves top artists",
+			target: "/api/v1/artists/top",
+			mock: func(m *ksqltest.MockProvider) {
+				m.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(topArtists)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"artist":"artist1","play_count":10,"total_play_time_ms":1000}]`,
+		},
+		{
+			name:   "filters by profile, source, period and limit",
+			target: "/api/v1/artists/top?user=alice&source=lastfm&period=2024&limit=5",
+			mock: func(m *ksqltest.MockProvider) {
+				m.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, records any, _ string, _ ...any) error {
+						*(records.(*[]decibel.Profile)) = []decibel.Profile{{Name: "alice", Username: "31abc"}}
+						return nil
+					})
+				m.EXPECT().Query(gomock.Any(), gomock.Any(),
+					gomock.Regex(`LIMIT 5`), "31abc", "lastfm", "2024-01-01 00:00:00", "2025-01-01 00:00:00").
+					DoAndReturn(topArtists)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"artist":"artist1","play_count":10,"total_play_time_ms":1000}]`,
+		},
+		{
+			name:   "writes empty lists as arrays",
+			target: "/api/v1/skips",
+			mock: func(m *ksqltest.MockProvider) {
+				m.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[]`,
+		},
+		{
+			name:   "serves listening over time",
+			target: "/api/v1/timeline?interval=year",
+			mock: func(m *ksqltest.MockProvider) {
+				m.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Regex(`strftime\('%Y'`)).
+					DoAndReturn(func(_ context.Context, records any, _ string, _ ...any) error {
+						*(records.(*[]spotify.PeriodStats)) = []spotify.PeriodStats{
+							{Period: "2024", PlayCount: 3, TotalPlayTimeMS: 300},
+						}
+						return nil
+					})
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"period":"2024","play_count":3,"total_play_time_ms":300}]`,
+		},
+		{
+			name:       "rejects invalid limit",
+			target:     "/api/v1/tracks/top?limit=abc",
+			mock:       func(*ksqltest.MockProvider) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"bad request: invalid limit \"abc\""}`,
+		},
+		{
+			name:       "rejects invalid interval",
+			target:     "/api/v1/devices?interval=decade",
+			mock:       func(*ksqltest.MockProvider) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"bad request: invalid interval: \"decade\""}`,
+		},
+		{
+			name:       "requires two periods to diff",
+			target:     "/api/v1/diff?period=2024",
+			mock:       func(*ksqltest.MockProvider) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"bad request: diff needs exactly two period parameters, got 1"}`,
+		},
+		{
+			name:   "rejects comparing a profile with its own username",
+			target: "/api/v1/compare?user=alice&user=31abc",
+			mock: func(m *ksqltest.MockProvider) {
+				m.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, records any, _ string, _ ...any) error {
+						*(records.(*[]decibel.Profile)) = []decibel.Profile{{Name: "alice", Username: "31abc"}}
+						return nil
+					})
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"bad request: compare needs two different users, alice and 31abc are both 31abc"}`,
+		},
+		{
+			name:   "hides database errors",
+			target: "/api/v1/albums/top",
+			mock: func(m *ksqltest.MockProvider) {
+				m.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).Return(assert.AnError)
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"Internal Server Error"}`,
+		},
+		{
+			name:       "rejects other methods",
+			target:     "/api/v1/totals",
+			mock:       func(*ksqltest.MockProvider) {},
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			mockProvider := ksqltest.NewMockProvider(ctrl)
+			tt.mock(mockProvider)
+
+			method := http.MethodGet
+			if tt.wantStatus == http.StatusMethodNotAllowed {
+				method = http.MethodPost
+			}
+
+			rec := httptest.NewRecorder()
+			server.New(mockProvider).ServeHTTP(rec, httptest.NewRequest(method, tt.target, nil))
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestServer_Compare(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockProvider := ksqltest.NewMockProvider(ctrl)
+	mockProvider.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockProvider.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), "a", "b").
+		DoAndReturn(func(_ context.Context, records any, _ string, _ ...any) error {
+			*(records.(*[]spotify.UserArtistStats)) = []spotify.UserArtistStats{
+				{Username: "a", Artist: "artist1", TotalPlayTimeMS: 100},
+				{Username: "b", Artist: "artist1", TotalPlayTimeMS: 100},
+			}
+			return nil
+		})
+
+	rec := httptest.NewRecorder()
+	server.New(mockProvider).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/compare?user=a&user=b", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var comparison spotify.UserComparison
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &comparison))
+	assert.Equal(t, "a", comparison.UserA)
+	assert.InDelta(t, 1.0, comparison.Jaccard, 1e-9)
+	require.Len(t, comparison.Shared, 1)
+	assert.Equal(t, "artist1", comparison.Shared[0].Artist)
+}
+
+func TestServer_Dashboard(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	srv := server.New(ksqltest.NewMockProvider(ctrl))
+
+	for _, target := range []string{"/", "/app.js", "/style.css"} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code, target)
+		assert.NotContains(t, rec.Body.String(), "https://", target)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing.js", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
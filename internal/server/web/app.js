@@ -0,0 +1,252 @@
+// decibel dashboard: fetches the JSON API and draws the charts as inline SVG,
+// without any dependency so it works offline.
+"use strict";
+
+const SVG_NS = "http://www.w3.org/2000/svg";
+const WEEKDAYS = ["Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"];
+
+const form = document.getElementById("filters");
+const errorBox = document.getElementById("error");
+
+function el(tag, attrs = {}, children = []) {
+  const node = document.createElementNS(SVG_NS, tag);
+  for (const [key, value] of Object.entries(attrs)) {
+    node.setAttribute(key, value);
+  }
+  for (const child of [].concat(children)) {
+    node.append(child);
+  }
+  return node;
+}
+
+function text(x, y, content, attrs = {}) {
+  return el("text", { x, y, ...attrs }, String(content));
+}
+
+function title(content) {
+  return el("title", {}, content);
+}
+
+function hours(ms) {
+  const h = Math.floor(ms / 3600000);
+  const m = Math.floor(ms / 60000) % 60;
+  return `${h}h ${m}m`;
+}
+
+function truncate(s, max) {
+  return s.length > max ? s.slice(0, max - 1) + "…" : s;
+}
+
+function render(id, node) {
+  const container = document.getElementById(id);
+  container.replaceChildren(node);
+}
+
+function empty(id) {
+  const p = document.createElement("p");
+  p.className = "empty";
+  p.textContent = "No data for these filters.";
+  render(id, p);
+}
+
+// barChart draws horizontal bars, one per item.
+function barChart(id, items, { label, value, format, className = "bar" }) {
+  if (items.length === 0) {
+    return empty(id);
+  }
+
+  const width = 600, rowHeight = 24, labelWidth = 220, valueWidth = 80;
+  const barWidth = width - labelWidth - valueWidth;
+  const max = Math.max(...items.map(value)) || 1;
+  const svg = el("svg", { viewBox: `0 0 ${width} ${items.length * rowHeight}` });
+
+  items.forEach((item, i) => {
+    const y = i * rowHeight;
+    const w = Math.max(1, (value(item) / max) * barWidth);
+    svg.append(
+      text(0, y + 16, truncate(label(item), 34), { class: "label" }),
+      el("rect", { class: className, x: labelWidth, y: y + 4, width: w, height: rowHeight - 8, rx: 2 },
+        title(`${label(item)}: ${format(value(item))}`)),
+      text(labelWidth + w + 6, y + 16, format(value(item))),
+    );
+  });
+
+  render(id, svg);
+}
+
+// areaChart draws play time per period as an area with period labels.
+function areaChart(id, periods) {
+  if (periods.length === 0) {
+    return empty(id);
+  }
+
+  const width = 1000, height = 260, left = 50, bottom = 24, top = 10;
+  const plotWidth = width - left, plotHeight = height - bottom - top;
+  const max = Math.max(...periods.map((p) => p.total_play_time_ms)) || 1;
+  const step = periods.length > 1 ? plotWidth / (periods.length - 1) : 0;
+  const x = (i) => left + i * step;
+  const y = (ms) => top + plotHeight - (ms / max) * plotHeight;
+
+  const svg = el("svg", { viewBox: `0 0 ${width} ${height}` });
+
+  for (const fraction of [0, 0.5, 1]) {
+    const gy = y(max * fraction);
+    svg.append(
+      el("line", { class: "grid", x1: left, x2: width, y1: gy, y2: gy }),
+      text(0, gy + 4, hours(max * fraction).split(" ")[0]),
+    );
+  }
+
+  const points = periods.map((p, i) => `${x(i)},${y(p.total_play_time_ms)}`);
+  svg.append(el("polygon", {
+    class: "area",
+    points: [`${x(0)},${y(0)}`, ...points, `${x(periods.length - 1)},${y(0)}`].join(" "),
+  }));
+
+  const labelEvery = Math.ceil(periods.length / 12);
+  periods.forEach((p, i) => {
+    svg.append(el("circle", { cx: x(i), cy: y(p.total_play_time_ms), r: 8, "fill-opacity": 0 },
+      title(`${p.period}: ${hours(p.total_play_time_ms)}, ${p.play_count} plays`)));
+    if (i % labelEvery === 0) {
+      svg.append(text(x(i), height - 6, p.period, { "text-anchor": "middle" }));
+    }
+  });
+
+  render(id, svg);
+}
+
+// heatmap draws play time per hour of each day of the week.
+function heatmap(id, cells) {
+  if (cells.length === 0) {
+    return empty(id);
+  }
+
+  const cell = 36, left = 40, top = 18;
+  const max = Math.max(...cells.map((c) => c.total_play_time_ms)) || 1;
+  const byKey = new Map(cells.map((c) => [`${c.weekday}-${c.hour}`, c]));
+  const svg = el("svg", { viewBox: `0 0 ${left + 24 * cell} ${top + 7 * cell}` });
+
+  for (let hour = 0; hour < 24; hour += 3) {
+    svg.append(text(left + hour * cell + cell / 2, 12, `${hour}h`, { "text-anchor": "middle" }));
+  }
+
+  // Weeks start on Monday.
+  [1, 2, 3, 4, 5, 6, 0].forEach((weekday, row) => {
+    svg.append(text(0, top + row * cell + cell / 2 + 4, WEEKDAYS[weekday]));
+    for (let hour = 0; hour < 24; hour++) {
+      const c = byKey.get(`${weekday}-${hour}`);
+      const ms = c ? c.total_play_time_ms : 0;
+      svg.append(el("rect", {
+        x: left + hour * cell + 1,
+        y: top + row * cell + 1,
+        width: cell - 2,
+        height: cell - 2,
+        rx: 3,
+        fill: "#1db954",
+        "fill-opacity": 0.08 + 0.92 * (ms / max),
+      }, title(`${WEEKDAYS[weekday]} ${hour}:00: ${hours(ms)}`)));
+    }
+  });
+
+  render(id, svg);
+}
+
+function totals(t) {
+  const items = [
+    [hours(t.total_play_time_ms), "listened"],
+    [t.play_count.toLocaleString(), "streams"],
+    [t.artist_count.toLocaleString(), "artists"],
+    [t.track_count.toLocaleString(), "tracks"],
+  ];
+  document.getElementById("totals").replaceChildren(...items.map(([value, label]) => {
+    const div = document.createElement("div");
+    div.className = "total";
+    const strong = document.createElement("strong");
+    strong.textContent = value;
+    const span = document.createElement("span");
+    span.textContent = label;
+    div.append(strong, span);
+    return div;
+  }));
+}
+
+async function api(path, params) {
+  const response = await fetch(`api/v1/${path}?${params}`);
+  const body = await response.json();
+  if (!response.ok) {
+    throw new Error(body.error || response.statusText);
+  }
+  return body;
+}
+
+function filterParams() {
+  const params = new URLSearchParams();
+  const data = new FormData(form);
+  for (const key of ["user", "period"]) {
+    const value = data.get(key).trim();
+    if (value) {
+      params.set(key, value);
+    }
+  }
+  return params;
+}
+
+async function refresh() {
+  const params = filterParams();
+  const timelineParams = new URLSearchParams(params);
+  timelineParams.set("interval", new FormData(form).get("interval"));
+  const listParams = new URLSearchParams(params);
+  listParams.set("limit", "15");
+
+  try {
+    const [summary, timeline, artists, tracks, hourCells, skips] = await Promise.all([
+      api("totals", params),
+      api("timeline", timelineParams),
+      api("artists/top", listParams),
+      api("tracks/top", listParams),
+      api("heatmap", params),
+      api("skips", listParams),
+    ]);
+    errorBox.hidden = true;
+
+    totals(summary);
+    areaChart("timeline", timeline);
+    barChart("artists", artists, { label: (a) => a.artist, value: (a) => a.total_play_time_ms, format: hours });
+    barChart("tracks", tracks, {
+      label: (t) => `${t.track} - ${t.artist}`,
+      value: (t) => t.total_play_time_ms,
+      format: hours,
+    });
+    heatmap("heatmap", hourCells);
+    barChart("skips", skips, {
+      label: (s) => `${s.track} - ${s.artist}`,
+      value: (s) => s.skip_rate,
+      format: (rate) => `${(rate * 100).toFixed(1)}%`,
+      className: "bar skip",
+    });
+  } catch (err) {
+    errorBox.textContent = err.message;
+    errorBox.hidden = false;
+  }
+}
+
+async function loadProfiles() {
+  try {
+    const profiles = await api("profiles", new URLSearchParams());
+    const select = form.elements.user;
+    for (const profile of profiles) {
+      select.append(new Option(profile.name, profile.name));
+    }
+  } catch (err) {
+    errorBox.textContent = err.message;
+    errorBox.hidden = false;
+  }
+}
+
+form.addEventListener("submit", (event) => {
+  event.preventDefault();
+  refresh();
+});
+
+loadProfiles();
+refresh();
@@ -0,0 +1,168 @@
+// Package shell implements an interactive SQL shell on top of the read-only
+// query runner, with dot commands in the spirit of the sqlite3 shell.
+package shell
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cadoween/decibel/internal/query"
+	"github.com/cadoween/decibel/pkg/tabular"
+)
+
+var (
+	// ErrQuit is returned by Session.Exec when the user asked to leave.
+	ErrQuit           = errors.New("quit")
+	ErrUnknownCommand = errors.New("unknown command")
+)
+
+const help = `.format [table|csv|json]  Show or change the output format
+.help                     Show this help
+.quit, .exit              Leave the shell
+.schema [TABLE]           Show the columns of every table, or of TABLE
+.tables                   List the tables
+
+Statements are run read-only and must start with SELECT, WITH, VALUES or
+EXPLAIN. Press tab to complete table and column names.
+`
+
+// Runner runs a single SQL statement.
+type Runner interface {
+	Run(ctx context.Context, statement string, args ...any) (tabular.Table, error)
+}
+
+// Session runs statements and dot commands, keeping the output format
+// between them.
+type Session struct {
+	runner Runner
+	tables []query.Table
+	format tabular.Format
+}
+
+// NewSession returns a session running statements with runner. tables are
+// the tables and columns shown by .tables and .schema.
+func NewSession(runner Runner, tables []query.Table, format tabular.Format) *Session {
+	return &Session{
+		runner: runner,
+		tables: tables,
+		format: format,
+	}
+}
+
+// Tables returns the tables of the database.
+func (s *Session) Tables() []query.Table {
+	return s.tables
+}
+
+// Exec runs a statement or a dot command and writes its output to w.
+func (s *Session) Exec(ctx context.Context, w io.Writer, input string) error {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(input, ".") {
+		return s.command(w, strings.Fields(input))
+	}
+
+	table, err := s.runner.Run(ctx, input)
+	if err != nil {
+		return fmt.Errorf("s.runner.Run: %w", err)
+	}
+
+	if err := tabular.Write(w, s.format, table); err != nil {
+		return fmt.Errorf("tabular.Write: %w", err)
+	}
+
+	if s.format == tabular.FormatTable {
+		rows := "rows"
+		if len(table.Rows) == 1 {
+			rows = "row"
+		}
+		_, _ = fmt.Fprintf(w, "(%d %s)\n", len(table.Rows), rows)
+	}
+
+	return nil
+}
+
+func (s *Session) command(w io.Writer, args []string) error {
+	switch args[0] {
+	case ".quit", ".exit":
+		return ErrQuit
+
+	case ".help":
+		_, _ = io.WriteString(w, help)
+
+	case ".tables":
+		for _, table := range s.tables {
+			_, _ = fmt.Fprintln(w, table.Name)
+		}
+
+	case ".schema":
+		for _, table := range s.tables {
+			if len(args) > 1 && !strings.EqualFold(table.Name, args[1]) {
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "%s (%s)\n", table.Name, strings.Join(table.Columns, ", "))
+		}
+
+	case ".format":
+		if len(args) == 1 {
+			_, _ = fmt.Fprintln(w, s.format)
+			return nil
+		}
+
+		format, err := tabular.ParseFormat(args[1])
+		if err != nil {
+			return fmt.Errorf("tabular.ParseFormat: %w", err)
+		}
+		s.format = format
+
+	default:
+		return fmt.Errorf("%w: %s, try .help", ErrUnknownCommand, args[0])
+	}
+
+	return nil
+}
+
+// RunScript runs the statements read from r, writing their output to w. A
+// statement ends with a semicolon at the end of a line, dot commands take a
+// line of their own. It stops at the first failing statement.
+func (s *Session) RunScript(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+
+	var statement strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if statement.Len() == 0 && strings.HasPrefix(line, ".") {
+			if err := s.Exec(ctx, w, line); err != nil {
+				if errors.Is(err, ErrQuit) {
+					return nil
+				}
+				return err
+			}
+			continue
+		}
+
+		statement.WriteString(line)
+		statement.WriteString("\n")
+
+		if strings.HasSuffix(line, ";") {
+			if err := s.Exec(ctx, w, statement.String()); err != nil {
+				return err
+			}
+			statement.Reset()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scanner.Err: %w", err)
+	}
+
+	return s.Exec(ctx, w, statement.String())
+}
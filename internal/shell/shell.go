@@ -0,0 +1,242 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cadoween/decibel/internal/query"
+)
+
+// Prompt is shown in front of the line being edited.
+const Prompt = "decibel> "
+
+type execMsg struct {
+	output string
+	err    error
+}
+
+var (
+	promptStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10"))
+	cursorStyle = lipgloss.NewStyle().Reverse(true)
+	faintStyle  = lipgloss.NewStyle().Faint(true)
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// Model is the bubbletea model of the shell. It edits a single line at the
+// bottom of the terminal and prints statements and their output above it,
+// so results stay in the terminal scrollback.
+type Model struct {
+	ctx     context.Context
+	session *Session
+
+	input  []rune
+	cursor int
+
+	// history holds the previous lines, historyPos is the index of the one
+	// being edited or len(history) for a new line.
+	history    []string
+	historyPos int
+
+	candidates []string
+	running    bool
+}
+
+// New creates a shell running its input in session.
+func New(ctx context.Context, session *Session) Model {
+	return Model{
+		ctx:     ctx,
+		session: session,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case execMsg:
+		m.running = false
+		switch {
+		case errors.Is(msg.err, ErrQuit):
+			return m, tea.Quit
+		case msg.err != nil:
+			return m, tea.Println(errorStyle.Render("Error: " + msg.err.Error()))
+		case msg.output != "":
+			return m, tea.Println(strings.TrimRight(msg.output, "\n"))
+		default:
+			return m, nil
+		}
+	case tea.KeyMsg:
+		if m.running {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		return m.updateInput(msg)
+	default:
+		return m, nil
+	}
+}
+
+func (m Model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type != tea.KeyTab {
+		m.candidates = nil
+	}
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		if len(m.input) == 0 {
+			return m, tea.Quit
+		}
+		m.setInput("")
+	case tea.KeyCtrlD:
+		if len(m.input) == 0 {
+			return m, tea.Quit
+		}
+	case tea.KeyEnter:
+		return m.submit()
+	case tea.KeyTab:
+		m.complete()
+	case tea.KeyUp:
+		if m.historyPos > 0 {
+			m.historyPos--
+			m.setInput(m.history[m.historyPos])
+		}
+	case tea.KeyDown:
+		if m.historyPos < len(m.history) {
+			m.historyPos++
+			if m.historyPos == len(m.history) {
+				m.setInput("")
+			} else {
+				m.setInput(m.history[m.historyPos])
+			}
+		}
+	case tea.KeyLeft:
+		m.cursor = max(0, m.cursor-1)
+	case tea.KeyRight:
+		m.cursor = min(len(m.input), m.cursor+1)
+	case tea.KeyHome, tea.KeyCtrlA:
+		m.cursor = 0
+	case tea.KeyEnd, tea.KeyCtrlE:
+		m.cursor = len(m.input)
+	case tea.KeyBackspace:
+		if m.cursor > 0 {
+			m.input = append(m.input[:m.cursor-1:m.cursor-1], m.input[m.cursor:]...)
+			m.cursor--
+		}
+	case tea.KeyDelete:
+		if m.cursor < len(m.input) {
+			m.input = append(m.input[:m.cursor:m.cursor], m.input[m.cursor+1:]...)
+		}
+	case tea.KeyCtrlU:
+		m.input = m.input[m.cursor:]
+		m.cursor = 0
+	case tea.KeyCtrlW:
+		start := m.cursor
+		for start > 0 && m.input[start-1] == ' ' {
+			start--
+		}
+		for start > 0 && m.input[start-1] != ' ' {
+			start--
+		}
+		m.input = append(m.input[:start:start], m.input[m.cursor:]...)
+		m.cursor = start
+	case tea.KeyRunes, tea.KeySpace:
+		// Pasted statements may span several lines.
+		m.insert([]rune(strings.ReplaceAll(string(msg.Runes), "\n", " ")))
+	default:
+	}
+
+	return m, nil
+}
+
+// submit runs the current line and prints it above the prompt.
+func (m Model) submit() (tea.Model, tea.Cmd) {
+	line := strings.TrimSpace(string(m.input))
+	m.setInput("")
+
+	echo := tea.Println(promptStyle.Render(Prompt) + line)
+	if line == "" {
+		return m, echo
+	}
+
+	if len(m.history) == 0 || m.history[len(m.history)-1] != line {
+		m.history = append(m.history, line)
+	}
+	m.historyPos = len(m.history)
+	m.running = true
+
+	ctx, session := m.ctx, m.session
+	run := func() tea.Msg {
+		var sb strings.Builder
+		err := session.Exec(ctx, &sb, line)
+		return execMsg{output: sb.String(), err: err}
+	}
+
+	return m, tea.Sequence(echo, run)
+}
+
+// complete completes the word before the cursor with a table or column
+// name. When several names match, the word is extended as far as they agree
+// and the names are listed under the prompt.
+func (m *Model) complete() {
+	before := string(m.input[:m.cursor])
+	start, candidates := query.Complete(m.session.Tables(), before)
+	word := before[start:]
+
+	replacement := query.CommonPrefix(candidates)
+	if len(candidates) > 1 {
+		m.candidates = candidates
+	}
+	if len(replacement) <= len(word) {
+		return
+	}
+
+	after := m.input[m.cursor:]
+	head := []rune(before[:start] + replacement)
+	m.input = append(head, after...)
+	m.cursor = len(head)
+}
+
+func (m *Model) insert(runes []rune) {
+	input := make([]rune, 0, len(m.input)+len(runes))
+	input = append(input, m.input[:m.cursor]...)
+	input = append(input, runes...)
+	m.input = append(input, m.input[m.cursor:]...)
+	m.cursor += len(runes)
+}
+
+func (m *Model) setInput(s string) {
+	m.input = []rune(s)
+	m.cursor = len(m.input)
+}
+
+func (m Model) View() string {
+	var sb strings.Builder
+
+	sb.WriteString(promptStyle.Render(Prompt))
+	sb.WriteString(string(m.input[:m.cursor]))
+	if m.cursor < len(m.input) {
+		sb.WriteString(cursorStyle.Render(string(m.input[m.cursor])))
+		sb.WriteString(string(m.input[m.cursor+1:]))
+	} else {
+		sb.WriteString(cursorStyle.Render(" "))
+	}
+	sb.WriteString("\n")
+
+	switch {
+	case m.running:
+		sb.WriteString(faintStyle.Render("Running…") + "\n")
+	case len(m.candidates) > 0:
+		sb.WriteString(faintStyle.Render(strings.Join(m.candidates, "  ")) + "\n")
+	default:
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,180 @@
+package shell_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/query"
+	"github.com/cadoween/decibel/internal/shell"
+	"github.com/cadoween/decibel/pkg/tabular"
+)
+
+var errNoSuchTable = errors.New("no such table")
+
+// fakeRunner answers every statement with the same row and records the
+// statements it ran.
+type fakeRunner struct {
+	statements []string
+}
+
+func (r *fakeRunner) Run(_ context.Context, statement string, _ ...any) (tabular.Table, error) {
+	r.statements = append(r.statements, statement)
+	if strings.Contains(statement, "missing") {
+		return tabular.Table{}, errNoSuchTable
+	}
+	return tabular.Table{Columns: []string{"name"}, Rows: [][]any{{"alice"}}}, nil
+}
+
+var tables = []query.Table{
+	{Name: "profiles", Columns: []string{"name", "username"}},
+	{Name: "spotify_streams", Columns: []string{"ts", "ms_played"}},
+}
+
+func TestSession_Exec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "statement", input: "SELECT name FROM profiles", want: "name\n-----\nalice\n(1 row)\n"},
+		{name: "tables", input: ".tables", want: "profiles\nspotify_streams\n"},
+		{name: "schema", input: ".schema profiles", want: "profiles (name, username)\n"},
+		{name: "format", input: ".format", want: "table\n"},
+		{name: "empty", input: "  ", want: ""},
+		{name: "quit", input: ".quit", wantErr: shell.ErrQuit},
+		{name: "unknown command", input: ".open other.db", wantErr: shell.ErrUnknownCommand},
+		{name: "invalid format", input: ".format xml", wantErr: tabular.ErrInvalidFormat},
+		{name: "failing statement", input: "SELECT * FROM missing", wantErr: errNoSuchTable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			session := shell.NewSession(&fakeRunner{}, tables, tabular.FormatTable)
+
+			var sb strings.Builder
+			err := session.Exec(context.Background(), &sb, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+}
+
+func TestSession_RunScript(t *testing.T) {
+	t.Parallel()
+
+	runner := &fakeRunner{}
+	session := shell.NewSession(runner, tables, tabular.FormatTable)
+
+	script := ".format csv\nSELECT name\nFROM profiles;\nSELECT 1;\n.quit\nSELECT 2;\n"
+
+	var sb strings.Builder
+	require.NoError(t, session.RunScript(context.Background(), strings.NewReader(script), &sb))
+	assert.Equal(t, []string{"SELECT name\nFROM profiles;", "SELECT 1;"}, runner.statements)
+	assert.Equal(t, "name\nalice\nname\nalice\n", sb.String())
+}
+
+func TestSession_RunScript_StopsOnError(t *testing.T) {
+	t.Parallel()
+
+	runner := &fakeRunner{}
+	session := shell.NewSession(runner, tables, tabular.FormatCSV)
+
+	script := "SELECT * FROM missing;\nSELECT 1;\n"
+
+	err := session.RunScript(context.Background(), strings.NewReader(script), &strings.Builder{})
+	require.ErrorIs(t, err, errNoSuchTable)
+	assert.Len(t, runner.statements, 1)
+}
+
+// run runs cmd and feeds its messages back into the model, going through
+// the commands of a tea.Sequence, whose message type bubbletea doesn't export.
+func run(m tea.Model, cmd tea.Cmd) tea.Model {
+	msg := cmd()
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice {
+		for i := range v.Len() {
+			if c, ok := v.Index(i).Interface().(tea.Cmd); ok && c != nil {
+				m = run(m, c)
+			}
+		}
+		return m
+	}
+
+	m, _ = m.Update(msg)
+	return m
+}
+
+func TestModel(t *testing.T) {
+	t.Parallel()
+
+	typeText := func(m tea.Model, text string) tea.Model {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)})
+		return m
+	}
+	press := func(m tea.Model, key tea.KeyType) (tea.Model, tea.Cmd) {
+		return m.Update(tea.KeyMsg{Type: key})
+	}
+
+	t.Run("completes a unique name", func(t *testing.T) {
+		t.Parallel()
+
+		m := tea.Model(shell.New(context.Background(), shell.NewSession(&fakeRunner{}, tables, tabular.FormatTable)))
+		m = typeText(m, "SELECT * FROM pro")
+		m, _ = press(m, tea.KeyTab)
+
+		assert.Contains(t, m.View(), "SELECT * FROM profiles")
+	})
+
+	t.Run("lists ambiguous names", func(t *testing.T) {
+		t.Parallel()
+
+		m := tea.Model(shell.New(context.Background(), shell.NewSession(&fakeRunner{}, tables, tabular.FormatTable)))
+		m = typeText(m, "SELECT spotify_streams.")
+		m, _ = press(m, tea.KeyTab)
+
+		assert.Contains(t, m.View(), "spotify_streams.ms_played  spotify_streams.ts")
+	})
+
+	t.Run("runs the line and recalls it from history", func(t *testing.T) {
+		t.Parallel()
+
+		runner := &fakeRunner{}
+		m := tea.Model(shell.New(context.Background(), shell.NewSession(runner, tables, tabular.FormatTable)))
+		m = typeText(m, "SELECT name FROM profiles")
+
+		m, cmd := press(m, tea.KeyEnter)
+		require.NotNil(t, cmd)
+		assert.NotContains(t, m.View(), "SELECT")
+
+		m = run(m, cmd)
+		assert.Equal(t, []string{"SELECT name FROM profiles"}, runner.statements)
+
+		m, _ = press(m, tea.KeyUp)
+		assert.Contains(t, m.View(), "SELECT name FROM profiles")
+	})
+
+	t.Run("quits on ctrl+d", func(t *testing.T) {
+		t.Parallel()
+
+		m := tea.Model(shell.New(context.Background(), shell.NewSession(&fakeRunner{}, tables, tabular.FormatTable)))
+		_, cmd := press(m, tea.KeyCtrlD)
+		require.NotNil(t, cmd)
+		assert.Equal(t, tea.Quit(), cmd())
+	})
+}
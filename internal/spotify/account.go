@@ -0,0 +1,77 @@
+package spotify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+// SourceSpotifyAccount is the source recorded for imports of the streaming
+// history of the account data export, the last year of streams sent within
+// days of the request. Its streams are merged into the ones of the extended
+// history, which records them in full.
+const SourceSpotifyAccount = "spotifyaccount"
+
+// accountTimeLayout is the layout of the end times of the account data, in
+// UTC to the minute.
+const accountTimeLayout = "2006-01-02 15:04"
+
+var ErrInvalidAccountStream = errors.New("invalid account data stream")
+
+// AccountStream is a stream of the StreamingHistory*.json files of the
+// account data export. EndTime is when the stream ended.
+type AccountStream struct {
+	EndTime    string `json:"endTime"`
+	ArtistName string `json:"artistName"`
+	TrackName  string `json:"trackName"`
+	MSPlayed   int64  `json:"msPlayed"`
+}
+
+// ReadAccountFile reads the streams of an account data history file.
+func ReadAccountFile(path string) ([]AccountStream, error) {
+	f, err := os.Open(path) //nolint:gosec // The path is given by the user on purpose.
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer iox.Close(f)
+
+	var streams []AccountStream
+	if err := json.NewDecoder(f).Decode(&streams); err != nil {
+		return nil, fmt.Errorf("json.NewDecoder(f).Decode: %w", err)
+	}
+
+	return streams, nil
+}
+
+// AccountStreamsToListens maps the streams of the account data to listens
+// of username. Podcast episodes, found in their own files, have no track
+// and are left out.
+func AccountStreamsToListens(streams []AccountStream, username string) ([]listen.Listen, error) {
+	listens := make([]listen.Listen, 0, len(streams))
+	for i, stream := range streams {
+		if stream.TrackName == "" {
+			continue
+		}
+
+		endedAt, err := time.Parse(accountTimeLayout, stream.EndTime)
+		if err != nil {
+			return nil, fmt.Errorf("%w: stream %d ends at %q", ErrInvalidAccountStream, i+1, stream.EndTime)
+		}
+
+		listens = append(listens, listen.Listen{
+			EndedAt:  endedAt,
+			Username: username,
+			Source:   SourceSpotifyAccount,
+			Track:    stream.TrackName,
+			Artist:   stream.ArtistName,
+			Played:   time.Duration(stream.MSPlayed) * time.Millisecond,
+		})
+	}
+
+	return listens, nil
+}
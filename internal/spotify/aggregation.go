@@ -0,0 +1,171 @@
+package spotify
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownDimension = errors.New("unknown dimension")
+	ErrUnknownMetric    = errors.New("unknown metric")
+	ErrInvalidOrder     = errors.New("order must be one of the dimensions or metrics")
+	ErrNoMetrics        = errors.New("at least one metric is required")
+)
+
+// dimension is a column streams can be grouped by.
+type dimension struct {
+	expr string
+	// notNull keeps the streams with a value only, so podcasts don't show
+	// up as an empty artist for instance.
+	notNull string
+}
+
+// dimensions are the columns an Aggregation can group by, by name.
+var dimensions = map[string]dimension{
+	"artist":       {expr: "master_metadata_album_artist_name", notNull: "master_metadata_album_artist_name IS NOT NULL"},
+	"track":        {expr: "master_metadata_track_name", notNull: "master_metadata_track_name IS NOT NULL"},
+	"album":        {expr: "master_metadata_album_album_name", notNull: "master_metadata_album_album_name IS NOT NULL"},
+	"track_uri":    {expr: "spotify_track_uri", notNull: "spotify_track_uri IS NOT NULL AND spotify_track_uri != ''"},
+	"username":     {expr: "username"},
+	"source":       {expr: "source"},
+	"country":      {expr: "conn_country", notNull: "conn_country IS NOT NULL AND conn_country != ''"},
+	"platform":     {expr: "platform"},
+	"device":       {expr: "device_class"},
+	"os":           {expr: "os_name"},
+	"reason_start": {expr: "reason_start"},
+	"reason_end":   {expr: "reason_end"},
+	"year":         {expr: IntervalYear.periodExpr()},
+	"month":        {expr: IntervalMonth.periodExpr()},
+	"week":         {expr: IntervalWeek.periodExpr()},
+	"day":          {expr: IntervalDay.periodExpr()},
+	"weekday":      {expr: "CAST(strftime('%w', " + tsExpr + ") AS INTEGER)"},
+	"hour":         {expr: "CAST(strftime('%H', " + tsExpr + ") AS INTEGER)"},
+}
+
+// metrics are the aggregates an Aggregation can compute, by name.
+var metrics = map[string]string{
+	"plays":        "COUNT(*)",
+	"play_time_ms": "SUM(ms_played)",
+	"skips":        "SUM(CASE WHEN skipped THEN 1 ELSE 0 END)",
+	"skip_rate":    "CAST(SUM(CASE WHEN skipped THEN 1 ELSE 0 END) AS FLOAT) / COUNT(*)",
+	"artists":      "COUNT(DISTINCT master_metadata_album_artist_name)",
+	"tracks":       "COUNT(DISTINCT NULLIF(spotify_track_uri, ''))",
+	"first_played": "MIN(" + tsExpr + ")",
+	"last_played":  "MAX(" + tsExpr + ")",
+}
+
+// Dimensions returns the names of the columns an Aggregation can group by.
+func Dimensions() []string {
+	return slices.Sorted(maps.Keys(dimensions))
+}
+
+// Metrics returns the names of the metrics an Aggregation can compute.
+func Metrics() []string {
+	return slices.Sorted(maps.Keys(metrics))
+}
+
+// Aggregation is a statistic described by data rather than code: the streams
+// matching a filter and a few conditions, grouped by dimensions, with
+// metrics computed per group. It lets users define their own statistics
+// without a new query method.
+type Aggregation struct {
+	// GroupBy are dimension names such as "artist" or "month", none gives a
+	// single row over every stream.
+	GroupBy []string
+	// Metrics are metric names such as "plays" or "play_time_ms".
+	Metrics []string
+
+	// Skipped, Shuffle and Offline keep the streams with the given flag
+	// only, nil values match both. The play time and the other conditions
+	// on streams are the ones of the filter given to Query.
+	Skipped *bool
+	Shuffle *bool
+	Offline *bool
+
+	// OrderBy is the dimension or metric to sort rows by, the first metric
+	// by default.
+	OrderBy   string
+	Ascending bool
+}
+
+// Query builds the SQL query of the aggregation over the streams matching
+// filter, along with the arguments of its placeholders. The columns of the
+// result are named after the dimensions and metrics. Up to 100 rows are
+// returned unless the filter sets a limit.
+func (a Aggregation) Query(filter Filter) (string, []any, error) {
+	if len(a.Metrics) == 0 {
+		return "", nil, ErrNoMetrics
+	}
+
+	var (
+		columns    []string
+		groups     []string
+		conditions []string
+	)
+
+	for _, name := range a.GroupBy {
+		d, ok := dimensions[name]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %q, expected one of %s", ErrUnknownDimension, name, strings.Join(Dimensions(), ", "))
+		}
+
+		columns = append(columns, d.expr+" AS "+name)
+		groups = append(groups, name)
+		if d.notNull != "" {
+			conditions = append(conditions, d.notNull)
+		}
+	}
+
+	for _, name := range a.Metrics {
+		expr, ok := metrics[name]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %q, expected one of %s", ErrUnknownMetric, name, strings.Join(Metrics(), ", "))
+		}
+
+		columns = append(columns, expr+" AS "+name)
+	}
+
+	orderBy := a.Metrics[0]
+	if a.OrderBy != "" {
+		if !slices.Contains(a.GroupBy, a.OrderBy) && !slices.Contains(a.Metrics, a.OrderBy) {
+			return "", nil, fmt.Errorf("%w: %q", ErrInvalidOrder, a.OrderBy)
+		}
+		orderBy = a.OrderBy
+	}
+
+	direction := "DESC"
+	if a.Ascending {
+		direction = "ASC"
+	}
+
+	flags := []struct {
+		column string
+		value  *bool
+	}{
+		{column: "skipped", value: a.Skipped},
+		{column: "shuffle", value: a.Shuffle},
+		{column: "offline", value: a.Offline},
+	}
+	for _, flag := range flags {
+		if flag.value != nil {
+			conditions = append(conditions, flag.column+" = "+strconv.FormatBool(*flag.value))
+		}
+	}
+
+	where, args := whereClause(filter, conditions...)
+
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM spotify_streams"
+	if where != "" {
+		query += " " + where
+	}
+	if len(groups) > 0 {
+		query += " GROUP BY " + strings.Join(groups, ", ")
+	}
+	query += " ORDER BY " + orderBy + " " + direction + " " + limitClause(filter, 100)
+
+	return query, args, nil
+}
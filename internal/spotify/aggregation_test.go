@@ -0,0 +1,90 @@
+package spotify_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestAggregation_Query(t *testing.T) {
+	t.Parallel()
+
+	skipped := true
+
+	tests := []struct {
+		name        string
+		aggregation spotify.Aggregation
+		filter      spotify.Filter
+		wantQuery   string
+		wantArgs    []any
+		wantErr     error
+	}{
+		{
+			name:        "totals without grouping",
+			aggregation: spotify.Aggregation{Metrics: []string{"plays", "play_time_ms"}},
+			wantQuery:   "SELECT COUNT(*) AS plays, SUM(ms_played) AS play_time_ms FROM spotify_streams ORDER BY plays DESC LIMIT 100",
+		},
+		{
+			name: "groups, filters and orders",
+			aggregation: spotify.Aggregation{
+				GroupBy:   []string{"artist", "year"},
+				Metrics:   []string{"skips"},
+				Skipped:   &skipped,
+				OrderBy:   "year",
+				Ascending: true,
+			},
+			filter: spotify.Filter{
+				Usernames: []string{"alice"},
+				From:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				MinPlayed: 30 * time.Second,
+				Limit:     5,
+			},
+			wantQuery: "SELECT master_metadata_album_artist_name AS artist, strftime('%Y', substr(ts, 1, 19)) AS year, " +
+				"SUM(CASE WHEN skipped THEN 1 ELSE 0 END) AS skips FROM spotify_streams " +
+				"WHERE master_metadata_album_artist_name IS NOT NULL AND skipped = true " +
+				"AND username IN (?) AND substr(ts, 1, 19) >= ? AND ms_played >= ? " +
+				"GROUP BY artist, year ORDER BY year ASC LIMIT 5",
+			wantArgs: []any{"alice", "2024-01-01 00:00:00", int64(30000)},
+		},
+		{
+			name:        "unknown dimension",
+			aggregation: spotify.Aggregation{GroupBy: []string{"genre"}, Metrics: []string{"plays"}},
+			wantErr:     spotify.ErrUnknownDimension,
+		},
+		{
+			name:        "unknown metric",
+			aggregation: spotify.Aggregation{Metrics: []string{"plays; DROP TABLE profiles"}},
+			wantErr:     spotify.ErrUnknownMetric,
+		},
+		{
+			name:        "order not selected",
+			aggregation: spotify.Aggregation{Metrics: []string{"plays"}, OrderBy: "skips"},
+			wantErr:     spotify.ErrInvalidOrder,
+		},
+		{
+			name:        "no metrics",
+			aggregation: spotify.Aggregation{GroupBy: []string{"artist"}},
+			wantErr:     spotify.ErrNoMetrics,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			query, args, err := tt.aggregation.Query(tt.filter)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantQuery, query)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package spotify
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+type AnomalyKind string
+
+const (
+	AnomalyConcurrentStreams AnomalyKind = "concurrent-streams"
+	AnomalyNewCountry        AnomalyKind = "new-country"
+	AnomalyUnusualHour       AnomalyKind = "unusual-hour"
+	AnomalyNewArtistBurst    AnomalyKind = "new-artist-burst"
+)
+
+// Anomaly is a suspicious event found in the streaming history. Score goes
+// from 0 (barely suspicious) to 1 (very suspicious).
+type Anomaly struct {
+	TS          time.Time   `json:"ts"`
+	Kind        AnomalyKind `json:"kind"`
+	Username    string      `json:"username"`
+	Description string      `json:"description"`
+	Score       float64     `json:"score"`
+	// Occurrences is the number of similar events merged into this one.
+	Occurrences int `json:"occurrences"`
+}
+
+// AnomalyOptions tunes the anomaly detectors.
+type AnomalyOptions struct {
+	// Warmup is the history needed for a user before new countries and new
+	// artists are considered suspicious.
+	Warmup time.Duration
+	// MinOverlap is the overlap two streams need to count as concurrent,
+	// which ignores crossfades and devices handing over playback.
+	MinOverlap time.Duration
+	// RareHourShare is the share of streams under which an hour of the day is
+	// considered unusual for a user.
+	RareHourShare float64
+	// MinNewArtists is the minimum number of never heard artists in a single
+	// day for it to be considered a burst.
+	MinNewArtists int
+}
+
+var DefaultAnomalyOptions = AnomalyOptions{
+	Warmup:        30 * 24 * time.Hour,
+	MinOverlap:    30 * time.Second,
+	RareHourShare: 0.01,
+	MinNewArtists: 5,
+}
+
+// DetectAnomalies flags suspicious activity in chronologically ordered
+// streams, per user. Similar anomalies within the same hour are merged into a
+// single one keeping the highest score. The result is sorted by descending
+// score.
+func DetectAnomalies(streams []ActivityStream, opts AnomalyOptions) []Anomaly {
+	byUser := make(map[string][]ActivityStream)
+	for i := range streams {
+		byUser[streams[i].Username] = append(byUser[streams[i].Username], streams[i])
+	}
+
+	var anomalies []Anomaly
+	for _, userStreams := range byUser {
+		anomalies = append(anomalies, detectConcurrentStreams(userStreams, opts)...)
+		anomalies = append(anomalies, detectNewCountries(userStreams, opts)...)
+		anomalies = append(anomalies, detectUnusualHours(userStreams, opts)...)
+		anomalies = append(anomalies, detectNewArtistBursts(userStreams, opts)...)
+	}
+
+	anomalies = mergeAnomalies(anomalies)
+
+	slices.SortStableFunc(anomalies, func(a, b Anomaly) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return a.TS.Compare(b.TS)
+	})
+
+	return anomalies
+}
+
+// detectConcurrentStreams flags streams of the same user overlapping in time
+// while coming from a different IP address or platform. Spotify records the
+// time a stream ended, so each stream spans [TS - MSPlayed, TS].
+func detectConcurrentStreams(streams []ActivityStream, opts AnomalyOptions) []Anomaly {
+	sorted := slices.Clone(streams)
+	slices.SortFunc(sorted, func(a, b ActivityStream) int {
+		return a.start().Compare(b.start())
+	})
+
+	var (
+		anomalies []Anomaly
+		active    []ActivityStream
+	)
+
+	for _, stream := range sorted {
+		start := stream.start()
+		active = slices.DeleteFunc(active, func(other ActivityStream) bool {
+			return !other.TS.After(start)
+		})
+
+		for _, other := range active {
+			differentIP := stream.IPAddr != "" && other.IPAddr != "" && stream.IPAddr != other.IPAddr
+			differentPlatform := stream.Platform != other.Platform
+			if !differentIP && !differentPlatform {
+				continue
+			}
+
+			overlap := minTime(stream.TS, other.TS).Sub(start)
+			if overlap < opts.MinOverlap {
+				continue
+			}
+
+			shortest := min(stream.TS.Sub(start), other.TS.Sub(other.start()))
+			if shortest <= 0 {
+				continue
+			}
+
+			score := 0.5
+			if differentIP {
+				score += 0.25
+			}
+			if differentPlatform {
+				score += 0.25
+			}
+			score *= math.Min(1, float64(overlap)/float64(shortest))
+			if stream.Country != other.Country {
+				score = 1
+			}
+
+			anomalies = append(anomalies, Anomaly{
+				TS:       start,
+				Kind:     AnomalyConcurrentStreams,
+				Username: stream.Username,
+				Description: fmt.Sprintf("%s from %s (%s) while %s from %s (%s)",
+					stream.Platform, stream.IPAddr, stream.Country,
+					other.Platform, other.IPAddr, other.Country),
+				Score:       score,
+				Occurrences: 1,
+			})
+		}
+
+		active = append(active, stream)
+	}
+
+	return anomalies
+}
+
+// detectNewCountries flags the first stream from each country once the user
+// has enough history. Countries the user ends up streaming a lot from (e.g.
+// after moving) score lower than one-off appearances.
+func detectNewCountries(streams []ActivityStream, opts AnomalyOptions) []Anomaly {
+	if len(streams) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for i := range streams {
+		counts[streams[i].Country]++
+	}
+
+	var anomalies []Anomaly
+	seen := make(map[string]bool)
+	first := streams[0].TS
+
+	for i := range streams {
+		country := streams[i].Country
+		if country == "" || country == unknownCountry || seen[country] {
+			continue
+		}
+		seen[country] = true
+
+		if streams[i].TS.Sub(first) < opts.Warmup {
+			continue
+		}
+
+		anomalies = append(anomalies, Anomaly{
+			TS:          streams[i].TS,
+			Kind:        AnomalyNewCountry,
+			Username:    streams[i].Username,
+			Description: fmt.Sprintf("First stream from %s (%d streams in total)", country, counts[country]),
+			Score:       1 - float64(counts[country])/float64(len(streams)),
+			Occurrences: 1,
+		})
+	}
+
+	return anomalies
+}
+
+// detectUnusualHours flags streams played at hours of the day the user
+// rarely listens at.
+func detectUnusualHours(streams []ActivityStream, opts AnomalyOptions) []Anomaly {
+	var hours [24]int
+	for i := range streams {
+		hours[streams[i].TS.Hour()]++
+	}
+
+	var anomalies []Anomaly
+	for i := range streams {
+		share := float64(hours[streams[i].TS.Hour()]) / float64(len(streams))
+		if share >= opts.RareHourShare {
+			continue
+		}
+
+		anomalies = append(anomalies, Anomaly{
+			TS:          streams[i].TS,
+			Kind:        AnomalyUnusualHour,
+			Username:    streams[i].Username,
+			Description: fmt.Sprintf("Listening at %02d:00, an hour with %.2f%% of all streams", streams[i].TS.Hour(), share*100),
+			Score:       1 - share/opts.RareHourShare,
+			Occurrences: 1,
+		})
+	}
+
+	return anomalies
+}
+
+// detectNewArtistBursts flags days with an unusually high number of artists
+// the user never listened to before.
+func detectNewArtistBursts(streams []ActivityStream, opts AnomalyOptions) []Anomaly {
+	if len(streams) == 0 {
+		return nil
+	}
+
+	type day struct {
+		first      time.Time
+		newArtists int
+	}
+
+	var days []day
+	heard := make(map[string]bool)
+	first := streams[0].TS
+
+	for i := range streams {
+		date := streams[i].TS.Truncate(24 * time.Hour)
+		if len(days) == 0 || !days[len(days)-1].first.Truncate(24*time.Hour).Equal(date) {
+			days = append(days, day{first: streams[i].TS})
+		}
+
+		artist := streams[i].Artist
+		if artist == "" || heard[artist] {
+			continue
+		}
+		heard[artist] = true
+
+		if streams[i].TS.Sub(first) >= opts.Warmup {
+			days[len(days)-1].newArtists++
+		}
+	}
+
+	// Days within the warmup are left out of the baseline since every artist
+	// is new at the beginning of the history.
+	days = slices.DeleteFunc(days, func(d day) bool {
+		return d.first.Sub(first) < opts.Warmup
+	})
+	if len(days) == 0 {
+		return nil
+	}
+
+	var sum, sumSquares float64
+	for _, d := range days {
+		sum += float64(d.newArtists)
+		sumSquares += float64(d.newArtists * d.newArtists)
+	}
+	mean := sum / float64(len(days))
+	stddev := math.Sqrt(math.Max(0, sumSquares/float64(len(days))-mean*mean))
+	threshold := math.Max(float64(opts.MinNewArtists), mean+3*stddev)
+
+	var anomalies []Anomaly
+	for _, d := range days {
+		count := float64(d.newArtists)
+		if count < threshold {
+			continue
+		}
+
+		anomalies = append(anomalies, Anomaly{
+			TS:          d.first,
+			Kind:        AnomalyNewArtistBurst,
+			Username:    streams[0].Username,
+			Description: fmt.Sprintf("%d never heard artists in a day (%.1f on average)", d.newArtists, mean),
+			Score:       (count - mean) / (count + stddev),
+			Occurrences: 1,
+		})
+	}
+
+	return anomalies
+}
+
+// mergeAnomalies merges anomalies of the same kind and user happening within
+// the same hour, keeping the description of the highest scored one.
+func mergeAnomalies(anomalies []Anomaly) []Anomaly {
+	type key struct {
+		hour     time.Time
+		kind     AnomalyKind
+		username string
+	}
+
+	merged := make([]Anomaly, 0, len(anomalies))
+	index := make(map[key]int)
+
+	for i := range anomalies {
+		k := key{
+			hour:     anomalies[i].TS.Truncate(time.Hour),
+			kind:     anomalies[i].Kind,
+			username: anomalies[i].Username,
+		}
+
+		j, ok := index[k]
+		if !ok {
+			index[k] = len(merged)
+			merged = append(merged, anomalies[i])
+			continue
+		}
+
+		ts := minTime(merged[j].TS, anomalies[i].TS)
+		occurrences := merged[j].Occurrences + anomalies[i].Occurrences
+		if anomalies[i].Score > merged[j].Score {
+			merged[j] = anomalies[i]
+		}
+		merged[j].TS = ts
+		merged[j].Occurrences = occurrences
+	}
+
+	return merged
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
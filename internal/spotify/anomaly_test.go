@@ -0,0 +1,124 @@
+package spotify_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+
+	// baseline is two months of one evening stream per day from home.
+	baseline := func() []spotify.ActivityStream {
+		streams := make([]spotify.ActivityStream, 0, 60)
+		for day := range 60 {
+			streams = append(streams, spotify.ActivityStream{
+				TS:       start.AddDate(0, 0, day),
+				Username: "user1",
+				Platform: "android",
+				IPAddr:   "10.0.0.1",
+				Country:  "DE",
+				Artist:   "Artist1",
+				MSPlayed: 180000,
+			})
+		}
+		return streams
+	}
+
+	kinds := func(anomalies []spotify.Anomaly) []spotify.AnomalyKind {
+		result := make([]spotify.AnomalyKind, 0, len(anomalies))
+		for i := range anomalies {
+			result = append(result, anomalies[i].Kind)
+		}
+		return result
+	}
+
+	t.Run("regular listening has no anomalies", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Empty(t, spotify.DetectAnomalies(baseline(), spotify.DefaultAnomalyOptions))
+	})
+
+	t.Run("flags concurrent streams from another IP and country", func(t *testing.T) {
+		t.Parallel()
+
+		streams := baseline()
+		streams = append(streams, spotify.ActivityStream{
+			TS:       streams[10].TS.Add(-time.Minute),
+			Username: "user1",
+			Platform: "ios",
+			IPAddr:   "203.0.113.7",
+			Country:  "BR",
+			Artist:   "Artist1",
+			MSPlayed: 120000,
+		})
+
+		anomalies := spotify.DetectAnomalies(streams, spotify.DefaultAnomalyOptions)
+		require.Equal(t, []spotify.AnomalyKind{spotify.AnomalyConcurrentStreams}, kinds(anomalies))
+		assert.InDelta(t, 1.0, anomalies[0].Score, 0.001)
+		assert.Contains(t, anomalies[0].Description, "(BR)")
+	})
+
+	t.Run("flags new countries after the warmup only", func(t *testing.T) {
+		t.Parallel()
+
+		streams := baseline()
+		streams[2].Country = "FR"
+		streams[45].Country = "US"
+
+		anomalies := spotify.DetectAnomalies(streams, spotify.DefaultAnomalyOptions)
+		require.Equal(t, []spotify.AnomalyKind{spotify.AnomalyNewCountry}, kinds(anomalies))
+		assert.Contains(t, anomalies[0].Description, "US")
+	})
+
+	t.Run("flags unusual hours", func(t *testing.T) {
+		t.Parallel()
+
+		streams := baseline()
+		for day := range 120 {
+			streams = append(streams, spotify.ActivityStream{
+				TS:       start.AddDate(0, 0, day%60).Add(time.Hour),
+				Username: "user1",
+				Platform: "android",
+				IPAddr:   "10.0.0.1",
+				Country:  "DE",
+				Artist:   "Artist1",
+				MSPlayed: 1000,
+			})
+		}
+		streams[30].TS = streams[30].TS.Add(-16 * time.Hour)
+
+		anomalies := spotify.DetectAnomalies(streams, spotify.DefaultAnomalyOptions)
+		require.Equal(t, []spotify.AnomalyKind{spotify.AnomalyUnusualHour}, kinds(anomalies))
+		assert.Equal(t, 4, anomalies[0].TS.Hour())
+	})
+
+	t.Run("flags bursts of new artists", func(t *testing.T) {
+		t.Parallel()
+
+		streams := baseline()
+		for i := range 8 {
+			streams = append(streams, spotify.ActivityStream{
+				TS:       streams[50].TS.Add(time.Duration(i+1) * 4 * time.Minute),
+				Username: "user1",
+				Platform: "android",
+				IPAddr:   "10.0.0.1",
+				Country:  "DE",
+				Artist:   fmt.Sprintf("New Artist %d", i),
+				MSPlayed: 180000,
+			})
+		}
+
+		anomalies := spotify.DetectAnomalies(streams, spotify.DefaultAnomalyOptions)
+		require.Equal(t, []spotify.AnomalyKind{spotify.AnomalyNewArtistBurst}, kinds(anomalies))
+		assert.Greater(t, anomalies[0].Score, 0.5)
+	})
+}
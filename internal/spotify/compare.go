@@ -0,0 +1,105 @@
+package spotify
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+)
+
+// UserComparison compares the artists listened to by two users.
+type UserComparison struct {
+	UserA string `json:"user_a"`
+	UserB string `json:"user_b"`
+	// Shared holds the artists both users listened to, by combined play time.
+	Shared []SharedArtist `json:"shared"`
+	// OnlyA and OnlyB hold the artists only one of the users listened to, by
+	// play time.
+	OnlyA []UserArtistStats `json:"only_a"`
+	OnlyB []UserArtistStats `json:"only_b"`
+	// Jaccard is the share of artists listened to by both users among all
+	// artists listened to by either of them.
+	Jaccard float64 `json:"jaccard"`
+	// Cosine is the cosine similarity of the users' play time per artist,
+	// so artists they both listen to a lot weigh more than one-off plays.
+	Cosine float64 `json:"cosine"`
+}
+
+type SharedArtist struct {
+	FirstPlayedA time.Time `json:"first_played_a"`
+	FirstPlayedB time.Time `json:"first_played_b"`
+	Artist       string    `json:"artist"`
+	// DiscoveredBy is the user who listened to the artist first.
+	DiscoveredBy    string `json:"discovered_by"`
+	TotalPlayTimeA  int64  `json:"total_play_time_a_ms"`
+	TotalPlayTimeB  int64  `json:"total_play_time_b_ms"`
+	TotalPlayTimeMS int64  `json:"total_play_time_ms"`
+}
+
+// CompareUsers compares the per artist play time of userA and userB.
+func CompareUsers(userA, userB string, stats []UserArtistStats) UserComparison {
+	comparison := UserComparison{UserA: userA, UserB: userB}
+
+	byArtistA := make(map[string]UserArtistStats)
+	byArtistB := make(map[string]UserArtistStats)
+	for i := range stats {
+		switch stats[i].Username {
+		case userA:
+			byArtistA[stats[i].Artist] = stats[i]
+		case userB:
+			byArtistB[stats[i].Artist] = stats[i]
+		}
+	}
+
+	var dot, normA, normB float64
+	for artist, a := range byArtistA {
+		normA += float64(a.TotalPlayTimeMS) * float64(a.TotalPlayTimeMS)
+
+		b, ok := byArtistB[artist]
+		if !ok {
+			comparison.OnlyA = append(comparison.OnlyA, a)
+			continue
+		}
+
+		discoveredBy := userA
+		if b.FirstPlayed.Before(a.FirstPlayed.Time) {
+			discoveredBy = userB
+		}
+
+		dot += float64(a.TotalPlayTimeMS) * float64(b.TotalPlayTimeMS)
+		comparison.Shared = append(comparison.Shared, SharedArtist{
+			FirstPlayedA:    a.FirstPlayed.Time,
+			FirstPlayedB:    b.FirstPlayed.Time,
+			Artist:          artist,
+			DiscoveredBy:    discoveredBy,
+			TotalPlayTimeA:  a.TotalPlayTimeMS,
+			TotalPlayTimeB:  b.TotalPlayTimeMS,
+			TotalPlayTimeMS: a.TotalPlayTimeMS + b.TotalPlayTimeMS,
+		})
+	}
+
+	for artist, b := range byArtistB {
+		normB += float64(b.TotalPlayTimeMS) * float64(b.TotalPlayTimeMS)
+		if _, ok := byArtistA[artist]; !ok {
+			comparison.OnlyB = append(comparison.OnlyB, b)
+		}
+	}
+
+	if union := len(byArtistA) + len(byArtistB) - len(comparison.Shared); union > 0 {
+		comparison.Jaccard = float64(len(comparison.Shared)) / float64(union)
+	}
+	if normA > 0 && normB > 0 {
+		comparison.Cosine = dot / (math.Sqrt(normA) * math.Sqrt(normB))
+	}
+
+	slices.SortFunc(comparison.Shared, func(a, b SharedArtist) int {
+		return cmp.Or(cmp.Compare(b.TotalPlayTimeMS, a.TotalPlayTimeMS), cmp.Compare(a.Artist, b.Artist))
+	})
+	sortByPlayTime := func(a, b UserArtistStats) int {
+		return cmp.Or(cmp.Compare(b.TotalPlayTimeMS, a.TotalPlayTimeMS), cmp.Compare(a.Artist, b.Artist))
+	}
+	slices.SortFunc(comparison.OnlyA, sortByPlayTime)
+	slices.SortFunc(comparison.OnlyB, sortByPlayTime)
+
+	return comparison
+}
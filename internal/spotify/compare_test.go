@@ -0,0 +1,70 @@
+package spotify_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestCompareUsers(t *testing.T) {
+	t.Parallel()
+
+	day := func(d int) spotify.Timestamp {
+		return spotify.Timestamp{Time: time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)}
+	}
+
+	stats := []spotify.UserArtistStats{
+		{Username: "a", Artist: "Shared1", TotalPlayTimeMS: 3000, FirstPlayed: day(5)},
+		{Username: "a", Artist: "Shared2", TotalPlayTimeMS: 4000, FirstPlayed: day(1)},
+		{Username: "a", Artist: "OnlyA", TotalPlayTimeMS: 1000, FirstPlayed: day(1)},
+		{Username: "b", Artist: "Shared1", TotalPlayTimeMS: 6000, FirstPlayed: day(2)},
+		{Username: "b", Artist: "Shared2", TotalPlayTimeMS: 1000, FirstPlayed: day(3)},
+		{Username: "b", Artist: "OnlyB1", TotalPlayTimeMS: 500, FirstPlayed: day(1)},
+		{Username: "b", Artist: "OnlyB2", TotalPlayTimeMS: 700, FirstPlayed: day(1)},
+		{Username: "c", Artist: "Ignored", TotalPlayTimeMS: 9000, FirstPlayed: day(1)},
+	}
+
+	got := spotify.CompareUsers("a", "b", stats)
+
+	assert.Equal(t, []spotify.SharedArtist{
+		{
+			FirstPlayedA: day(5).Time, FirstPlayedB: day(2).Time, Artist: "Shared1", DiscoveredBy: "b",
+			TotalPlayTimeA: 3000, TotalPlayTimeB: 6000, TotalPlayTimeMS: 9000,
+		},
+		{
+			FirstPlayedA: day(1).Time, FirstPlayedB: day(3).Time, Artist: "Shared2", DiscoveredBy: "a",
+			TotalPlayTimeA: 4000, TotalPlayTimeB: 1000, TotalPlayTimeMS: 5000,
+		},
+	}, got.Shared)
+
+	assert.Equal(t, []string{"OnlyA"}, artists(got.OnlyA))
+	assert.Equal(t, []string{"OnlyB2", "OnlyB1"}, artists(got.OnlyB))
+	assert.InDelta(t, 2.0/5.0, got.Jaccard, 1e-9)
+
+	// a = (3000, 4000, 1000, 0, 0), b = (6000, 1000, 0, 500, 700)
+	assert.InDelta(t, 22e6/(5099.0195*6143.2891), got.Cosine, 1e-4)
+}
+
+func TestCompareUsers_NoOverlap(t *testing.T) {
+	t.Parallel()
+
+	got := spotify.CompareUsers("a", "b", []spotify.UserArtistStats{
+		{Username: "a", Artist: "Artist1", TotalPlayTimeMS: 1000},
+		{Username: "b", Artist: "Artist2", TotalPlayTimeMS: 1000},
+	})
+
+	assert.Empty(t, got.Shared)
+	assert.Zero(t, got.Jaccard)
+	assert.Zero(t, got.Cosine)
+}
+
+func artists(stats []spotify.UserArtistStats) []string {
+	result := make([]string, 0, len(stats))
+	for i := range stats {
+		result = append(result, stats[i].Artist)
+	}
+	return result
+}
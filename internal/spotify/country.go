@@ -0,0 +1,62 @@
+package spotify
+
+import "time"
+
+// unknownCountry is the code Spotify uses when the connection country could
+// not be determined.
+const unknownCountry = "ZZ"
+
+// CountryPeriod is a span of time during which every stream came from the
+// same connection country.
+type CountryPeriod struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Country         string    `json:"country"`
+	StreamCount     int       `json:"stream_count"`
+	TotalPlayTimeMS int64     `json:"total_play_time_ms"`
+}
+
+// BuildCountryTimeline groups chronologically ordered streams into periods
+// spent streaming from each country. Periods with fewer than minStreams
+// streams are treated as noise (e.g. a VPN session or a bad geolocation) and
+// dropped before merging the surrounding periods together.
+func BuildCountryTimeline(streams []CountryStream, minStreams int) []CountryPeriod {
+	var periods []CountryPeriod
+	for _, stream := range streams {
+		if stream.Country == "" || stream.Country == unknownCountry {
+			continue
+		}
+
+		periods = appendCountryPeriod(periods, CountryPeriod{
+			Start:           stream.TS,
+			End:             stream.TS,
+			Country:         stream.Country,
+			StreamCount:     1,
+			TotalPlayTimeMS: stream.MSPlayed,
+		})
+	}
+
+	timeline := make([]CountryPeriod, 0, len(periods))
+	for _, period := range periods {
+		if period.StreamCount >= minStreams {
+			timeline = appendCountryPeriod(timeline, period)
+		}
+	}
+
+	return timeline
+}
+
+// appendCountryPeriod appends period to periods, extending the last period
+// instead when both are in the same country.
+func appendCountryPeriod(periods []CountryPeriod, period CountryPeriod) []CountryPeriod {
+	last := len(periods) - 1
+	if last < 0 || periods[last].Country != period.Country {
+		return append(periods, period)
+	}
+
+	periods[last].End = period.End
+	periods[last].StreamCount += period.StreamCount
+	periods[last].TotalPlayTimeMS += period.TotalPlayTimeMS
+
+	return periods
+}
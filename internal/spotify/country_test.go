@@ -0,0 +1,80 @@
+package spotify_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestBuildCountryTimeline(t *testing.T) {
+	t.Parallel()
+
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		streams    []spotify.CountryStream
+		minStreams int
+		want       []spotify.CountryPeriod
+	}{
+		{
+			name: "groups consecutive streams by country",
+			streams: []spotify.CountryStream{
+				{TS: day(1), Country: "DE", MSPlayed: 1000},
+				{TS: day(2), Country: "DE", MSPlayed: 1000},
+				{TS: day(3), Country: "FR", MSPlayed: 2000},
+				{TS: day(4), Country: "DE", MSPlayed: 3000},
+			},
+			minStreams: 1,
+			want: []spotify.CountryPeriod{
+				{Start: day(1), End: day(2), Country: "DE", StreamCount: 2, TotalPlayTimeMS: 2000},
+				{Start: day(3), End: day(3), Country: "FR", StreamCount: 1, TotalPlayTimeMS: 2000},
+				{Start: day(4), End: day(4), Country: "DE", StreamCount: 1, TotalPlayTimeMS: 3000},
+			},
+		},
+		{
+			name: "drops short periods and merges their neighbours",
+			streams: []spotify.CountryStream{
+				{TS: day(1), Country: "DE", MSPlayed: 1000},
+				{TS: day(2), Country: "DE", MSPlayed: 1000},
+				{TS: day(3), Country: "US", MSPlayed: 5000},
+				{TS: day(4), Country: "DE", MSPlayed: 1000},
+				{TS: day(5), Country: "DE", MSPlayed: 1000},
+			},
+			minStreams: 2,
+			want: []spotify.CountryPeriod{
+				{Start: day(1), End: day(5), Country: "DE", StreamCount: 4, TotalPlayTimeMS: 4000},
+			},
+		},
+		{
+			name: "ignores unknown countries",
+			streams: []spotify.CountryStream{
+				{TS: day(1), Country: "DE", MSPlayed: 1000},
+				{TS: day(2), Country: "ZZ", MSPlayed: 1000},
+				{TS: day(3), Country: "DE", MSPlayed: 1000},
+			},
+			minStreams: 1,
+			want: []spotify.CountryPeriod{
+				{Start: day(1), End: day(3), Country: "DE", StreamCount: 2, TotalPlayTimeMS: 2000},
+			},
+		},
+		{
+			name:       "empty history",
+			minStreams: 1,
+			want:       []spotify.CountryPeriod{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, spotify.BuildCountryTimeline(tt.streams, tt.minStreams))
+		})
+	}
+}
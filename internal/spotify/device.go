@@ -0,0 +1,263 @@
+package spotify
+
+import (
+	"strings"
+	"unicode"
+)
+
+type DeviceClass string
+
+const (
+	DeviceClassPhone   DeviceClass = "phone"
+	DeviceClassTablet  DeviceClass = "tablet"
+	DeviceClassDesktop DeviceClass = "desktop"
+	DeviceClassWeb     DeviceClass = "web"
+	DeviceClassTV      DeviceClass = "tv"
+	DeviceClassSpeaker DeviceClass = "speaker"
+	DeviceClassConsole DeviceClass = "console"
+	DeviceClassCar     DeviceClass = "car"
+	DeviceClassWatch   DeviceClass = "watch"
+	DeviceClassUnknown DeviceClass = "unknown"
+)
+
+// Device is the normalized form of the raw platform and user agent strings
+// found in the streaming history.
+type Device struct {
+	Class        DeviceClass
+	OS           string
+	OSVersion    string
+	Manufacturer string
+}
+
+// ParseDevice normalizes the platform and user agent strings of a stream into
+// a Device. The platform is preferred since it is the most descriptive field,
+// the user agent is only used to fill in what the platform couldn't tell.
+func ParseDevice(platform, userAgent string) Device {
+	device := parsePlatform(platform)
+	if device.Class != DeviceClassUnknown && device.OSVersion != "" {
+		return device
+	}
+
+	fallback := parseUserAgent(userAgent)
+	if device.Class == DeviceClassUnknown {
+		device.Class = fallback.Class
+	}
+	if device.OS == "" {
+		device.OS = fallback.OS
+	}
+	if device.OSVersion == "" && device.OS == fallback.OS {
+		device.OSVersion = fallback.OSVersion
+	}
+	if device.Manufacturer == "" {
+		device.Manufacturer = fallback.Manufacturer
+	}
+
+	return device
+}
+
+// parsePlatform handles both the verbose platform strings of older exports,
+// e.g. "Android OS 13 API 33 (Samsung, SM-S911B)" or "iOS 14.4 (iPhone12,1)",
+// and the short ones used by newer exports, e.g. "android" or "osx".
+func parsePlatform(platform string) Device {
+	p := strings.ToLower(strings.TrimSpace(platform))
+
+	switch {
+	case p == "" || p == "not_applicable" || p == "unknown":
+		return Device{Class: DeviceClassUnknown}
+	case strings.HasPrefix(p, "partner "):
+		return parsePartner(strings.TrimSpace(platform[len("partner "):]))
+	case strings.HasPrefix(p, "web_player"):
+		device := parseOS(strings.TrimSpace(strings.SplitN(p[len("web_player"):], ";", 2)[0]))
+		device.Class = DeviceClassWeb
+		return device
+	case strings.HasPrefix(p, "ps4"), strings.HasPrefix(p, "ps5"), strings.HasPrefix(p, "playstation"):
+		return Device{Class: DeviceClassConsole, OS: "PlayStation", Manufacturer: "Sony"}
+	case strings.HasPrefix(p, "xbox"):
+		return Device{Class: DeviceClassConsole, OS: "Xbox", Manufacturer: "Microsoft"}
+	default:
+		return parseOS(platform)
+	}
+}
+
+// parseOS recognizes operating system descriptions such as
+// "windows 10 (10.0.19045; x64)" or "OS X 10.15.7 [x86 8]".
+func parseOS(s string) Device {
+	p := strings.ToLower(strings.TrimSpace(s))
+	fields := strings.Fields(p)
+	details := parenthesized(s)
+
+	switch {
+	case strings.HasPrefix(p, "android"):
+		device := Device{Class: DeviceClassPhone, OS: "Android"}
+		if strings.HasPrefix(p, "android-tablet") {
+			device.Class = DeviceClassTablet
+		}
+		device.OSVersion = fieldAfter(fields, "os")
+		if manufacturer, _, ok := strings.Cut(details, ","); ok {
+			device.Manufacturer = normalizeManufacturer(manufacturer)
+		}
+		if strings.Contains(p, "wear os") || strings.Contains(p, "wearos") {
+			device.Class = DeviceClassWatch
+		}
+		return device
+	case strings.HasPrefix(p, "ios"), strings.HasPrefix(p, "ipados"):
+		device := Device{Class: DeviceClassPhone, OS: "iOS", Manufacturer: "Apple"}
+		device.OSVersion = fieldAt(fields, 1)
+		device.Class = appleModelClass(details, device.Class)
+		return device
+	case strings.HasPrefix(p, "watchos"):
+		return Device{Class: DeviceClassWatch, OS: "watchOS", OSVersion: fieldAt(fields, 1), Manufacturer: "Apple"}
+	case strings.HasPrefix(p, "tvos"):
+		return Device{Class: DeviceClassTV, OS: "tvOS", OSVersion: fieldAt(fields, 1), Manufacturer: "Apple"}
+	case strings.HasPrefix(p, "os x"):
+		return Device{Class: DeviceClassDesktop, OS: "macOS", OSVersion: fieldAt(fields, 2), Manufacturer: "Apple"}
+	case strings.HasPrefix(p, "osx"), strings.HasPrefix(p, "macos"):
+		return Device{Class: DeviceClassDesktop, OS: "macOS", OSVersion: fieldAt(fields, 1), Manufacturer: "Apple"}
+	case strings.HasPrefix(p, "windows"):
+		device := Device{Class: DeviceClassDesktop, OS: "Windows", OSVersion: fieldAt(fields, 1)}
+		if version, _, _ := strings.Cut(details, ";"); version != "" {
+			device.OSVersion = strings.TrimSpace(version)
+		}
+		return device
+	case strings.HasPrefix(p, "linux"):
+		return Device{Class: DeviceClassDesktop, OS: "Linux"}
+	case strings.HasPrefix(p, "chrome os"), strings.HasPrefix(p, "chromeos"):
+		return Device{Class: DeviceClassDesktop, OS: "ChromeOS"}
+	default:
+		return Device{Class: DeviceClassUnknown}
+	}
+}
+
+// parsePartner handles Spotify Connect partner devices, which are reported as
+// "Partner <integration> <Brand>;<Model>;..." e.g.
+// "Partner sonos_one Sonos;One;" or "Partner android_tv Sony;BRAVIA 4K;".
+func parsePartner(s string) Device {
+	integration, rest, _ := strings.Cut(s, " ")
+	brand, _, _ := strings.Cut(rest, ";")
+	if brand == "" || strings.Contains(brand, "_") {
+		// Some integrations, e.g. "google cast_tv", report the brand first.
+		brand, _, _ = strings.Cut(integration, "_")
+	}
+
+	device := Device{Class: DeviceClassSpeaker, Manufacturer: normalizeManufacturer(brand)}
+
+	lower := strings.ToLower(s)
+	switch {
+	case strings.Contains(lower, "android_auto"), strings.Contains(lower, "car"):
+		device.Class = DeviceClassCar
+	case strings.Contains(lower, "tv"), strings.Contains(lower, "chromecast"), strings.Contains(lower, "roku"):
+		device.Class = DeviceClassTV
+	case strings.Contains(lower, "playstation"), strings.Contains(lower, "xbox"):
+		device.Class = DeviceClassConsole
+	case strings.Contains(lower, "watch"), strings.Contains(lower, "wear"):
+		device.Class = DeviceClassWatch
+	}
+
+	return device
+}
+
+// parseUserAgent handles the client user agents found in the streaming
+// history, e.g. "Spotify/8.8.0 Android/33 (SM-S911B)" or
+// "Spotify/8.8.0 iOS/16.1 (iPhone15,2)", as well as plain platform strings.
+func parseUserAgent(userAgent string) Device {
+	ua := strings.TrimSpace(userAgent)
+	if ua == "" || strings.EqualFold(ua, "unknown") {
+		return Device{Class: DeviceClassUnknown}
+	}
+
+	lower := strings.ToLower(ua)
+	if !strings.HasPrefix(lower, "spotify/") {
+		if strings.HasPrefix(lower, "mozilla/") {
+			return Device{Class: DeviceClassWeb}
+		}
+		return parsePlatform(ua)
+	}
+
+	fields := strings.Fields(ua)
+	if len(fields) < 2 {
+		return Device{Class: DeviceClassUnknown}
+	}
+
+	osName, osVersion, _ := strings.Cut(fields[1], "/")
+	details := parenthesized(ua)
+	lowerOS := strings.ToLower(osName)
+
+	switch {
+	case strings.HasPrefix(lowerOS, "android"):
+		// Android user agents carry the API level rather than the OS version.
+		device := Device{Class: DeviceClassPhone, OS: "Android"}
+		if osVersion != "" {
+			device.OSVersion = "API " + osVersion
+		}
+		return device
+	case lowerOS == "ios":
+		return Device{Class: appleModelClass(details, DeviceClassPhone), OS: "iOS", OSVersion: osVersion, Manufacturer: "Apple"}
+	case strings.HasPrefix(lowerOS, "win"):
+		return Device{Class: DeviceClassDesktop, OS: "Windows"}
+	case strings.HasPrefix(lowerOS, "osx"), strings.HasPrefix(lowerOS, "macos"):
+		return Device{Class: DeviceClassDesktop, OS: "macOS", Manufacturer: "Apple"}
+	case strings.HasPrefix(lowerOS, "linux"):
+		return Device{Class: DeviceClassDesktop, OS: "Linux"}
+	default:
+		return Device{Class: DeviceClassUnknown}
+	}
+}
+
+// appleModelClass derives the device class from an Apple model identifier such
+// as "iPhone12,1" or "iPad6,11", falling back to def for unknown models.
+func appleModelClass(model string, def DeviceClass) DeviceClass {
+	model = strings.ToLower(model)
+	switch {
+	case strings.HasPrefix(model, "ipad"):
+		return DeviceClassTablet
+	case strings.HasPrefix(model, "watch"):
+		return DeviceClassWatch
+	case strings.HasPrefix(model, "appletv"):
+		return DeviceClassTV
+	case strings.HasPrefix(model, "iphone"), strings.HasPrefix(model, "ipod"):
+		return DeviceClassPhone
+	default:
+		return def
+	}
+}
+
+// parenthesized returns the content of the first pair of parentheses in s.
+func parenthesized(s string) string {
+	_, after, ok := strings.Cut(s, "(")
+	if !ok {
+		return ""
+	}
+	content, _, _ := strings.Cut(after, ")")
+	return strings.TrimSpace(content)
+}
+
+// fieldAfter returns the field following the first occurrence of key.
+func fieldAfter(fields []string, key string) string {
+	for i, field := range fields {
+		if field == key {
+			return fieldAt(fields, i+1)
+		}
+	}
+	return ""
+}
+
+// fieldAt returns the i-th field if it looks like a version number.
+func fieldAt(fields []string, i int) string {
+	if i < 0 || i >= len(fields) {
+		return ""
+	}
+	if field := fields[i]; field != "" && unicode.IsDigit(rune(field[0])) {
+		return field
+	}
+	return ""
+}
+
+// normalizeManufacturer capitalizes lowercase manufacturer names so "samsung"
+// and "Samsung" are grouped together, while keeping names like "OnePlus" as is.
+func normalizeManufacturer(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ToLower(s) != s {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,90 @@
+package spotify_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestParseDevice(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		platform  string
+		userAgent string
+		want      spotify.Device
+	}{
+		{
+			name:     "android phone",
+			platform: "Android OS 13 API 33 (Samsung, SM-S911B)",
+			want:     spotify.Device{Class: spotify.DeviceClassPhone, OS: "Android", OSVersion: "13", Manufacturer: "Samsung"},
+		},
+		{
+			name:     "android tablet with lowercase manufacturer",
+			platform: "Android-tablet OS 10 API 29 (samsung, SM-T510)",
+			want:     spotify.Device{Class: spotify.DeviceClassTablet, OS: "Android", OSVersion: "10", Manufacturer: "Samsung"},
+		},
+		{
+			name:     "iphone",
+			platform: "iOS 14.4 (iPhone12,1)",
+			want:     spotify.Device{Class: spotify.DeviceClassPhone, OS: "iOS", OSVersion: "14.4", Manufacturer: "Apple"},
+		},
+		{
+			name:     "ipad",
+			platform: "iOS 12.1.4 (iPad6,11)",
+			want:     spotify.Device{Class: spotify.DeviceClassTablet, OS: "iOS", OSVersion: "12.1.4", Manufacturer: "Apple"},
+		},
+		{
+			name:     "windows desktop",
+			platform: "windows 10 (10.0.19045; x64)",
+			want:     spotify.Device{Class: spotify.DeviceClassDesktop, OS: "Windows", OSVersion: "10.0.19045"},
+		},
+		{
+			name:     "macos desktop",
+			platform: "OS X 10.15.7 [x86 8]",
+			want:     spotify.Device{Class: spotify.DeviceClassDesktop, OS: "macOS", OSVersion: "10.15.7", Manufacturer: "Apple"},
+		},
+		{
+			name:     "web player",
+			platform: "web_player windows 10;chrome 87.0.4280.88;desktop",
+			want:     spotify.Device{Class: spotify.DeviceClassWeb, OS: "Windows", OSVersion: "10"},
+		},
+		{
+			name:     "cast tv",
+			platform: "Partner google cast_tv;Chromecast;;",
+			want:     spotify.Device{Class: spotify.DeviceClassTV, Manufacturer: "Google"},
+		},
+		{
+			name:     "smart speaker",
+			platform: "Partner sonos_one Sonos;One;;",
+			want:     spotify.Device{Class: spotify.DeviceClassSpeaker, Manufacturer: "Sonos"},
+		},
+		{
+			name:      "short platform completed by user agent",
+			platform:  "android",
+			userAgent: "Spotify/8.8.0 Android/33 (SM-S911B)",
+			want:      spotify.Device{Class: spotify.DeviceClassPhone, OS: "Android", OSVersion: "API 33"},
+		},
+		{
+			name:      "unknown platform falls back to user agent",
+			platform:  "not_applicable",
+			userAgent: "Spotify/8.8.0 iOS/16.1 (iPhone15,2)",
+			want:      spotify.Device{Class: spotify.DeviceClassPhone, OS: "iOS", OSVersion: "16.1", Manufacturer: "Apple"},
+		},
+		{
+			name: "empty",
+			want: spotify.Device{Class: spotify.DeviceClassUnknown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, spotify.ParseDevice(tt.platform, tt.userAgent))
+		})
+	}
+}
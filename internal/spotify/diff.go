@@ -0,0 +1,117 @@
+package spotify
+
+// RankedItem is an entry of a top list, identified by Key.
+type RankedItem struct {
+	Key   string
+	Label string
+	Value int64
+}
+
+// PeriodSummary holds the totals and ranked top lists of a period, as
+// compared by DiffRanks.
+type PeriodSummary struct {
+	Totals  Totals
+	Artists []RankedItem
+	Tracks  []RankedItem
+	Albums  []RankedItem
+}
+
+// RankChange describes how an entry of a top list moved between two periods.
+// A zero rank means the entry wasn't part of the list in that period.
+type RankChange struct {
+	Label       string `json:"label"`
+	RankBefore  int    `json:"rank_before"`
+	RankAfter   int    `json:"rank_after"`
+	ValueBefore int64  `json:"value_before"`
+	ValueAfter  int64  `json:"value_after"`
+}
+
+// IsNew reports whether the entry entered the list in the second period.
+func (c RankChange) IsNew() bool {
+	return c.RankBefore == 0
+}
+
+// IsDropped reports whether the entry left the list in the second period.
+func (c RankChange) IsDropped() bool {
+	return c.RankAfter == 0
+}
+
+// Delta returns the number of ranks gained, negative when the entry fell.
+func (c RankChange) Delta() int {
+	return c.RankBefore - c.RankAfter
+}
+
+// DiffRanks compares two top lists ordered by rank. Entries of the second list
+// come first in their order, followed by the dropped entries of the first.
+func DiffRanks(before, after []RankedItem) []RankChange {
+	beforeRanks := make(map[string]int, len(before))
+	for i := range before {
+		beforeRanks[before[i].Key] = i + 1
+	}
+
+	afterKeys := make(map[string]bool, len(after))
+	changes := make([]RankChange, 0, len(before)+len(after))
+
+	for i := range after {
+		afterKeys[after[i].Key] = true
+
+		change := RankChange{
+			Label:      after[i].Label,
+			RankAfter:  i + 1,
+			ValueAfter: after[i].Value,
+		}
+		if rank, ok := beforeRanks[after[i].Key]; ok {
+			change.RankBefore = rank
+			change.ValueBefore = before[rank-1].Value
+		}
+		changes = append(changes, change)
+	}
+
+	dropped := make([]RankChange, 0, len(before))
+	for i := range before {
+		if !afterKeys[before[i].Key] {
+			dropped = append(dropped, RankChange{
+				Label:       before[i].Label,
+				RankBefore:  i + 1,
+				ValueBefore: before[i].Value,
+			})
+		}
+	}
+
+	return append(changes, dropped...)
+}
+
+// RankArtists converts top artists to ranked items, ranked by play time.
+func RankArtists(artists []ArtistStats) []RankedItem {
+	items := make([]RankedItem, 0, len(artists))
+	for _, artist := range artists {
+		items = append(items, RankedItem{Key: artist.Artist, Label: artist.Artist, Value: artist.TotalPlayTime})
+	}
+	return items
+}
+
+// RankTracks converts top tracks to ranked items, ranked by play time.
+func RankTracks(tracks []TrackStats) []RankedItem {
+	items := make([]RankedItem, 0, len(tracks))
+	for _, track := range tracks {
+		items = append(items, RankedItem{
+			Key:   track.Track + "\x00" + track.Artist,
+			Label: track.Track + " - " + track.Artist,
+			Value: track.TotalPlayTimeMS,
+		})
+	}
+	return items
+}
+
+// RankAlbums converts top albums to ranked items, ranked by play count.
+func RankAlbums(albums []AlbumStats) []RankedItem {
+	items := make([]RankedItem, 0, len(albums))
+	for _, album := range albums {
+		items = append(items, RankedItem{
+			Key:   album.Album + "\x00" + album.Artist,
+			Label: album.Album + " - " + album.Artist,
+			Value: album.Count,
+		})
+	}
+	return items
+}
@@ -0,0 +1,39 @@
+package spotify_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestDiffRanks(t *testing.T) {
+	t.Parallel()
+
+	before := []spotify.RankedItem{
+		{Key: "a", Label: "A", Value: 30},
+		{Key: "b", Label: "B", Value: 20},
+		{Key: "c", Label: "C", Value: 10},
+	}
+	after := []spotify.RankedItem{
+		{Key: "c", Label: "C", Value: 50},
+		{Key: "d", Label: "D", Value: 40},
+		{Key: "a", Label: "A", Value: 5},
+	}
+
+	got := spotify.DiffRanks(before, after)
+
+	require.Equal(t, []spotify.RankChange{
+		{Label: "C", RankBefore: 3, RankAfter: 1, ValueBefore: 10, ValueAfter: 50},
+		{Label: "D", RankBefore: 0, RankAfter: 2, ValueBefore: 0, ValueAfter: 40},
+		{Label: "A", RankBefore: 1, RankAfter: 3, ValueBefore: 30, ValueAfter: 5},
+		{Label: "B", RankBefore: 2, RankAfter: 0, ValueBefore: 20, ValueAfter: 0},
+	}, got)
+
+	assert.Equal(t, 2, got[0].Delta())
+	assert.True(t, got[1].IsNew())
+	assert.Equal(t, -2, got[2].Delta())
+	assert.True(t, got[3].IsDropped())
+}
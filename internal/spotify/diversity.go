@@ -0,0 +1,118 @@
+package spotify
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// Diversity measures how evenly listening time is spread in a period.
+type Diversity struct {
+	Period string `json:"period"`
+	// Count is the number of distinct entries listened to.
+	Count int `json:"count"`
+	// Entropy is the Shannon entropy of the play time distribution, in bits.
+	// It grows as listening is spread over more entries.
+	Entropy float64 `json:"entropy"`
+	// Gini is the Gini coefficient of the play time distribution, from 0 when
+	// every entry is played as much to 1 when a single entry takes it all.
+	Gini float64 `json:"gini"`
+	// Top10Share is the share of play time of the 10 most played entries.
+	Top10Share float64 `json:"top10_share"`
+}
+
+// ComputeDiversity computes the diversity of each period of play times
+// ordered by period.
+func ComputeDiversity(playTimes []PeriodPlayTime) []Diversity {
+	var (
+		diversities []Diversity
+		values      []int64
+	)
+
+	flush := func(period string) {
+		if len(values) == 0 {
+			return
+		}
+		diversities = append(diversities, Diversity{
+			Period:     period,
+			Count:      len(values),
+			Entropy:    ShannonEntropy(values),
+			Gini:       Gini(values),
+			Top10Share: TopShare(values, 10),
+		})
+		values = values[:0]
+	}
+
+	for i := range playTimes {
+		if i > 0 && playTimes[i].Period != playTimes[i-1].Period {
+			flush(playTimes[i-1].Period)
+		}
+		if playTimes[i].TotalPlayTimeMS > 0 {
+			values = append(values, playTimes[i].TotalPlayTimeMS)
+		}
+	}
+	if len(playTimes) > 0 {
+		flush(playTimes[len(playTimes)-1].Period)
+	}
+
+	return diversities
+}
+
+// ShannonEntropy returns the entropy in bits of the distribution of values.
+func ShannonEntropy(values []int64) float64 {
+	total := sum(values)
+	if total == 0 {
+		return 0
+	}
+
+	var entropy float64
+	for _, value := range values {
+		if value <= 0 {
+			continue
+		}
+		p := float64(value) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+// Gini returns the Gini coefficient of values.
+func Gini(values []int64) float64 {
+	total := sum(values)
+	if total == 0 || len(values) < 2 {
+		return 0
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	var weighted float64
+	for i, value := range sorted {
+		weighted += float64(i+1) * float64(value)
+	}
+
+	n := float64(len(sorted))
+	return 2*weighted/(n*float64(total)) - (n+1)/n
+}
+
+// TopShare returns the share of the n largest values in the total.
+func TopShare(values []int64, n int) float64 {
+	total := sum(values)
+	if total == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(values)
+	slices.SortFunc(sorted, func(a, b int64) int { return cmp.Compare(b, a) })
+
+	return float64(sum(sorted[:min(n, len(sorted))])) / float64(total)
+}
+
+func sum(values []int64) int64 {
+	var total int64
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
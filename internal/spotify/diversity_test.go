@@ -0,0 +1,55 @@
+package spotify_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestShannonEntropy(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 0.0, spotify.ShannonEntropy([]int64{100}), 1e-9)
+	assert.InDelta(t, 2.0, spotify.ShannonEntropy([]int64{5, 5, 5, 5}), 1e-9)
+	assert.InDelta(t, 1.5, spotify.ShannonEntropy([]int64{2, 1, 1}), 1e-9)
+	assert.InDelta(t, 0.0, spotify.ShannonEntropy(nil), 1e-9)
+}
+
+func TestGini(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 0.0, spotify.Gini([]int64{5, 5, 5, 5}), 1e-9)
+	assert.InDelta(t, 0.75, spotify.Gini([]int64{0, 0, 0, 10}), 1e-9)
+	assert.InDelta(t, 0.25, spotify.Gini([]int64{1, 3}), 1e-9)
+	assert.InDelta(t, 0.0, spotify.Gini([]int64{42}), 1e-9)
+}
+
+func TestTopShare(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 0.7, spotify.TopShare([]int64{10, 50, 20, 20}, 2), 1e-9)
+	assert.InDelta(t, 1.0, spotify.TopShare([]int64{10, 50}, 10), 1e-9)
+	assert.InDelta(t, 0.0, spotify.TopShare(nil, 10), 1e-9)
+}
+
+func TestComputeDiversity(t *testing.T) {
+	t.Parallel()
+
+	got := spotify.ComputeDiversity([]spotify.PeriodPlayTime{
+		{Period: "2024-01", Key: "a", TotalPlayTimeMS: 5},
+		{Period: "2024-01", Key: "b", TotalPlayTimeMS: 5},
+		{Period: "2024-02", Key: "a", TotalPlayTimeMS: 10},
+		{Period: "2024-02", Key: "b", TotalPlayTimeMS: 0},
+	})
+
+	require.Len(t, got, 2)
+	assert.Equal(t, "2024-01", got[0].Period)
+	assert.Equal(t, 2, got[0].Count)
+	assert.InDelta(t, 1.0, got[0].Entropy, 1e-9)
+	assert.Equal(t, "2024-02", got[1].Period)
+	assert.Equal(t, 1, got[1].Count)
+	assert.InDelta(t, 1.0, got[1].Top10Share, 1e-9)
+}
@@ -0,0 +1,85 @@
+package spotify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cadoween/decibel/internal/listen"
+)
+
+// Filter and Period live in the listen package, since they apply to the
+// listens of every service. They are kept here for the statistics callers.
+type (
+	Filter = listen.Filter
+	Period = listen.Period
+)
+
+var ErrInvalidPeriod = listen.ErrInvalidPeriod
+
+// ParsePeriod parses a period, see listen.ParsePeriod.
+func ParsePeriod(s string) (Period, error) {
+	period, err := listen.ParsePeriod(s)
+	if err != nil {
+		return Period{}, fmt.Errorf("listen.ParsePeriod: %w", err)
+	}
+	return period, nil
+}
+
+// whereClause builds a WHERE clause combining the given conditions with the ones
+// of the filter, along with the arguments of its placeholders.
+func whereClause(f Filter, conditions ...string) (string, []any) {
+	var args []any
+
+	if len(f.Usernames) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(f.Usernames)), ", ")
+		conditions = append(conditions, "username IN ("+placeholders+")")
+		for _, username := range f.Usernames {
+			args = append(args, username)
+		}
+	}
+
+	if len(f.Sources) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(f.Sources)), ", ")
+		conditions = append(conditions, "source IN ("+placeholders+")")
+		for _, source := range f.Sources {
+			args = append(args, source)
+		}
+	}
+
+	if !f.From.IsZero() {
+		conditions = append(conditions, tsExpr+" >= ?")
+		args = append(args, f.From.UTC().Format(time.DateTime))
+	}
+
+	if !f.To.IsZero() {
+		conditions = append(conditions, tsExpr+" < ?")
+		args = append(args, f.To.UTC().Format(time.DateTime))
+	}
+
+	if f.MinPlayed > 0 {
+		conditions = append(conditions, "ms_played >= ?")
+		args = append(args, f.MinPlayed.Milliseconds())
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// limitClause returns the LIMIT clause of top lists, def rows unless overridden.
+func limitClause(f Filter, def int) string {
+	if f.Limit > 0 {
+		def = f.Limit
+	}
+	return "LIMIT " + strconv.Itoa(def)
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, using a backslash as
+// the escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
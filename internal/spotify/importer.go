@@ -0,0 +1,90 @@
+package spotify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"strings"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/pkg/ext"
+)
+
+func init() {
+	listen.Register(ExtendedImporter{})
+	listen.Register(AccountImporter{})
+}
+
+// ExtendedImporter reads the files of the Extended Streaming History as
+// listens, podcast episodes included. The username of the account is
+// recorded by the export.
+type ExtendedImporter struct{}
+
+// AccountImporter reads the streaming history of the account data export as
+// listens.
+type AccountImporter struct{}
+
+func (ExtendedImporter) Format() listen.Format {
+	return listen.Format{
+		Name:        SourceSpotify,
+		Source:      SourceSpotify,
+		Description: "Streaming_History_Audio_*.json of the Spotify Extended Streaming History",
+	}
+}
+
+// Detect recognizes JSON files of extended history streams.
+func (ExtendedImporter) Detect(path string, head []byte) bool {
+	return strings.EqualFold(filepath.Ext(path), ext.JSON) &&
+		bytes.Contains(head, []byte(`"ms_played"`)) &&
+		bytes.Contains(head, []byte(`"master_metadata_track_name"`))
+}
+
+func (ExtendedImporter) Open(ctx context.Context, path string, _ listen.Options) (iter.Seq2[listen.Listen, error], error) {
+	streams, err := NewJSONReader().readStreamsFromFile(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("readStreamsFromFile: %w", err)
+	}
+
+	listens := make([]listen.Listen, 0, len(streams))
+	for _, stream := range streams {
+		listens = append(listens, stream.Listen())
+	}
+
+	return listen.All(listens), nil
+}
+
+func (AccountImporter) Format() listen.Format {
+	return listen.Format{
+		Name:        SourceSpotifyAccount,
+		Source:      SourceSpotifyAccount,
+		Description: "StreamingHistory*.json of the Spotify account data export",
+		Scrobbles:   true,
+	}
+}
+
+// Detect recognizes JSON files of account data streams.
+func (AccountImporter) Detect(path string, head []byte) bool {
+	return strings.EqualFold(filepath.Ext(path), ext.JSON) &&
+		bytes.Contains(head, []byte(`"endTime"`)) &&
+		bytes.Contains(head, []byte(`"msPlayed"`))
+}
+
+func (AccountImporter) Open(_ context.Context, path string, opts listen.Options) (iter.Seq2[listen.Listen, error], error) {
+	if opts.Username == "" {
+		return nil, listen.ErrUsernameRequired
+	}
+
+	streams, err := ReadAccountFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ReadAccountFile: %w", err)
+	}
+
+	listens, err := AccountStreamsToListens(streams, opts.Username)
+	if err != nil {
+		return nil, fmt.Errorf("AccountStreamsToListens: %w", err)
+	}
+
+	return listen.All(listens), nil
+}
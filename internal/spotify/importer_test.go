@@ -0,0 +1,97 @@
+package spotify_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+const (
+	extendedJSON = `[{"ts":"2024-05-01T10:00:00Z","username":"alice","platform":"android","ms_played":200000,` +
+		`"master_metadata_track_name":"Jóga","master_metadata_album_artist_name":"Björk",` +
+		`"master_metadata_album_album_name":"Homogenic","spotify_track_uri":"spotify:track:abc"},` +
+		`{"ts":"2024-05-01T11:00:00Z","username":"alice","ms_played":60000,"master_metadata_track_name":null,` +
+		`"episode_name":"Daily News","episode_show_name":"The Show","spotify_episode_uri":"spotify:episode:def",` +
+		`"incognito_mode":true,"offline":true,"offline_timestamp":1714557000}]`
+	accountJSON = `[{"endTime":"2024-05-02 10:00","artistName":"Björk","trackName":"Hyperballad","msPlayed":300000},` +
+		`{"endTime":"2024-05-02 11:00","artistName":"","trackName":"","msPlayed":1000}]`
+)
+
+func TestImporters_Detect(t *testing.T) {
+	t.Parallel()
+
+	extended, account := spotify.ExtendedImporter{}, spotify.AccountImporter{}
+
+	assert.True(t, extended.Detect("Streaming_History_Audio_2024.json", []byte(extendedJSON)))
+	assert.False(t, extended.Detect("StreamingHistory_music_0.json", []byte(accountJSON)))
+	assert.False(t, extended.Detect("Streaming_History_Audio_2024.csv", []byte(extendedJSON)))
+
+	assert.True(t, account.Detect("StreamingHistory_music_0.json", []byte(accountJSON)))
+	assert.False(t, account.Detect("Streaming_History_Audio_2024.json", []byte(extendedJSON)))
+}
+
+func TestImporters_Open(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	extendedPath := filepath.Join(dir, "Streaming_History_Audio_2024.json")
+	accountPath := filepath.Join(dir, "StreamingHistory_music_0.json")
+	require.NoError(t, os.WriteFile(extendedPath, []byte(extendedJSON), 0o600))
+	require.NoError(t, os.WriteFile(accountPath, []byte(accountJSON), 0o600))
+
+	collect := func(importer listen.Importer, path string, opts listen.Options) []listen.Listen {
+		seq, err := importer.Open(context.Background(), path, opts)
+		require.NoError(t, err)
+
+		var listens []listen.Listen
+		for l, err := range seq {
+			require.NoError(t, err)
+			listens = append(listens, l)
+		}
+		return listens
+	}
+
+	// Episodes are kept, the username is the one of the export.
+	assert.Equal(t, []listen.Listen{{
+		EndedAt:  time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Username: "alice",
+		Source:   spotify.SourceSpotify,
+		Track:    "Jóga",
+		Artist:   "Björk",
+		Album:    "Homogenic",
+		TrackURI: "spotify:track:abc",
+		Played:   200 * time.Second,
+		Platform: "android",
+	}, {
+		EndedAt:          time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+		Username:         "alice",
+		Source:           spotify.SourceSpotify,
+		Played:           time.Minute,
+		Offline:          true,
+		Episode:          "Daily News",
+		Show:             "The Show",
+		EpisodeURI:       "spotify:episode:def",
+		Incognito:        true,
+		OfflineTimestamp: 1714557000,
+	}}, collect(spotify.ExtendedImporter{}, extendedPath, listen.Options{Username: "bob"}))
+
+	assert.Equal(t, []listen.Listen{{
+		EndedAt:  time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC),
+		Username: "bob",
+		Source:   spotify.SourceSpotifyAccount,
+		Track:    "Hyperballad",
+		Artist:   "Björk",
+		Played:   5 * time.Minute,
+	}}, collect(spotify.AccountImporter{}, accountPath, listen.Options{Username: "bob"}))
+
+	_, err := spotify.AccountImporter{}.Open(context.Background(), accountPath, listen.Options{})
+	require.ErrorIs(t, err, listen.ErrUsernameRequired)
+}
@@ -0,0 +1,170 @@
+package spotify
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/vingarcia/ksql"
+)
+
+// SourceSpotify is the source recorded for imports of Spotify exports.
+const SourceSpotify = "spotify"
+
+var importsTable = ksql.NewTable("imports", "id")
+
+// Import records a batch of streams imported for a user from an export.
+type Import struct {
+	ID          int       `ksql:"id"`
+	Username    string    `ksql:"username"`
+	Source      string    `ksql:"source"`
+	Path        string    `ksql:"path"`
+	StreamCount int       `ksql:"stream_count"`
+	FirstTS     time.Time `ksql:"first_ts"`
+	LastTS      time.Time `ksql:"last_ts"`
+	ImportedAt  time.Time `ksql:"imported_at"`
+}
+
+// NewImports summarizes streams into one Import per username.
+func NewImports(source, path string, streams []Stream, importedAt time.Time) []Import {
+	var imports []Import
+	index := make(map[string]int)
+
+	for i := range streams {
+		j, ok := index[streams[i].Username]
+		if !ok {
+			index[streams[i].Username] = len(imports)
+			imports = append(imports, Import{
+				Username:   streams[i].Username,
+				Source:     source,
+				Path:       path,
+				FirstTS:    streams[i].TS,
+				LastTS:     streams[i].TS,
+				ImportedAt: importedAt,
+			})
+			j = len(imports) - 1
+		}
+
+		imports[j].StreamCount++
+		if streams[i].TS.Before(imports[j].FirstTS) {
+			imports[j].FirstTS = streams[i].TS
+		}
+		if streams[i].TS.After(imports[j].LastTS) {
+			imports[j].LastTS = streams[i].TS
+		}
+	}
+
+	return imports
+}
+
+// SkipImportedStreams drops the streams already stored from source, the ones
+// of the same user played at the same time of the same track or episode, and
+// returns the remaining ones. Exports can be imported in any order, older or
+// partial ones only adding the streams they have that aren't stored yet.
+func (s *SQLite) SkipImportedStreams(ctx context.Context, source string, streams []Stream) ([]Stream, error) {
+	if len(streams) == 0 {
+		return streams, nil
+	}
+
+	var stored []struct {
+		Username string    `ksql:"username"`
+		TS       time.Time `ksql:"ts"`
+		Item     string    `ksql:"item"`
+	}
+	query := `
+		SELECT
+			COALESCE(username, '') AS username,
+			ts,
+			COALESCE(NULLIF(spotify_track_uri, ''), NULLIF(master_metadata_track_name, ''),
+				NULLIF(spotify_episode_uri, ''), episode_name, '') AS item
+		FROM spotify_streams
+		WHERE source = ? AND ts IS NOT NULL
+	`
+	if err := s.sqlProvider.Query(ctx, &stored, query, source); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	seen := make(map[streamKey]bool, len(stored))
+	for _, stream := range stored {
+		seen[streamKey{username: stream.Username, ts: stream.TS.UnixNano(), item: stream.Item}] = true
+	}
+
+	return slices.DeleteFunc(streams, func(stream Stream) bool {
+		return seen[stream.key()]
+	}), nil
+}
+
+// streamKey identifies a stream of a source: who played what and when.
+type streamKey struct {
+	username string
+	ts       int64
+	item     string
+}
+
+// key returns the key of the stream, identifying the track by its URI, or
+// its name when it has none, and episodes alike.
+func (s *Stream) key() streamKey {
+	return streamKey{
+		username: s.Username,
+		ts:       s.TS.UnixNano(),
+		item:     cmp.Or(s.SpotifyTrackURI, s.MasterMetadataTrackName, deref(s.SpotifyEpisodeURI), deref(s.EpisodeName)),
+	}
+}
+
+// ImportStreams stores the streams not imported yet from source, as
+// SkipImportedStreams, and records their import under path. Both happen in a
+// single transaction, so an interrupted import leaves no streams behind
+// without the import skipping them next time. It returns the streams stored.
+func (s *SQLite) ImportStreams(ctx context.Context, source, path string, streams []Stream, importedAt time.Time) ([]Stream, error) {
+	err := s.sqlProvider.Transaction(ctx, func(tx ksql.Provider) error {
+		txSQLite := NewSQLite(tx)
+
+		var err error
+		streams, err = txSQLite.SkipImportedStreams(ctx, source, streams)
+		if err != nil {
+			return fmt.Errorf("txSQLite.SkipImportedStreams: %w", err)
+		}
+
+		if err := txSQLite.BulkInsertStreams(ctx, streams); err != nil {
+			return fmt.Errorf("txSQLite.BulkInsertStreams: %w", err)
+		}
+
+		if err := txSQLite.RecordImports(ctx, NewImports(source, path, streams, importedAt)); err != nil {
+			return fmt.Errorf("txSQLite.RecordImports: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Transaction: %w", err)
+	}
+
+	return streams, nil
+}
+
+func (s *SQLite) RecordImports(ctx context.Context, imports []Import) error {
+	err := s.sqlProvider.Transaction(ctx, func(tx ksql.Provider) error {
+		for i := range imports {
+			if err := tx.Insert(ctx, importsTable, &imports[i]); err != nil {
+				return fmt.Errorf("tx.Insert: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("s.sqlProvider.Transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLite) GetImports(ctx context.Context) ([]Import, error) {
+	var results []Import
+	if err := s.sqlProvider.Query(ctx, &results, "FROM imports ORDER BY imported_at ASC, id ASC"); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,120 @@
+package spotify_test
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestSQLite_ImportStreams(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := ksqlite.New(ctx, filepath.Join(t.TempDir(), "decibel.db"), ksql.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	s := spotify.NewSQLite(db)
+	require.NoError(t, s.Migrate(ctx))
+
+	ts := time.Date(2024, 3, 1, 22, 5, 0, 0, time.UTC)
+	streams := func() []spotify.Stream {
+		return []spotify.Stream{
+			{TS: ts, Username: "alice", MasterMetadataTrackName: "Roads", MSPlayed: 300000},
+			{TS: ts.Add(time.Hour), Username: "alice", MasterMetadataTrackName: "Glory Box", MSPlayed: 300000},
+			{TS: ts, Username: "bob", MasterMetadataTrackName: "Teardrop", MSPlayed: 300000},
+		}
+	}
+
+	imported, err := s.ImportStreams(ctx, spotify.SourceSpotify, "export", streams(), ts)
+	require.NoError(t, err)
+	assert.Len(t, imported, 3)
+
+	imported, err = s.ImportStreams(ctx, spotify.SourceSpotify, "export", streams(), ts)
+	require.NoError(t, err)
+	assert.Empty(t, imported)
+
+	imports, err := s.GetImports(ctx)
+	require.NoError(t, err)
+	require.Len(t, imports, 2)
+	assert.Equal(t, "alice", imports[0].Username)
+	assert.Equal(t, 2, imports[0].StreamCount)
+	assert.Equal(t, ts.Add(time.Hour), imports[0].LastTS)
+
+	// An older export imported afterwards adds the streams it doesn't share
+	// with the newer one.
+	imported, err = s.ImportStreams(ctx, spotify.SourceSpotify, "older export", []spotify.Stream{
+		{TS: ts.Add(-time.Hour), Username: "alice", MasterMetadataTrackName: "Sour Times", MSPlayed: 300000},
+		{TS: ts, Username: "alice", MasterMetadataTrackName: "Roads", MSPlayed: 300000},
+		{TS: ts, Username: "alice", MasterMetadataTrackName: "Mysterons", MSPlayed: 300000},
+	}, ts)
+	require.NoError(t, err)
+	require.Len(t, imported, 2)
+	assert.Equal(t, "Sour Times", imported[0].MasterMetadataTrackName)
+	assert.Equal(t, "Mysterons", imported[1].MasterMetadataTrackName)
+
+	// Streams of another source are not taken for the same ones.
+	imported, err = s.ImportStreams(ctx, "lastfm", "scrobbles", streams(), ts)
+	require.NoError(t, err)
+	assert.Len(t, imported, 3)
+}
+
+func TestSQLite_Migrate_BackfillsImports(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := ksqlite.New(ctx, filepath.Join(t.TempDir(), "decibel.db"), ksql.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	s := spotify.NewSQLite(db)
+	require.NoError(t, s.Migrate(ctx))
+
+	// Streams inserted without recording their import, as older versions
+	// did, before running the last migration again.
+	ts := time.Date(2024, 3, 1, 22, 5, 0, 0, time.UTC)
+	require.NoError(t, s.BulkInsertStreams(ctx, []spotify.Stream{
+		{TS: ts, Username: "alice", MasterMetadataTrackName: "Roads", MSPlayed: 300000},
+		{TS: ts.Add(time.Hour), Username: "alice", MasterMetadataTrackName: "Glory Box", MSPlayed: 300000},
+	}))
+	// Streams without a username, which older versions could store, are
+	// left out.
+	_, err = db.Exec(ctx, "INSERT INTO spotify_streams (ts, ms_played) VALUES (?, 1000)", ts)
+	require.NoError(t, err)
+
+	var versions []struct {
+		UserVersion int `ksql:"user_version"`
+	}
+	require.NoError(t, db.Query(ctx, &versions, "PRAGMA user_version"))
+	require.Len(t, versions, 1)
+	_, err = db.Exec(ctx, "PRAGMA user_version = "+strconv.Itoa(versions[0].UserVersion-1))
+	require.NoError(t, err)
+	require.NoError(t, s.Migrate(ctx))
+
+	imports, err := s.GetImports(ctx)
+	require.NoError(t, err)
+	require.Len(t, imports, 1)
+	assert.Equal(t, "alice", imports[0].Username)
+	assert.Equal(t, spotify.SourceSpotify, imports[0].Source)
+	assert.Equal(t, 2, imports[0].StreamCount)
+	assert.Equal(t, ts.Add(time.Hour), imports[0].LastTS)
+
+	imported, err := s.ImportStreams(ctx, spotify.SourceSpotify, "export", []spotify.Stream{
+		{TS: ts.Add(time.Hour), Username: "alice", MasterMetadataTrackName: "Glory Box", MSPlayed: 300000},
+		{TS: ts.Add(2 * time.Hour), Username: "alice", MasterMetadataTrackName: "Sour Times", MSPlayed: 300000},
+	}, ts)
+	require.NoError(t, err)
+	require.Len(t, imported, 1)
+	assert.Equal(t, "Sour Times", imported[0].MasterMetadataTrackName)
+}
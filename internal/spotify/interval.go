@@ -0,0 +1,47 @@
+package spotify
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidInterval = errors.New("invalid interval")
+
+// Interval is the granularity used to group streams over time.
+type Interval string
+
+const (
+	IntervalDay   Interval = "day"
+	IntervalWeek  Interval = "week"
+	IntervalMonth Interval = "month"
+	IntervalYear  Interval = "year"
+)
+
+// ParseInterval parses the name of an interval, case insensitively.
+func ParseInterval(s string) (Interval, error) {
+	interval := Interval(strings.ToLower(strings.TrimSpace(s)))
+	switch interval {
+	case IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+		return interval, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidInterval, s)
+	}
+}
+
+// periodExpr returns the SQL expression formatting the stream timestamp as
+// the period it belongs to, e.g. "2024-03" for IntervalMonth.
+func (i Interval) periodExpr() string {
+	switch i {
+	case IntervalDay:
+		return "strftime('%Y-%m-%d', " + tsExpr + ")"
+	case IntervalWeek:
+		return "strftime('%Y-W%W', " + tsExpr + ")"
+	case IntervalYear:
+		return "strftime('%Y', " + tsExpr + ")"
+	case IntervalMonth:
+		return "strftime('%Y-%m', " + tsExpr + ")"
+	default:
+		return "strftime('%Y-%m', " + tsExpr + ")"
+	}
+}
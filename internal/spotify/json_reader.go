@@ -149,6 +149,7 @@ func (r *JSONReader) readStreamsFromFile(ctx context.Context, path string) ([]St
 
 			return nil, fmt.Errorf("decoder.Decode: %w", err)
 		}
+		stream.Device = ParseDevice(stream.Platform, stream.UserAgentDecrypted)
 		streams = append(streams, stream)
 	}
 
@@ -0,0 +1,176 @@
+package spotify
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cadoween/decibel/internal/listen"
+)
+
+var _ listen.Store = (*SQLite)(nil)
+
+// listenRow is a stream read back as a listen, episodes left out.
+type listenRow struct {
+	TS          time.Time `ksql:"ts"`
+	Username    string    `ksql:"username"`
+	Source      string    `ksql:"source"`
+	Track       string    `ksql:"master_metadata_track_name"`
+	Artist      string    `ksql:"master_metadata_album_artist_name"`
+	Album       string    `ksql:"master_metadata_album_album_name"`
+	TrackURI    string    `ksql:"spotify_track_uri"`
+	MSPlayed    int64     `ksql:"ms_played"`
+	Skipped     bool      `ksql:"skipped"`
+	Shuffle     bool      `ksql:"shuffle"`
+	Offline     bool      `ksql:"offline"`
+	ReasonStart string    `ksql:"reason_start"`
+	ReasonEnd   string    `ksql:"reason_end"`
+	Platform    string    `ksql:"platform"`
+	UserAgent   string    `ksql:"user_agent_decrypted"`
+	Country     string    `ksql:"conn_country"`
+	IPAddr      string    `ksql:"ip_addr_decrypted"`
+}
+
+// Listen maps the stream to a listen.
+func (s Stream) Listen() listen.Listen {
+	return listen.Listen{
+		EndedAt:     s.TS,
+		Username:    s.Username,
+		Source:      cmp.Or(s.Source, SourceSpotify),
+		Track:       s.MasterMetadataTrackName,
+		Artist:      s.MasterMetadataAlbumArtistName,
+		Album:       s.MasterMetadataAlbumAlbumName,
+		TrackURI:    s.SpotifyTrackURI,
+		Played:      time.Duration(s.MSPlayed) * time.Millisecond,
+		Skipped:     s.Skipped,
+		Shuffle:     s.Shuffle,
+		Offline:     s.Offline,
+		ReasonStart: s.ReasonStart,
+		ReasonEnd:   s.ReasonEnd,
+		Platform:    s.Platform,
+		UserAgent:   s.UserAgentDecrypted,
+		Country:     s.ConnCountry,
+		IPAddr:      s.IPAddrDecrypted,
+
+		Episode:          deref(s.EpisodeName),
+		Show:             deref(s.EpisodeShowName),
+		EpisodeURI:       deref(s.SpotifyEpisodeURI),
+		Incognito:        s.IncognitoMode,
+		OfflineTimestamp: s.OfflineTimestamp,
+	}
+}
+
+// FromListens maps listens to the streams they are stored as, with their
+// device parsed from the platform and user agent.
+func FromListens(listens []listen.Listen) []Stream {
+	streams := make([]Stream, 0, len(listens))
+	for _, l := range listens {
+		streams = append(streams, Stream{
+			TS:                            l.EndedAt,
+			Username:                      l.Username,
+			Platform:                      l.Platform,
+			MSPlayed:                      int(l.Played.Milliseconds()),
+			ConnCountry:                   l.Country,
+			IPAddrDecrypted:               l.IPAddr,
+			UserAgentDecrypted:            l.UserAgent,
+			MasterMetadataTrackName:       l.Track,
+			MasterMetadataAlbumArtistName: l.Artist,
+			MasterMetadataAlbumAlbumName:  l.Album,
+			SpotifyTrackURI:               l.TrackURI,
+			ReasonStart:                   l.ReasonStart,
+			ReasonEnd:                     l.ReasonEnd,
+			Shuffle:                       l.Shuffle,
+			Skipped:                       l.Skipped,
+			Offline:                       l.Offline,
+			OfflineTimestamp:              l.OfflineTimestamp,
+			IncognitoMode:                 l.Incognito,
+			EpisodeName:                   optional(l.Episode),
+			EpisodeShowName:               optional(l.Show),
+			SpotifyEpisodeURI:             optional(l.EpisodeURI),
+			Device:                        ParseDevice(l.Platform, l.UserAgent),
+			Source:                        l.Source,
+		})
+	}
+
+	return streams
+}
+
+// optional returns a pointer to s, nil when empty, as episode columns are
+// NULL for tracks.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// deref returns the string s points to, empty when nil.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// QueryListens gets the streams of tracks matching filter as listens, oldest
+// first.
+func (s *SQLite) QueryListens(ctx context.Context, filter Filter) ([]listen.Listen, error) {
+	where, args := whereClause(filter, "master_metadata_track_name IS NOT NULL", "master_metadata_track_name != ''")
+	query := `
+		SELECT
+			ts,
+			COALESCE(username, '') AS username,
+			source,
+			master_metadata_track_name,
+			COALESCE(master_metadata_album_artist_name, '') AS master_metadata_album_artist_name,
+			COALESCE(master_metadata_album_album_name, '') AS master_metadata_album_album_name,
+			COALESCE(spotify_track_uri, '') AS spotify_track_uri,
+			COALESCE(ms_played, 0) AS ms_played,
+			COALESCE(skipped, FALSE) AS skipped,
+			COALESCE(shuffle, FALSE) AS shuffle,
+			COALESCE(offline, FALSE) AS offline,
+			COALESCE(reason_start, '') AS reason_start,
+			COALESCE(reason_end, '') AS reason_end,
+			COALESCE(platform, '') AS platform,
+			COALESCE(user_agent_decrypted, '') AS user_agent_decrypted,
+			COALESCE(conn_country, '') AS conn_country,
+			COALESCE(ip_addr_decrypted, '') AS ip_addr_decrypted
+		FROM spotify_streams
+		` + where + `
+		ORDER BY ` + tsExpr + ` ASC, id ASC
+	`
+	if filter.Limit > 0 {
+		query += limitClause(filter, 0)
+	}
+
+	var rows []listenRow
+	if err := s.sqlProvider.Query(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	listens := make([]listen.Listen, 0, len(rows))
+	for _, row := range rows {
+		listens = append(listens, listen.Listen{
+			EndedAt:     row.TS,
+			Username:    row.Username,
+			Source:      row.Source,
+			Track:       row.Track,
+			Artist:      row.Artist,
+			Album:       row.Album,
+			TrackURI:    row.TrackURI,
+			Played:      time.Duration(row.MSPlayed) * time.Millisecond,
+			Skipped:     row.Skipped,
+			Shuffle:     row.Shuffle,
+			Offline:     row.Offline,
+			ReasonStart: row.ReasonStart,
+			ReasonEnd:   row.ReasonEnd,
+			Platform:    row.Platform,
+			UserAgent:   row.UserAgent,
+			Country:     row.Country,
+			IPAddr:      row.IPAddr,
+		})
+	}
+
+	return listens, nil
+}
@@ -0,0 +1,114 @@
+package spotify_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestSQLite_QueryListens(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := ksqlite.New(ctx, filepath.Join(t.TempDir(), "decibel.db"), ksql.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	s := spotify.NewSQLite(db)
+	require.NoError(t, s.Migrate(ctx))
+
+	ts := time.Date(2024, 3, 1, 22, 5, 0, 0, time.UTC)
+	require.NoError(t, s.BulkInsertStreams(ctx, []spotify.Stream{
+		{
+			TS:                            ts.Add(time.Hour),
+			Username:                      "alice",
+			MasterMetadataAlbumArtistName: "Portishead",
+			MasterMetadataTrackName:       "Roads",
+			MSPlayed:                      300000,
+		},
+		// Too short, another user, podcast and another source.
+		{TS: ts, Username: "alice", MasterMetadataTrackName: "Hunter", MSPlayed: 1000},
+		{TS: ts, Username: "bob", MasterMetadataTrackName: "Hunter", MSPlayed: 300000},
+		{TS: ts, Username: "alice", MSPlayed: 300000},
+		{TS: ts, Username: "alice", MasterMetadataTrackName: "Hunter", MSPlayed: 300000, Source: "lastfm"},
+	}))
+
+	want := listen.Listen{
+		EndedAt:   ts,
+		Username:  "alice",
+		Source:    spotify.SourceSpotify,
+		Track:     "Jóga",
+		Artist:    "Björk",
+		Album:     "Homogenic",
+		TrackURI:  "spotify:track:1",
+		Played:    5 * time.Minute,
+		Shuffle:   true,
+		ReasonEnd: "trackdone",
+		Platform:  "iOS 17.4 (iPhone15,2)",
+		Country:   "IS",
+	}
+	require.NoError(t, s.BulkInsertStreams(ctx, spotify.FromListens([]listen.Listen{want})))
+
+	got, err := s.QueryListens(ctx, spotify.Filter{
+		Usernames: []string{"alice"},
+		Sources:   []string{spotify.SourceSpotify},
+		MinPlayed: 30 * time.Second,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, got, 2)
+	assert.Equal(t, want, got[0])
+	assert.Equal(t, ts.Add(-5*time.Minute), got[0].StartedAt())
+	assert.Equal(t, "Roads", got[1].Track)
+
+	// The device is parsed from the platform of inserted listens.
+	devices, err := s.GetPlayTimeByDevice(ctx, spotify.IntervalYear, spotify.Filter{Usernames: []string{"alice"}, Sources: []string{spotify.SourceSpotify}})
+	require.NoError(t, err)
+	assert.Contains(t, devices, spotify.DeviceStats{
+		Period:          "2024",
+		DeviceClass:     string(spotify.DeviceClassPhone),
+		OS:              "iOS",
+		Manufacturer:    "Apple",
+		PlayCount:       1,
+		TotalPlayTimeMS: 300000,
+	})
+}
+
+func TestFromListens(t *testing.T) {
+	t.Parallel()
+
+	episode, show, uri := "Daily News", "The Show", "spotify:episode:def"
+	stream := spotify.Stream{
+		TS:                 time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+		Username:           "alice",
+		Platform:           "android",
+		MSPlayed:           60000,
+		UserAgentDecrypted: "Spotify/8.8.0 Android/33 (SM-S911B)",
+		EpisodeName:        &episode,
+		EpisodeShowName:    &show,
+		SpotifyEpisodeURI:  &uri,
+		Offline:            true,
+		OfflineTimestamp:   1714557000,
+		IncognitoMode:      true,
+		Source:             spotify.SourceSpotify,
+	}
+	stream.Device = spotify.ParseDevice(stream.Platform, stream.UserAgentDecrypted)
+
+	// What only Spotify records is kept from streams to listens and back.
+	assert.Equal(t, []spotify.Stream{stream}, spotify.FromListens([]listen.Listen{stream.Listen()}))
+
+	// Tracks have no episode.
+	streams := spotify.FromListens([]listen.Listen{{Track: "Roads", Artist: "Portishead"}})
+	require.Len(t, streams, 1)
+	assert.Nil(t, streams[0].EpisodeName)
+}
@@ -0,0 +1,161 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vingarcia/ksql"
+)
+
+// migrations holds the schema changes of the database in order. The index of
+// the last applied migration is tracked with SQLite's user_version pragma, so
+// new migrations must always be appended at the end of the list.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS spotify_streams (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		ts TIMESTAMP,
+		username TEXT,
+		platform TEXT,
+		ms_played INTEGER,
+		conn_country TEXT,
+		ip_addr_decrypted TEXT,
+		user_agent_decrypted TEXT,
+		master_metadata_track_name TEXT,
+		master_metadata_album_artist_name TEXT,
+		master_metadata_album_album_name TEXT,
+		spotify_track_uri TEXT,
+		episode_name TEXT,
+		episode_show_name TEXT,
+		spotify_episode_uri TEXT,
+		reason_start TEXT,
+		reason_end TEXT,
+		shuffle BOOLEAN,
+		skipped BOOLEAN,
+		offline BOOLEAN,
+		offline_timestamp INTEGER,
+		incognito_mode BOOLEAN
+	)`,
+	`ALTER TABLE spotify_streams ADD COLUMN device_class TEXT`,
+	`ALTER TABLE spotify_streams ADD COLUMN os_name TEXT`,
+	`ALTER TABLE spotify_streams ADD COLUMN os_version TEXT`,
+	`ALTER TABLE spotify_streams ADD COLUMN device_manufacturer TEXT`,
+	`CREATE TABLE IF NOT EXISTS profiles (
+		name TEXT PRIMARY KEY,
+		username TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS imports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT NOT NULL,
+		source TEXT NOT NULL,
+		path TEXT NOT NULL,
+		stream_count INTEGER NOT NULL,
+		first_ts TIMESTAMP,
+		last_ts TIMESTAMP,
+		imported_at TIMESTAMP NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS spotify_streams_username_idx ON spotify_streams (username)`,
+	`ALTER TABLE spotify_streams ADD COLUMN source TEXT NOT NULL DEFAULT 'spotify'`,
+	`CREATE INDEX IF NOT EXISTS spotify_streams_track_idx ON spotify_streams (master_metadata_track_name COLLATE NOCASE)`,
+	// Streams stored before imports were recorded are recorded as one import
+	// per user and source.
+	`INSERT INTO imports (username, source, path, stream_count, first_ts, last_ts, imported_at)
+	SELECT username, source, '', COUNT(*), MIN(ts), MAX(ts), CURRENT_TIMESTAMP
+	FROM spotify_streams AS s
+	WHERE username IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM imports AS i WHERE i.username = s.username AND i.source = s.source)
+	GROUP BY username, source`,
+}
+
+// Migrate brings the database schema up to date and fills in the columns
+// derived at import time for rows imported before they existed.
+func (s *SQLite) Migrate(ctx context.Context) error {
+	var versions []struct {
+		UserVersion int `ksql:"user_version"`
+	}
+	if err := s.sqlProvider.Query(ctx, &versions, "PRAGMA user_version"); err != nil {
+		return fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	version := 0
+	if len(versions) > 0 {
+		version = versions[0].UserVersion
+	}
+
+	// Each migration is applied along with its version in a transaction, so
+	// a failing migration leaves the database at the previous version
+	// instead of half migrated.
+	for i := version; i < len(migrations); i++ {
+		err := s.sqlProvider.Transaction(ctx, func(tx ksql.Provider) error {
+			if _, err := tx.Exec(ctx, migrations[i]); err != nil {
+				return fmt.Errorf("tx.Exec: %w", err)
+			}
+
+			if _, err := tx.Exec(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+				return fmt.Errorf("tx.Exec: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("s.sqlProvider.Transaction migration %d: %w", i+1, err)
+		}
+	}
+
+	if err := s.backfillDevices(ctx); err != nil {
+		return fmt.Errorf("s.backfillDevices: %w", err)
+	}
+
+	return nil
+}
+
+// backfillDevices parses the platform and user agent of streams imported
+// before device columns were added to the schema.
+func (s *SQLite) backfillDevices(ctx context.Context) error {
+	var pairs []struct {
+		Platform  string `ksql:"platform"`
+		UserAgent string `ksql:"user_agent_decrypted"`
+	}
+
+	query := `
+		SELECT DISTINCT
+			COALESCE(platform, '') AS platform,
+			COALESCE(user_agent_decrypted, '') AS user_agent_decrypted
+		FROM spotify_streams
+		WHERE device_class IS NULL
+	`
+	if err := s.sqlProvider.Query(ctx, &pairs, query); err != nil {
+		return fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	updateQuery := `
+		UPDATE spotify_streams
+		SET device_class = ?, os_name = ?, os_version = ?, device_manufacturer = ?
+		WHERE device_class IS NULL
+			AND COALESCE(platform, '') = ?
+			AND COALESCE(user_agent_decrypted, '') = ?
+	`
+
+	err := s.sqlProvider.Transaction(ctx, func(tx ksql.Provider) error {
+		for _, pair := range pairs {
+			device := ParseDevice(pair.Platform, pair.UserAgent)
+			_, err := tx.Exec(ctx, updateQuery,
+				device.Class, device.OS, device.OSVersion, device.Manufacturer,
+				pair.Platform, pair.UserAgent,
+			)
+			if err != nil {
+				return fmt.Errorf("tx.Exec: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("s.sqlProvider.Transaction: %w", err)
+	}
+
+	return nil
+}
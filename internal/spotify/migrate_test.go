@@ -0,0 +1,58 @@
+package spotify_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestSQLite_Migrate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := ksqlite.New(ctx, filepath.Join(t.TempDir(), "decibel.db"), ksql.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	userVersion := func() int {
+		var versions []struct {
+			UserVersion int `ksql:"user_version"`
+		}
+		require.NoError(t, db.Query(ctx, &versions, "PRAGMA user_version"))
+		return versions[0].UserVersion
+	}
+
+	// A table already holding a column a migration adds makes it fail.
+	_, err = db.Exec(ctx, `CREATE TABLE spotify_streams (
+		ts TIMESTAMP, username TEXT, platform TEXT, user_agent_decrypted TEXT, master_metadata_track_name TEXT, os_name TEXT
+	)`)
+	require.NoError(t, err)
+
+	s := spotify.NewSQLite(db)
+	require.Error(t, s.Migrate(ctx))
+
+	// The migrations before the failing one are kept, the failing one is
+	// rolled back and fails again on the next run.
+	assert.Equal(t, 2, userVersion())
+	require.ErrorContains(t, s.Migrate(ctx), "migration 3")
+	assert.Equal(t, 2, userVersion())
+
+	_, err = db.Exec(ctx, `ALTER TABLE spotify_streams DROP COLUMN os_name`)
+	require.NoError(t, err)
+	require.NoError(t, s.Migrate(ctx))
+	require.NoError(t, s.Migrate(ctx))
+
+	var columns []struct {
+		Name string `ksql:"name"`
+	}
+	require.NoError(t, db.Query(ctx, &columns, "SELECT name FROM pragma_table_info('spotify_streams') WHERE name = 'device_class'"))
+	assert.Len(t, columns, 1)
+}
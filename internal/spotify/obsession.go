@@ -0,0 +1,176 @@
+package spotify
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Obsession is a period during which a user played a track over and over,
+// either many times within a short window or back-to-back on repeat.
+type Obsession struct {
+	Username string `json:"username"`
+	TrackURI string `json:"track_uri"`
+	Track    string `json:"track"`
+	Artist   string `json:"artist"`
+	// Start and End are the first and last plays of the obsession, i.e. when
+	// it started and when it faded.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Plays int       `json:"plays"`
+	// PeakPlays is the highest number of plays within a single window.
+	PeakPlays int `json:"peak_plays"`
+	// LongestRun is the highest number of back-to-back plays.
+	LongestRun int `json:"longest_run"`
+}
+
+// ObsessionOptions tunes the obsession detection.
+type ObsessionOptions struct {
+	// MinPlays is the number of plays within Window for a track to become an
+	// obsession.
+	MinPlays int
+	Window   time.Duration
+	// MinRepeats is the number of back-to-back plays for a track to become an
+	// obsession on its own.
+	MinRepeats int
+	// MinPlayTime is the play time under which a stream isn't counted as a
+	// play, so skipping through a track doesn't count towards an obsession.
+	MinPlayTime time.Duration
+}
+
+var DefaultObsessionOptions = ObsessionOptions{
+	MinPlays:    20,
+	Window:      7 * 24 * time.Hour,
+	MinRepeats:  5,
+	MinPlayTime: 30 * time.Second,
+}
+
+// DetectObsessions finds obsessions in chronologically ordered streams, per
+// user. Overlapping windows and runs of the same track are merged into a
+// single obsession. The result is sorted by start time.
+func DetectObsessions(streams []TrackStream, opts ObsessionOptions) []Obsession {
+	var (
+		usernames []string
+		byUser    = make(map[string][]TrackStream)
+	)
+	for i := range streams {
+		username := streams[i].Username
+		if _, ok := byUser[username]; !ok {
+			usernames = append(usernames, username)
+		}
+		byUser[username] = append(byUser[username], streams[i])
+	}
+
+	var obsessions []Obsession
+	for _, username := range usernames {
+		obsessions = append(obsessions, detectUserObsessions(byUser[username], opts)...)
+	}
+
+	slices.SortStableFunc(obsessions, func(a, b Obsession) int {
+		if c := a.Start.Compare(b.Start); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Username, b.Username)
+	})
+
+	return obsessions
+}
+
+// trackPlay is a play of a track along with the number of plays of the same
+// track within the window ending with it and its position in a back-to-back
+// run.
+type trackPlay struct {
+	stream TrackStream
+	window int
+	run    int
+}
+
+func detectUserObsessions(streams []TrackStream, opts ObsessionOptions) []Obsession {
+	var (
+		uris  []string
+		plays = make(map[string][]trackPlay)
+		last  string
+		run   int
+	)
+
+	for i := range streams {
+		stream := streams[i]
+		if stream.TrackURI == "" || time.Duration(stream.MSPlayed)*time.Millisecond < opts.MinPlayTime {
+			continue
+		}
+
+		if stream.TrackURI == last {
+			run++
+		} else {
+			last, run = stream.TrackURI, 1
+		}
+
+		trackPlays := plays[stream.TrackURI]
+		if trackPlays == nil {
+			uris = append(uris, stream.TrackURI)
+		}
+
+		window := 1
+		for j := len(trackPlays) - 1; j >= 0 && stream.TS.Sub(trackPlays[j].stream.TS) <= opts.Window; j-- {
+			window++
+		}
+
+		plays[stream.TrackURI] = append(trackPlays, trackPlay{stream: stream, window: window, run: run})
+	}
+
+	var obsessions []Obsession
+	for _, uri := range uris {
+		obsessions = append(obsessions, trackObsessions(plays[uri], opts)...)
+	}
+
+	return obsessions
+}
+
+// trackObsessions merges the windows and runs of a single track reaching the
+// thresholds into obsessions.
+func trackObsessions(plays []trackPlay, opts ObsessionOptions) []Obsession {
+	type span struct{ lo, hi int }
+
+	var spans []span
+	for i, play := range plays {
+		if play.window >= opts.MinPlays {
+			spans = append(spans, span{lo: i - play.window + 1, hi: i})
+		}
+		if play.run >= opts.MinRepeats {
+			spans = append(spans, span{lo: i - play.run + 1, hi: i})
+		}
+	}
+
+	slices.SortFunc(spans, func(a, b span) int { return a.lo - b.lo })
+
+	var merged []span
+	for _, s := range spans {
+		if len(merged) > 0 && s.lo <= merged[len(merged)-1].hi {
+			merged[len(merged)-1].hi = max(merged[len(merged)-1].hi, s.hi)
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	obsessions := make([]Obsession, 0, len(merged))
+	for _, s := range merged {
+		first, end := plays[s.lo].stream, plays[s.hi].stream
+		obsession := Obsession{
+			Username: first.Username,
+			TrackURI: first.TrackURI,
+			Track:    end.Track,
+			Artist:   end.Artist,
+			Start:    first.start(),
+			End:      end.TS,
+			Plays:    s.hi - s.lo + 1,
+		}
+		for i := s.lo; i <= s.hi; i++ {
+			// Plays before the obsession started aren't part of its peak.
+			obsession.PeakPlays = max(obsession.PeakPlays, min(plays[i].window, i-s.lo+1))
+			obsession.LongestRun = max(obsession.LongestRun, min(plays[i].run, i-s.lo+1))
+		}
+		obsessions = append(obsessions, obsession)
+	}
+
+	return obsessions
+}
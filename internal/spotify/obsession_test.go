@@ -0,0 +1,99 @@
+package spotify_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestDetectObsessions(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	play := func(ts time.Time, uri string, msPlayed int64) spotify.TrackStream {
+		return spotify.TrackStream{
+			TS:       ts,
+			Username: "user1",
+			TrackURI: uri,
+			Track:    "Track " + uri,
+			Artist:   "Artist1",
+			MSPlayed: msPlayed,
+		}
+	}
+
+	// daily plays a track three times a day for days days, with a different
+	// track in between each play.
+	daily := func(uri string, days int) []spotify.TrackStream {
+		var streams []spotify.TrackStream
+		for day := range days {
+			for i := range 3 {
+				ts := start.AddDate(0, 0, day).Add(time.Duration(i*2) * time.Hour)
+				streams = append(streams, play(ts, uri, 180000))
+				streams = append(streams, play(ts.Add(time.Hour), fmt.Sprintf("%s-filler-%d-%d", uri, day, i), 180000))
+			}
+		}
+		return streams
+	}
+
+	t.Run("occasional plays are not an obsession", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Empty(t, spotify.DetectObsessions(daily("a", 5), spotify.DefaultObsessionOptions))
+	})
+
+	t.Run("many plays within a week are an obsession", func(t *testing.T) {
+		t.Parallel()
+
+		streams := daily("a", 14)
+		obsessions := spotify.DetectObsessions(streams, spotify.DefaultObsessionOptions)
+
+		require.Len(t, obsessions, 1)
+		assert.Equal(t, "a", obsessions[0].TrackURI)
+		assert.Equal(t, "user1", obsessions[0].Username)
+		assert.Equal(t, streams[0].TS.Add(-3*time.Minute), obsessions[0].Start)
+		assert.Equal(t, streams[len(streams)-2].TS, obsessions[0].End)
+		assert.Equal(t, 42, obsessions[0].Plays)
+		assert.Equal(t, 22, obsessions[0].PeakPlays)
+		assert.Equal(t, 1, obsessions[0].LongestRun)
+	})
+
+	t.Run("back-to-back repeats are an obsession", func(t *testing.T) {
+		t.Parallel()
+
+		var streams []spotify.TrackStream
+		for i := range 6 {
+			streams = append(streams, play(start.Add(time.Duration(i)*3*time.Minute), "a", 180000))
+		}
+		// A skipped track in between doesn't break the run.
+		streams = append(streams[:3], append([]spotify.TrackStream{play(start.Add(10*time.Minute), "b", 1000)}, streams[3:]...)...)
+		streams = append(streams, play(start.Add(time.Hour), "b", 180000))
+
+		obsessions := spotify.DetectObsessions(streams, spotify.DefaultObsessionOptions)
+
+		require.Len(t, obsessions, 1)
+		assert.Equal(t, "a", obsessions[0].TrackURI)
+		assert.Equal(t, 6, obsessions[0].Plays)
+		assert.Equal(t, 6, obsessions[0].LongestRun)
+	})
+
+	t.Run("users are detected separately", func(t *testing.T) {
+		t.Parallel()
+
+		var streams []spotify.TrackStream
+		for i := range 6 {
+			stream := play(start.Add(time.Duration(i)*3*time.Minute), "a", 180000)
+			if i%2 == 1 {
+				stream.Username = "user2"
+			}
+			streams = append(streams, stream)
+		}
+
+		assert.Empty(t, spotify.DetectObsessions(streams, spotify.DefaultObsessionOptions))
+	})
+}
@@ -0,0 +1,79 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vingarcia/ksql"
+
+	"github.com/cadoween/decibel/pkg/redact"
+)
+
+// RedactStreams redacts the IP addresses and user agents of the streams
+// already stored in the database. The schema is migrated first so device
+// information is parsed from the user agents before they are redacted.
+func (s *SQLite) RedactStreams(ctx context.Context, redactor redact.Redactor) error {
+	if err := redactor.Validate(); err != nil {
+		return fmt.Errorf("redactor.Validate: %w", err)
+	}
+
+	if err := s.Migrate(ctx); err != nil {
+		return fmt.Errorf("s.Migrate: %w", err)
+	}
+
+	columns := []struct {
+		name   string
+		mode   redact.Mode
+		redact func(string) string
+	}{
+		{name: "ip_addr_decrypted", mode: redactor.IPMode, redact: redactor.IP},
+		{name: "user_agent_decrypted", mode: redactor.UserAgentMode, redact: redactor.UserAgent},
+	}
+
+	for _, column := range columns {
+		if column.mode == "" || column.mode == redact.ModeKeep {
+			continue
+		}
+
+		if err := s.redactColumn(ctx, column.name, column.redact); err != nil {
+			return fmt.Errorf("s.redactColumn %s: %w", column.name, err)
+		}
+	}
+
+	return nil
+}
+
+// redactColumn replaces every distinct value of column with its redacted
+// form. column must never come from user input since it is interpolated in
+// the queries.
+func (s *SQLite) redactColumn(ctx context.Context, column string, redactValue func(string) string) error {
+	var values []struct {
+		Value string `ksql:"value"`
+	}
+
+	query := `SELECT DISTINCT ` + column + ` AS value FROM spotify_streams WHERE ` + column + ` IS NOT NULL AND ` + column + ` != ''`
+	if err := s.sqlProvider.Query(ctx, &values, query); err != nil {
+		return fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	updateQuery := `UPDATE spotify_streams SET ` + column + ` = ? WHERE ` + column + ` = ?`
+
+	err := s.sqlProvider.Transaction(ctx, func(tx ksql.Provider) error {
+		for _, value := range values {
+			redacted := redactValue(value.Value)
+			if redacted == value.Value {
+				continue
+			}
+
+			if _, err := tx.Exec(ctx, updateQuery, redacted, value.Value); err != nil {
+				return fmt.Errorf("tx.Exec: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("s.sqlProvider.Transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package spotify_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+
+	"github.com/cadoween/decibel/internal/spotify"
+	"github.com/cadoween/decibel/pkg/redact"
+)
+
+func TestSQLite_RedactStreams(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := ksqlite.New(ctx, filepath.Join(t.TempDir(), "decibel.db"), ksql.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	s := spotify.NewSQLite(db)
+	require.NoError(t, s.Migrate(ctx))
+
+	ts := time.Date(2024, 3, 1, 22, 5, 0, 0, time.UTC)
+	require.NoError(t, s.BulkInsertStreams(ctx, []spotify.Stream{
+		{TS: ts, Username: "alice", IPAddrDecrypted: "192.168.1.42", UserAgentDecrypted: "windows 10 (10.0.19045; x64)"},
+		{TS: ts.Add(time.Hour), Username: "alice", IPAddrDecrypted: "2001:db8:abcd:12::1", UserAgentDecrypted: "Spotify/8.8.0 Android/33 (SM-S911B)"},
+	}))
+
+	redactor := redact.Redactor{IPMode: redact.ModeTruncate, UserAgentMode: redact.ModeHash, Salt: "pepper"}
+
+	type row struct {
+		IPAddr    string `ksql:"ip_addr_decrypted"`
+		UserAgent string `ksql:"user_agent_decrypted"`
+	}
+	query := `SELECT ip_addr_decrypted, user_agent_decrypted FROM spotify_streams ORDER BY ts`
+
+	require.NoError(t, s.RedactStreams(ctx, redactor))
+
+	var once []row
+	require.NoError(t, db.Query(ctx, &once, query))
+	require.Len(t, once, 2)
+	assert.Equal(t, "192.168.1.0/24", once[0].IPAddr)
+	assert.Equal(t, "2001:db8:abcd::/48", once[1].IPAddr)
+	assert.Equal(t, redactor.UserAgent("windows 10 (10.0.19045; x64)"), once[0].UserAgent)
+
+	// Redacting an already redacted database changes nothing.
+	require.NoError(t, s.RedactStreams(ctx, redactor))
+
+	var twice []row
+	require.NoError(t, db.Query(ctx, &twice, query))
+	assert.Equal(t, once, twice)
+}
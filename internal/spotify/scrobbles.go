@@ -0,0 +1,83 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// ScrobbleTolerance is how far before the start or after the end of a
+	// stream a scrobble of the same track is still considered the same play.
+	// Services only record when a stream ended and for how long it played,
+	// so pauses shift the actual start earlier.
+	ScrobbleTolerance = 3 * time.Minute
+	// DefaultScrobblePlayTime is the play time given to scrobbles of tracks
+	// never streamed on another service, scrobbles don't record how long a
+	// track played.
+	DefaultScrobblePlayTime = 3*time.Minute + 30*time.Second
+)
+
+// MergeScrobbles deletes the streams imported from source, such as Last.fm,
+// duplicating a stream imported from another service like Spotify: the same
+// user playing a track of the same name and artist at the same time,
+// ignoring the case of ASCII letters. Plays are often scrobbled, so
+// importing both would count them twice. It returns the number of deleted
+// streams, and can run after importing either side.
+func (s *SQLite) MergeScrobbles(ctx context.Context, source string) (int64, error) {
+	query := `
+		DELETE FROM spotify_streams
+		WHERE id IN (
+			SELECT scrobble.id
+			FROM spotify_streams scrobble
+			JOIN spotify_streams stream
+				ON stream.source != scrobble.source
+				AND stream.username = scrobble.username
+				AND stream.master_metadata_track_name = scrobble.master_metadata_track_name COLLATE NOCASE
+				AND stream.master_metadata_album_artist_name = scrobble.master_metadata_album_artist_name COLLATE NOCASE
+			WHERE scrobble.source = ?
+				AND julianday(substr(scrobble.ts, 1, 19))
+					BETWEEN julianday(substr(stream.ts, 1, 19)) - (stream.ms_played + ?) / 86400000.0
+					AND julianday(substr(stream.ts, 1, 19)) + ? / 86400000.0
+		)
+	`
+
+	tolerance := ScrobbleTolerance.Milliseconds()
+	result, err := s.sqlProvider.Exec(ctx, query, source, tolerance, tolerance)
+	if err != nil {
+		return 0, fmt.Errorf("s.sqlProvider.Exec: %w", err)
+	}
+
+	merged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("result.RowsAffected: %w", err)
+	}
+
+	return merged, nil
+}
+
+// EstimatePlayTimes fills in the play time of the streams imported from
+// source without one and not skipped, using the average play time of the
+// same track on other services, or DefaultScrobblePlayTime. Statistics by
+// play time would leave these streams out otherwise.
+func (s *SQLite) EstimatePlayTimes(ctx context.Context, source string) error {
+	query := `
+		UPDATE spotify_streams
+		SET ms_played = COALESCE((
+			SELECT CAST(AVG(stream.ms_played) AS INTEGER)
+			FROM spotify_streams stream
+			WHERE stream.source != ?
+				AND NOT stream.skipped
+				AND stream.ms_played > 0
+				AND stream.master_metadata_track_name = spotify_streams.master_metadata_track_name COLLATE NOCASE
+				AND stream.master_metadata_album_artist_name = spotify_streams.master_metadata_album_artist_name COLLATE NOCASE
+		), ?)
+		WHERE source = ? AND NOT skipped AND (ms_played IS NULL OR ms_played = 0)
+	`
+
+	if _, err := s.sqlProvider.Exec(ctx, query, source, DefaultScrobblePlayTime.Milliseconds(), source); err != nil {
+		return fmt.Errorf("s.sqlProvider.Exec: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package spotify_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vingarcia/ksql"
+	ksqlite "github.com/vingarcia/ksql/adapters/modernc-ksqlite"
+
+	"github.com/cadoween/decibel/internal/spotify"
+)
+
+func TestSQLite_MergeScrobbles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := ksqlite.New(ctx, filepath.Join(t.TempDir(), "decibel.db"), ksql.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	s := spotify.NewSQLite(db)
+	require.NoError(t, s.Migrate(ctx))
+
+	end := time.Date(2024, 3, 1, 22, 4, 0, 0, time.UTC)
+	scrobble := func(ts time.Time, track string) spotify.Stream {
+		return spotify.Stream{
+			TS:                            ts,
+			Username:                      "alice",
+			MasterMetadataAlbumArtistName: "björk",
+			MasterMetadataTrackName:       track,
+			Source:                        "lastfm",
+		}
+	}
+
+	require.NoError(t, s.BulkInsertStreams(ctx, []spotify.Stream{
+		{
+			TS:                            end,
+			Username:                      "alice",
+			MasterMetadataAlbumArtistName: "Björk",
+			MasterMetadataTrackName:       "Jóga",
+			SpotifyTrackURI:               "spotify:track:1",
+			MSPlayed:                      240000,
+		},
+		// Scrobbled when the Spotify stream started.
+		scrobble(end.Add(-4*time.Minute), "JóGA"),
+		// The same track played again an hour later, on another device.
+		scrobble(end.Add(time.Hour), "Jóga"),
+		scrobble(end.Add(-time.Hour), "Hunter"),
+	}))
+
+	// Skipped plays keep their unknown play time.
+	skipped := scrobble(end.Add(2*time.Hour), "Bachelorette")
+	skipped.Skipped = true
+	require.NoError(t, s.BulkInsertStreams(ctx, []spotify.Stream{skipped}))
+
+	merged, err := s.MergeScrobbles(ctx, "lastfm")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), merged)
+
+	require.NoError(t, s.EstimatePlayTimes(ctx, "lastfm"))
+
+	var got []struct {
+		Track    string `ksql:"master_metadata_track_name"`
+		MSPlayed int64  `ksql:"ms_played"`
+		Source   string `ksql:"source"`
+	}
+	require.NoError(t, db.Query(ctx, &got, "SELECT master_metadata_track_name, ms_played, source FROM spotify_streams ORDER BY id"))
+
+	assert.Equal(t, []struct {
+		Track    string `ksql:"master_metadata_track_name"`
+		MSPlayed int64  `ksql:"ms_played"`
+		Source   string `ksql:"source"`
+	}{
+		{Track: "Jóga", MSPlayed: 240000, Source: "spotify"},
+		{Track: "Jóga", MSPlayed: 240000, Source: "lastfm"},
+		{Track: "Hunter", MSPlayed: spotify.DefaultScrobblePlayTime.Milliseconds(), Source: "lastfm"},
+		{Track: "Bachelorette", MSPlayed: 0, Source: "lastfm"},
+	}, got)
+}
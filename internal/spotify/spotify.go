@@ -2,6 +2,11 @@ package spotify
 
 import "time"
 
+// tsExpr is the SQL expression used to read stream timestamps in a format
+// SQLite date functions understand, since they are stored with Go's default
+// time layout which they can't parse.
+const tsExpr = "substr(ts, 1, 19)"
+
 type Stream struct {
 	TS                            time.Time `json:"ts"`
 	SpotifyEpisodeURI             *string   `json:"spotify_episode_uri"`
@@ -24,30 +29,126 @@ type Stream struct {
 	Skipped                       bool      `json:"skipped"`
 	Offline                       bool      `json:"offline"`
 	IncognitoMode                 bool      `json:"incognito_mode"`
+
+	// Device is parsed from Platform and UserAgentDecrypted on import.
+	Device Device `json:"-"`
+	// Source is the service the stream was imported from, SourceSpotify when
+	// empty.
+	Source string `json:"-"`
 }
 
 type ArtistStats struct {
-	Artist        string `ksql:"master_metadata_album_artist_name"`
-	PlayCount     int64  `ksql:"play_count"`
-	TotalPlayTime int64  `ksql:"total_play_time_ms"`
+	Artist        string `ksql:"master_metadata_album_artist_name" json:"artist"`
+	PlayCount     int64  `ksql:"play_count" json:"play_count"`
+	TotalPlayTime int64  `ksql:"total_play_time_ms" json:"total_play_time_ms"`
 }
 
 type TrackStats struct {
-	Track           string `ksql:"master_metadata_track_name"`
-	Artist          string `ksql:"master_metadata_album_artist_name"`
-	PlayCount       int64  `ksql:"play_count"`
-	TotalPlayTimeMS int64  `ksql:"total_play_time_ms"`
+	Track           string `ksql:"master_metadata_track_name" json:"track"`
+	Artist          string `ksql:"master_metadata_album_artist_name" json:"artist"`
+	TrackURI        string `ksql:"spotify_track_uri" json:"track_uri"`
+	PlayCount       int64  `ksql:"play_count" json:"play_count"`
+	TotalPlayTimeMS int64  `ksql:"total_play_time_ms" json:"total_play_time_ms"`
 }
 
 type AlbumStats struct {
-	Album  string `ksql:"master_metadata_album_album_name"`
-	Artist string `ksql:"master_metadata_album_artist_name"`
-	Count  int64  `ksql:"play_count"`
+	Album           string `ksql:"master_metadata_album_album_name" json:"album"`
+	Artist          string `ksql:"master_metadata_album_artist_name" json:"artist"`
+	Count           int64  `ksql:"play_count" json:"play_count"`
+	TotalPlayTimeMS int64  `ksql:"total_play_time_ms" json:"total_play_time_ms"`
 }
 
 type TrackSkipStats struct {
-	TrackName  string  `ksql:"master_metadata_track_name"`
-	ArtistName string  `ksql:"master_metadata_album_artist_name"`
-	SkipCount  int     `ksql:"skip_count"`
-	SkipRate   float64 `ksql:"skip_rate"`
+	TrackName  string  `ksql:"master_metadata_track_name" json:"track"`
+	ArtistName string  `ksql:"master_metadata_album_artist_name" json:"artist"`
+	TrackURI   string  `ksql:"spotify_track_uri" json:"track_uri"`
+	SkipCount  int     `ksql:"skip_count" json:"skip_count"`
+	SkipRate   float64 `ksql:"skip_rate" json:"skip_rate"`
+}
+
+type DeviceStats struct {
+	Period          string `ksql:"period" json:"period"`
+	DeviceClass     string `ksql:"device_class" json:"device_class"`
+	OS              string `ksql:"os_name" json:"os"`
+	Manufacturer    string `ksql:"device_manufacturer" json:"manufacturer"`
+	PlayCount       int64  `ksql:"play_count" json:"play_count"`
+	TotalPlayTimeMS int64  `ksql:"total_play_time_ms" json:"total_play_time_ms"`
+}
+
+type CountryStats struct {
+	Country         string `ksql:"conn_country" json:"country"`
+	PlayCount       int64  `ksql:"play_count" json:"play_count"`
+	TotalPlayTimeMS int64  `ksql:"total_play_time_ms" json:"total_play_time_ms"`
+}
+
+type CountryStream struct {
+	TS       time.Time `ksql:"ts"`
+	Country  string    `ksql:"conn_country"`
+	MSPlayed int64     `ksql:"ms_played"`
+}
+
+type ActivityStream struct {
+	TS       time.Time `ksql:"ts"`
+	Username string    `ksql:"username"`
+	Platform string    `ksql:"platform"`
+	IPAddr   string    `ksql:"ip_addr_decrypted"`
+	Country  string    `ksql:"conn_country"`
+	Artist   string    `ksql:"master_metadata_album_artist_name"`
+	MSPlayed int64     `ksql:"ms_played"`
+}
+
+// start returns the time the stream started playing, Spotify only records
+// the time it ended.
+func (s ActivityStream) start() time.Time {
+	return s.TS.Add(-time.Duration(s.MSPlayed) * time.Millisecond)
+}
+
+type UserArtistStats struct {
+	FirstPlayed     Timestamp `ksql:"first_played" json:"first_played"`
+	Username        string    `ksql:"username" json:"username"`
+	Artist          string    `ksql:"master_metadata_album_artist_name" json:"artist"`
+	PlayCount       int64     `ksql:"play_count" json:"play_count"`
+	TotalPlayTimeMS int64     `ksql:"total_play_time_ms" json:"total_play_time_ms"`
+}
+
+type Totals struct {
+	PlayCount       int64 `ksql:"play_count" json:"play_count"`
+	TotalPlayTimeMS int64 `ksql:"total_play_time_ms" json:"total_play_time_ms"`
+	ArtistCount     int64 `ksql:"artist_count" json:"artist_count"`
+	TrackCount      int64 `ksql:"track_count" json:"track_count"`
+}
+
+type PeriodStats struct {
+	Period          string `ksql:"period" json:"period"`
+	PlayCount       int64  `ksql:"play_count" json:"play_count"`
+	TotalPlayTimeMS int64  `ksql:"total_play_time_ms" json:"total_play_time_ms"`
+}
+
+// HourStats is the listening of an hour of a day of the week, Weekday going
+// from 0 (Sunday) to 6 like time.Weekday.
+type HourStats struct {
+	Weekday         int   `ksql:"weekday" json:"weekday"`
+	Hour            int   `ksql:"hour" json:"hour"`
+	PlayCount       int64 `ksql:"play_count" json:"play_count"`
+	TotalPlayTimeMS int64 `ksql:"total_play_time_ms" json:"total_play_time_ms"`
+}
+
+type PeriodPlayTime struct {
+	Period          string `ksql:"period" json:"period"`
+	Key             string `ksql:"key" json:"key"`
+	TotalPlayTimeMS int64  `ksql:"total_play_time_ms" json:"total_play_time_ms"`
+}
+
+type TrackStream struct {
+	TS       time.Time `ksql:"ts"`
+	Username string    `ksql:"username"`
+	TrackURI string    `ksql:"spotify_track_uri"`
+	Track    string    `ksql:"master_metadata_track_name"`
+	Artist   string    `ksql:"master_metadata_album_artist_name"`
+	MSPlayed int64     `ksql:"ms_played"`
+}
+
+// start returns the time the stream started playing.
+func (s TrackStream) start() time.Time {
+	return s.TS.Add(-time.Duration(s.MSPlayed) * time.Millisecond)
 }
@@ -1,6 +1,7 @@
 package spotify
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"slices"
@@ -20,57 +21,29 @@ func NewSQLite(sqlProvider ksql.Provider) *SQLite {
 	}
 }
 
+// BulkInsertStreams inserts streams in batches, into a database brought up to
+// date with Migrate.
 func (s *SQLite) BulkInsertStreams(ctx context.Context, streams []Stream) error {
-	createTableQuery := `
-		CREATE TABLE IF NOT EXISTS spotify_streams (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			ts TIMESTAMP,
-			username TEXT,
-			platform TEXT,
-			ms_played INTEGER,
-			conn_country TEXT,
-			ip_addr_decrypted TEXT,
-			user_agent_decrypted TEXT,
-			master_metadata_track_name TEXT,
-			master_metadata_album_artist_name TEXT,
-			master_metadata_album_album_name TEXT,
-			spotify_track_uri TEXT,
-			episode_name TEXT,
-			episode_show_name TEXT,
-			spotify_episode_uri TEXT,
-			reason_start TEXT,
-			reason_end TEXT,
-			shuffle BOOLEAN,
-			skipped BOOLEAN,
-			offline BOOLEAN,
-			offline_timestamp INTEGER,
-			incognito_mode BOOLEAN
-		)
-	`
-
-	if _, err := s.sqlProvider.Exec(ctx, createTableQuery); err != nil {
-		return fmt.Errorf("s.sqlProvider.Exec: %w", err)
-	}
-
 	insertQuery := `
 		INSERT INTO spotify_streams (
 			ts, username, platform, ms_played, conn_country, ip_addr_decrypted,
 			user_agent_decrypted, master_metadata_track_name, master_metadata_album_artist_name,
 			master_metadata_album_album_name, spotify_track_uri, episode_name,
 			episode_show_name, spotify_episode_uri, reason_start, reason_end,
-			shuffle, skipped, offline, offline_timestamp, incognito_mode
+			shuffle, skipped, offline, offline_timestamp, incognito_mode,
+			device_class, os_name, os_version, device_manufacturer, source
 		) VALUES 
 	`
 
-	// SQLite has a limit of 999 parameters, each stream has 21 parameters so
-	// we'll use batches of 45 rows (945 parameters) to stay safely under the
+	// SQLite has a limit of 999 parameters, each stream has 26 parameters so
+	// we'll use batches of 38 rows (988 parameters) to stay safely under the
 	// limit.
-	for batch := range slices.Chunk(streams, 45) {
-		args := make([]any, 0, len(batch)*21)
+	for batch := range slices.Chunk(streams, 38) {
+		args := make([]any, 0, len(batch)*26)
 		valueStrings := make([]string, len(batch))
 
 		for j := range batch {
-			valueStrings[j] = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+			valueStrings[j] = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 			args = append(args,
 				batch[j].TS, batch[j].Username, batch[j].Platform, batch[j].MSPlayed, batch[j].ConnCountry,
 				batch[j].IPAddrDecrypted, batch[j].UserAgentDecrypted, batch[j].MasterMetadataTrackName,
@@ -78,6 +51,8 @@ func (s *SQLite) BulkInsertStreams(ctx context.Context, streams []Stream) error
 				batch[j].SpotifyTrackURI, batch[j].EpisodeName, batch[j].EpisodeShowName,
 				batch[j].SpotifyEpisodeURI, batch[j].ReasonStart, batch[j].ReasonEnd, batch[j].Shuffle,
 				batch[j].Skipped, batch[j].Offline, batch[j].OfflineTimestamp, batch[j].IncognitoMode,
+				batch[j].Device.Class, batch[j].Device.OS, batch[j].Device.OSVersion, batch[j].Device.Manufacturer,
+				cmp.Or(batch[j].Source, SourceSpotify),
 			)
 		}
 
@@ -90,87 +65,445 @@ func (s *SQLite) BulkInsertStreams(ctx context.Context, streams []Stream) error
 	return nil
 }
 
-func (s *SQLite) GetTopArtistsByPlayTime(ctx context.Context) ([]ArtistStats, error) {
+func (s *SQLite) GetTopArtistsByPlayTime(ctx context.Context, filter Filter) ([]ArtistStats, error) {
+	where, args := whereClause(filter, "master_metadata_album_artist_name IS NOT NULL")
 	query := `
 		SELECT 
 			master_metadata_album_artist_name,
 			COUNT(*) as play_count,
 			SUM(ms_played) as total_play_time_ms
 		FROM spotify_streams
-		WHERE master_metadata_album_artist_name IS NOT NULL
+		` + where + `
 		GROUP BY master_metadata_album_artist_name
 		ORDER BY total_play_time_ms DESC
-		LIMIT 10
+		` + limitClause(filter, 10) + `
 	`
 
 	var results []ArtistStats
-	if err := s.sqlProvider.Query(ctx, &results, query); err != nil {
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
 		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
 	}
 
 	return results, nil
 }
 
-func (s *SQLite) GetTopTracksByPlayTime(ctx context.Context) ([]TrackStats, error) {
+func (s *SQLite) GetTopTracksByPlayTime(ctx context.Context, filter Filter) ([]TrackStats, error) {
+	where, args := whereClause(filter, "master_metadata_track_name IS NOT NULL")
 	query := `
 		SELECT
 			master_metadata_track_name,
 			master_metadata_album_artist_name,
+			COALESCE(MAX(spotify_track_uri), '') AS spotify_track_uri,
 			COUNT(*) AS play_count,
 			SUM(ms_played) AS total_play_time_ms
 		FROM spotify_streams
-		WHERE master_metadata_track_name IS NOT NULL
+		` + where + `
 		GROUP BY master_metadata_track_name, master_metadata_album_artist_name
 		ORDER BY total_play_time_ms DESC
-		LIMIT 10
+		` + limitClause(filter, 10) + `
 	`
 
 	var results []TrackStats
-	if err := s.sqlProvider.Query(ctx, &results, query); err != nil {
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
 		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
 	}
 
 	return results, nil
 }
 
-func (s *SQLite) GetTopAlbumsByPlayCount(ctx context.Context) ([]AlbumStats, error) {
+func (s *SQLite) GetTopAlbumsByPlayCount(ctx context.Context, filter Filter) ([]AlbumStats, error) {
+	where, args := whereClause(filter, "master_metadata_album_album_name IS NOT NULL")
 	query := `
 		SELECT
 			master_metadata_album_album_name,
 			master_metadata_album_artist_name,
-			COUNT(*) AS play_count
+			COUNT(*) AS play_count,
+			SUM(ms_played) AS total_play_time_ms
 		FROM spotify_streams
-		WHERE master_metadata_album_album_name IS NOT NULL
+		` + where + `
 		GROUP BY master_metadata_album_album_name, master_metadata_album_artist_name
 		ORDER BY play_count DESC
-		LIMIT 10
+		` + limitClause(filter, 10) + `
 	`
 
 	var results []AlbumStats
-	if err := s.sqlProvider.Query(ctx, &results, query); err != nil {
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
 		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
 	}
 
 	return results, nil
 }
 
-func (s *SQLite) GetMostSkippedTracks(ctx context.Context) ([]TrackSkipStats, error) {
+func (s *SQLite) GetMostSkippedTracks(ctx context.Context, filter Filter) ([]TrackSkipStats, error) {
+	where, args := whereClause(filter, "master_metadata_track_name IS NOT NULL")
 	query := `
 		SELECT
 			master_metadata_track_name,
 			master_metadata_album_artist_name,
+			COALESCE(MAX(spotify_track_uri), '') AS spotify_track_uri,
 			SUM(CASE WHEN skipped THEN 1 ELSE 0 END) AS skip_count,
 			CAST(SUM(CASE WHEN skipped THEN 1 ELSE 0 END) AS FLOAT) / COUNT(*) AS skip_rate
 		FROM spotify_streams
-		WHERE master_metadata_track_name IS NOT NULL
+		` + where + `
 		GROUP BY master_metadata_track_name, master_metadata_album_artist_name
 		HAVING COUNT(*) > 5
 		ORDER BY skip_rate DESC
-		LIMIT 25
+		` + limitClause(filter, 25) + `
 	`
 
 	var results []TrackSkipStats
-	if err := s.sqlProvider.Query(ctx, &results, query); err != nil {
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLite) GetPlayTimeByDevice(ctx context.Context, interval Interval, filter Filter) ([]DeviceStats, error) {
+	where, args := whereClause(filter)
+	query := `
+		SELECT
+			` + interval.periodExpr() + ` AS period,
+			COALESCE(device_class, 'unknown') AS device_class,
+			COALESCE(os_name, '') AS os_name,
+			COALESCE(device_manufacturer, '') AS device_manufacturer,
+			COUNT(*) AS play_count,
+			SUM(ms_played) AS total_play_time_ms
+		FROM spotify_streams
+		` + where + `
+		GROUP BY 1, 2, 3, 4
+		ORDER BY period ASC, total_play_time_ms DESC
+	`
+
+	var results []DeviceStats
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLite) GetPlayTimeByCountry(ctx context.Context, filter Filter) ([]CountryStats, error) {
+	where, args := whereClause(filter, "conn_country IS NOT NULL", "conn_country != ''")
+	query := `
+		SELECT
+			conn_country,
+			COUNT(*) AS play_count,
+			SUM(ms_played) AS total_play_time_ms
+		FROM spotify_streams
+		` + where + `
+		GROUP BY conn_country
+		ORDER BY total_play_time_ms DESC
+	`
+
+	var results []CountryStats
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLite) GetCountryStreams(ctx context.Context, filter Filter) ([]CountryStream, error) {
+	where, args := whereClause(filter, "conn_country IS NOT NULL", "conn_country != ''")
+	query := `
+		SELECT ts, conn_country, ms_played
+		FROM spotify_streams
+		` + where + `
+		ORDER BY ` + tsExpr + ` ASC
+	`
+
+	var results []CountryStream
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLite) GetActivityStreams(ctx context.Context, filter Filter) ([]ActivityStream, error) {
+	where, args := whereClause(filter)
+	query := `
+		SELECT
+			ts,
+			COALESCE(username, '') AS username,
+			COALESCE(platform, '') AS platform,
+			COALESCE(ip_addr_decrypted, '') AS ip_addr_decrypted,
+			COALESCE(conn_country, '') AS conn_country,
+			COALESCE(master_metadata_album_artist_name, '') AS master_metadata_album_artist_name,
+			ms_played
+		FROM spotify_streams
+		` + where + `
+		ORDER BY ` + tsExpr + ` ASC
+	`
+
+	var results []ActivityStream
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLite) GetArtistPlayTimeByUser(ctx context.Context, filter Filter) ([]UserArtistStats, error) {
+	where, args := whereClause(filter, "master_metadata_album_artist_name IS NOT NULL")
+	query := `
+		SELECT
+			username,
+			master_metadata_album_artist_name,
+			COUNT(*) AS play_count,
+			SUM(ms_played) AS total_play_time_ms,
+			MIN(ts) AS first_played
+		FROM spotify_streams
+		` + where + `
+		GROUP BY username, master_metadata_album_artist_name
+	`
+
+	var results []UserArtistStats
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLite) GetTotals(ctx context.Context, filter Filter) (Totals, error) {
+	where, args := whereClause(filter)
+	query := `
+		SELECT
+			COUNT(*) AS play_count,
+			COALESCE(SUM(ms_played), 0) AS total_play_time_ms,
+			COUNT(DISTINCT master_metadata_album_artist_name) AS artist_count,
+			COUNT(DISTINCT master_metadata_track_name || char(0) || master_metadata_album_artist_name) AS track_count
+		FROM spotify_streams
+		` + where
+
+	var totals Totals
+	if err := s.sqlProvider.QueryOne(ctx, &totals, query, args...); err != nil {
+		return Totals{}, fmt.Errorf("s.sqlProvider.QueryOne: %w", err)
+	}
+
+	return totals, nil
+}
+
+func (s *SQLite) GetArtistPlayTimeByPeriod(ctx context.Context, interval Interval, filter Filter) ([]PeriodPlayTime, error) {
+	where, args := whereClause(filter, "master_metadata_album_artist_name IS NOT NULL")
+	query := `
+		SELECT
+			` + interval.periodExpr() + ` AS period,
+			master_metadata_album_artist_name AS key,
+			SUM(ms_played) AS total_play_time_ms
+		FROM spotify_streams
+		` + where + `
+		GROUP BY 1, 2
+		ORDER BY period ASC, total_play_time_ms DESC
+	`
+
+	var results []PeriodPlayTime
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLite) GetTrackPlayTimeByPeriod(ctx context.Context, interval Interval, filter Filter) ([]PeriodPlayTime, error) {
+	where, args := whereClause(filter, "master_metadata_track_name IS NOT NULL")
+	query := `
+		SELECT
+			` + interval.periodExpr() + ` AS period,
+			master_metadata_track_name || ' - ' || COALESCE(master_metadata_album_artist_name, '') AS key,
+			SUM(ms_played) AS total_play_time_ms
+		FROM spotify_streams
+		` + where + `
+		GROUP BY 1, 2
+		ORDER BY period ASC, total_play_time_ms DESC
+	`
+
+	var results []PeriodPlayTime
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLite) GetTrackStreams(ctx context.Context, filter Filter) ([]TrackStream, error) {
+	where, args := whereClause(filter, "spotify_track_uri IS NOT NULL", "spotify_track_uri != ''")
+	query := `
+		SELECT
+			ts,
+			COALESCE(username, '') AS username,
+			spotify_track_uri,
+			COALESCE(master_metadata_track_name, '') AS master_metadata_track_name,
+			COALESCE(master_metadata_album_artist_name, '') AS master_metadata_album_artist_name,
+			ms_played
+		FROM spotify_streams
+		` + where + `
+		ORDER BY ` + tsExpr + ` ASC
+	`
+
+	var results []TrackStream
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetPeriodSummary gets the totals and top lists of the streams matching
+// filter, usually restricted to a period with Filter.WithPeriod.
+func (s *SQLite) GetPeriodSummary(ctx context.Context, filter Filter) (PeriodSummary, error) {
+	totals, err := s.GetTotals(ctx, filter)
+	if err != nil {
+		return PeriodSummary{}, fmt.Errorf("s.GetTotals: %w", err)
+	}
+
+	artists, err := s.GetTopArtistsByPlayTime(ctx, filter)
+	if err != nil {
+		return PeriodSummary{}, fmt.Errorf("s.GetTopArtistsByPlayTime: %w", err)
+	}
+
+	tracks, err := s.GetTopTracksByPlayTime(ctx, filter)
+	if err != nil {
+		return PeriodSummary{}, fmt.Errorf("s.GetTopTracksByPlayTime: %w", err)
+	}
+
+	albums, err := s.GetTopAlbumsByPlayCount(ctx, filter)
+	if err != nil {
+		return PeriodSummary{}, fmt.Errorf("s.GetTopAlbumsByPlayCount: %w", err)
+	}
+
+	return PeriodSummary{
+		Totals:  totals,
+		Artists: RankArtists(artists),
+		Tracks:  RankTracks(tracks),
+		Albums:  RankAlbums(albums),
+	}, nil
+}
+
+func (s *SQLite) GetPlayTimeByPeriod(ctx context.Context, interval Interval, filter Filter) ([]PeriodStats, error) {
+	where, args := whereClause(filter)
+	query := `
+		SELECT
+			` + interval.periodExpr() + ` AS period,
+			COUNT(*) AS play_count,
+			SUM(ms_played) AS total_play_time_ms
+		FROM spotify_streams
+		` + where + `
+		GROUP BY 1
+		ORDER BY period ASC
+	`
+
+	var results []PeriodStats
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetPlayTimeByHour gets the listening per hour of each day of the week, in
+// UTC since that's how timestamps are exported.
+func (s *SQLite) GetPlayTimeByHour(ctx context.Context, filter Filter) ([]HourStats, error) {
+	where, args := whereClause(filter)
+	query := `
+		SELECT
+			CAST(strftime('%w', ` + tsExpr + `) AS INTEGER) AS weekday,
+			CAST(strftime('%H', ` + tsExpr + `) AS INTEGER) AS hour,
+			COUNT(*) AS play_count,
+			SUM(ms_played) AS total_play_time_ms
+		FROM spotify_streams
+		` + where + `
+		GROUP BY 1, 2
+		ORDER BY weekday ASC, hour ASC
+	`
+
+	var results []HourStats
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+// SearchArtists gets the artists whose name contains term, case
+// insensitively, by play time.
+func (s *SQLite) SearchArtists(ctx context.Context, term string, filter Filter) ([]ArtistStats, error) {
+	where, args := whereClause(filter,
+		"master_metadata_album_artist_name IS NOT NULL",
+		"master_metadata_album_artist_name LIKE ? ESCAPE '\\'",
+	)
+	args = append([]any{"%" + escapeLike(term) + "%"}, args...)
+	query := `
+		SELECT
+			master_metadata_album_artist_name,
+			COUNT(*) AS play_count,
+			SUM(ms_played) AS total_play_time_ms
+		FROM spotify_streams
+		` + where + `
+		GROUP BY master_metadata_album_artist_name
+		ORDER BY total_play_time_ms DESC
+		` + limitClause(filter, 50) + `
+	`
+
+	var results []ArtistStats
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLite) GetArtistAlbums(ctx context.Context, artist string, filter Filter) ([]AlbumStats, error) {
+	where, args := whereClause(filter,
+		"master_metadata_album_artist_name = ?",
+		"master_metadata_album_album_name IS NOT NULL",
+	)
+	args = append([]any{artist}, args...)
+	query := `
+		SELECT
+			master_metadata_album_album_name,
+			master_metadata_album_artist_name,
+			COUNT(*) AS play_count,
+			SUM(ms_played) AS total_play_time_ms
+		FROM spotify_streams
+		` + where + `
+		GROUP BY master_metadata_album_album_name, master_metadata_album_artist_name
+		ORDER BY total_play_time_ms DESC
+		` + limitClause(filter, 100) + `
+	`
+
+	var results []AlbumStats
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return results, nil
+}
+
+func (s *SQLite) GetAlbumTracks(ctx context.Context, artist, album string, filter Filter) ([]TrackStats, error) {
+	where, args := whereClause(filter,
+		"master_metadata_album_artist_name = ?",
+		"master_metadata_album_album_name = ?",
+		"master_metadata_track_name IS NOT NULL",
+	)
+	args = append([]any{artist, album}, args...)
+	query := `
+		SELECT
+			master_metadata_track_name,
+			master_metadata_album_artist_name,
+			COUNT(*) AS play_count,
+			SUM(ms_played) AS total_play_time_ms
+		FROM spotify_streams
+		` + where + `
+		GROUP BY master_metadata_track_name, master_metadata_album_artist_name
+		ORDER BY total_play_time_ms DESC
+		` + limitClause(filter, 100) + `
+	`
+
+	var results []TrackStats
+	if err := s.sqlProvider.Query(ctx, &results, query, args...); err != nil {
 		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
 	}
 
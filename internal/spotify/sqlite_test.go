@@ -44,18 +44,15 @@ func TestSQLite_BulkInsertStreams(t *testing.T) {
 				},
 			},
 			mock: func(m *ksqltest.MockProvider) {
-				m.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(nil, nil)
 				m.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 			},
 			wantErr: nil,
 		},
 		{
-			name:    "handles create table error",
+			name:    "inserts nothing without streams",
 			streams: []spotify.Stream{},
-			mock: func(m *ksqltest.MockProvider) {
-				m.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
-			},
-			wantErr: assert.AnError,
+			mock:    func(*ksqltest.MockProvider) {},
+			wantErr: nil,
 		},
 		{
 			name: "handles batch insert error",
@@ -64,7 +61,6 @@ func TestSQLite_BulkInsertStreams(t *testing.T) {
 				Username: "user1",
 			}},
 			mock: func(m *ksqltest.MockProvider) {
-				m.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(nil, nil)
 				m.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
 			},
 			wantErr: assert.AnError,
@@ -101,6 +97,7 @@ func TestSQLite_GetTopArtistsByPlayTime(t *testing.T) {
 
 	tests := []struct {
 		name    string
+		filter  spotify.Filter
 		mock    func(*ksqltest.MockProvider)
 		want    []spotify.ArtistStats
 		wantErr error
@@ -123,6 +120,13 @@ func TestSQLite_GetTopArtistsByPlayTime(t *testing.T) {
 				{Artist: "Artist2", PlayCount: 80, TotalPlayTime: 4000},
 			},
 		},
+		{
+			name:   "filters by usernames",
+			filter: spotify.Filter{Usernames: []string{"user1", "user2"}},
+			mock: func(m *ksqltest.MockProvider) {
+				m.EXPECT().Query(ctx, gomock.Any(), gomock.Any(), "user1", "user2").Return(nil)
+			},
+		},
 		{
 			name: "handles query error",
 			mock: func(m *ksqltest.MockProvider) {
@@ -143,7 +147,7 @@ func TestSQLite_GetTopArtistsByPlayTime(t *testing.T) {
 			tt.mock(mockProvider)
 
 			sqlite := spotify.NewSQLite(mockProvider)
-			got, err := sqlite.GetTopArtistsByPlayTime(ctx)
+			got, err := sqlite.GetTopArtistsByPlayTime(ctx, tt.filter)
 
 			if tt.wantErr != nil {
 				require.Error(t, err)
@@ -206,7 +210,7 @@ func TestSQLite_GetMostSkippedTracks(t *testing.T) {
 			tt.mock(mockProvider)
 
 			sqlite := spotify.NewSQLite(mockProvider)
-			got, err := sqlite.GetMostSkippedTracks(ctx)
+			got, err := sqlite.GetMostSkippedTracks(ctx, spotify.Filter{})
 
 			if tt.wantErr != nil {
 				require.Error(t, err)
@@ -0,0 +1,46 @@
+package spotify
+
+import (
+	"fmt"
+	"time"
+)
+
+// timestampLayouts are the layouts timestamps can be read with: Go's default
+// layout used when they were inserted, and the one of SQLite date functions.
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	time.DateTime,
+}
+
+// Timestamp scans timestamps computed by SQL expressions, e.g. MIN(ts), which
+// SQLite returns as plain text since they lose the declared column type.
+type Timestamp struct {
+	time.Time
+}
+
+func (t *Timestamp) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	case time.Time:
+		t.Time = v
+		return nil
+	case string:
+		return t.parse(v)
+	case []byte:
+		return t.parse(string(v))
+	default:
+		return fmt.Errorf("spotify: unsupported timestamp type %T", src)
+	}
+}
+
+func (t *Timestamp) parse(s string) error {
+	for _, layout := range timestampLayouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("spotify: invalid timestamp %q", s)
+}
@@ -0,0 +1,50 @@
+package youtubemusic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"strings"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/pkg/ext"
+)
+
+func init() {
+	listen.Register(Importer{})
+}
+
+// Importer reads the YouTube Music entries of a Takeout watch history as
+// listens.
+type Importer struct{}
+
+func (Importer) Format() listen.Format {
+	return listen.Format{
+		Name:             Source,
+		Source:           Source,
+		Description:      "watch-history.json of Google Takeout, exported as JSON",
+		MissingPlayTimes: true,
+	}
+}
+
+// Detect recognizes JSON files of watch history entries.
+func (Importer) Detect(path string, head []byte) bool {
+	return strings.EqualFold(filepath.Ext(path), ext.JSON) &&
+		bytes.Contains(head, []byte(`"titleUrl"`)) &&
+		bytes.Contains(head, []byte(`"time"`))
+}
+
+func (Importer) Open(_ context.Context, path string, opts listen.Options) (iter.Seq2[listen.Listen, error], error) {
+	if opts.Username == "" {
+		return nil, listen.ErrUsernameRequired
+	}
+
+	history, err := ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ReadFile: %w", err)
+	}
+
+	return listen.All(ToListens(history, opts.Username)), nil
+}
@@ -0,0 +1,205 @@
+// Package youtubemusic reads the YouTube Music entries of the watch history
+// found in Google Takeout and maps them into listens, so statistics can span
+// YouTube Music and other services.
+package youtubemusic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/pkg/ext"
+	"github.com/cadoween/decibel/pkg/iox"
+)
+
+// Source is the source recorded for streams and imports of YouTube Music
+// history.
+const Source = "youtubemusic"
+
+// FileName is the name of the watch history file in Google Takeout.
+const FileName = "watch-history.json"
+
+// MaxPlayTime is the longest time until the next entry still taken as the
+// play time of a track. Takeout doesn't record how long a video played, so
+// longer gaps most likely mean playback stopped.
+const MaxPlayTime = 10 * time.Minute
+
+var ErrUnsupportedFile = errors.New("unsupported file, expected watch-history.json, export the history as JSON in Takeout")
+
+const (
+	header   = "YouTube Music"
+	musicURL = "https://music.youtube.com/"
+	// watched is the prefix of the titles of watched videos.
+	watched = "Watched "
+)
+
+// noise matches the parenthesized or bracketed parts of video titles which
+// aren't part of the track name, e.g. "(Official Music Video)".
+var noise = regexp.MustCompile(`(?i)\s*[(\[][^)\]]*\b(official|video|audio|lyrics?|visuali[sz]er|mv|hd|4k)\b[^)\]]*[)\]]`)
+
+// Listen is a track played on YouTube Music. TS is when it started playing.
+type Listen struct {
+	TS     time.Time
+	Artist string
+	Track  string
+	URL    string
+}
+
+// entry is an entry of the watch history.
+type entry struct {
+	Header    string    `json:"header"`
+	Title     string    `json:"title"`
+	TitleURL  string    `json:"titleUrl"`
+	Time      time.Time `json:"time"`
+	Subtitles []named   `json:"subtitles"`
+	Details   []named   `json:"details"`
+}
+
+type named struct {
+	Name string `json:"name"`
+}
+
+// FindFile returns path when it is a file, or the path of the watch history
+// found anywhere under it when it is a directory, such as the unzipped
+// Takeout archive.
+func FindFile(path string) (string, error) {
+	found, err := iox.FindFile(path, FileName)
+	if err != nil {
+		return "", fmt.Errorf("iox.FindFile: %w", err)
+	}
+
+	if filepath.Ext(found) != ext.JSON {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFile, found)
+	}
+
+	return found, nil
+}
+
+// ReadFile reads the YouTube Music listens of the watch history at path.
+func ReadFile(path string) ([]Listen, error) {
+	f, err := os.Open(path) //nolint:gosec // The path is given by the user on purpose.
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer iox.Close(f)
+
+	return ReadJSON(f)
+}
+
+// ReadJSON reads the YouTube Music listens of a watch history, oldest
+// first. Videos watched on YouTube, ads and videos removed since are left
+// out.
+func ReadJSON(r io.Reader) ([]Listen, error) {
+	var entries []entry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("json.NewDecoder(r).Decode: %w", err)
+	}
+
+	listens := make([]Listen, 0, len(entries))
+	for _, e := range entries {
+		if !e.isMusic() {
+			continue
+		}
+
+		artist, track := ParseTitle(strings.TrimPrefix(e.Title, watched), e.channel())
+		if track == "" {
+			continue
+		}
+
+		listens = append(listens, Listen{
+			TS:     e.Time.UTC(),
+			Artist: artist,
+			Track:  track,
+			URL:    e.TitleURL,
+		})
+	}
+
+	slices.SortStableFunc(listens, func(a, b Listen) int {
+		return a.TS.Compare(b.TS)
+	})
+
+	return listens, nil
+}
+
+// isMusic reports whether the entry is a track played on YouTube Music,
+// which removed videos and ads aren't.
+func (e entry) isMusic() bool {
+	if e.Header != header && !strings.HasPrefix(e.TitleURL, musicURL) {
+		return false
+	}
+
+	// Removed videos have neither a link nor a channel left.
+	if e.TitleURL == "" || len(e.Subtitles) == 0 {
+		return false
+	}
+
+	return !slices.ContainsFunc(e.Details, func(detail named) bool {
+		return strings.Contains(detail.Name, "Google Ads")
+	})
+}
+
+func (e entry) channel() string {
+	if len(e.Subtitles) == 0 {
+		return ""
+	}
+	return e.Subtitles[0].Name
+}
+
+// ParseTitle extracts the artist and track from the title of a video and the
+// name of its channel. Tracks of auto-generated "Artist - Topic" channels
+// are titled after the track only, while uploads of other channels are
+// usually titled "Artist - Track". Noise such as "(Official Video)" is
+// dropped.
+func ParseTitle(title, channel string) (string, string) {
+	title = strings.TrimSpace(noise.ReplaceAllString(title, ""))
+	channel = strings.TrimSpace(channel)
+
+	if artist, ok := strings.CutSuffix(channel, " - Topic"); ok {
+		return artist, title
+	}
+
+	if artist, track, ok := strings.Cut(title, " - "); ok {
+		return strings.TrimSpace(artist), strings.TrimSpace(track)
+	}
+
+	return strings.TrimSuffix(channel, "VEVO"), title
+}
+
+// ToListens maps the listens of the watch history, oldest first, to listens
+// of username. The play time of a listen is estimated as the time until the
+// next one, up to MaxPlayTime. It is left empty otherwise, for
+// spotify.SQLite.EstimatePlayTimes to fill in. Listens are timed at the time
+// recorded by Takeout, like scrobbles, rather than at the estimated end: the
+// last listen of a Takeout has no next one, and its time must not change once
+// a later Takeout adds one, or importing it would store the listen again.
+func ToListens(history []Listen, username string) []listen.Listen {
+	listens := make([]listen.Listen, 0, len(history))
+	for i, item := range history {
+		var played time.Duration
+		if i+1 < len(history) {
+			if gap := history[i+1].TS.Sub(item.TS); gap <= MaxPlayTime {
+				played = gap
+			}
+		}
+
+		listens = append(listens, listen.Listen{
+			EndedAt:  item.TS,
+			Username: username,
+			Platform: header,
+			Played:   played,
+			Track:    item.Track,
+			Artist:   item.Artist,
+			Source:   Source,
+		})
+	}
+
+	return listens
+}
@@ -0,0 +1,150 @@
+package youtubemusic_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/internal/listen"
+	"github.com/cadoween/decibel/internal/youtubemusic"
+)
+
+func TestReadJSON(t *testing.T) {
+	t.Parallel()
+
+	input := `[
+		{
+			"header": "YouTube Music",
+			"title": "Watched Roads",
+			"titleUrl": "https://music.youtube.com/watch?v=2",
+			"subtitles": [{"name": "Portishead - Topic", "url": "https://www.youtube.com/channel/2"}],
+			"time": "2024-03-01T22:04:00.000Z",
+			"products": ["YouTube"]
+		},
+		{
+			"header": "YouTube",
+			"title": "Watched How to make bread",
+			"titleUrl": "https://www.youtube.com/watch?v=3",
+			"subtitles": [{"name": "Bakery"}],
+			"time": "2024-03-01T22:02:00.000Z"
+		},
+		{
+			"header": "YouTube Music",
+			"title": "Watched Björk - Jóga (Official Music Video)",
+			"titleUrl": "https://music.youtube.com/watch?v=1",
+			"subtitles": [{"name": "bjorkVEVO"}],
+			"time": "2024-03-01T22:00:00.000Z"
+		},
+		{
+			"header": "YouTube Music",
+			"title": "Watched a video that has been removed",
+			"time": "2024-03-01T21:00:00.000Z"
+		},
+		{
+			"header": "YouTube Music",
+			"title": "Watched Buy now",
+			"titleUrl": "https://music.youtube.com/watch?v=4",
+			"subtitles": [{"name": "Shop"}],
+			"details": [{"name": "From Google Ads"}],
+			"time": "2024-03-01T20:00:00.000Z"
+		}
+	]`
+
+	got, err := youtubemusic.ReadJSON(strings.NewReader(input))
+	require.NoError(t, err)
+
+	assert.Equal(t, []youtubemusic.Listen{
+		{
+			TS:     time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC),
+			Artist: "Björk",
+			Track:  "Jóga",
+			URL:    "https://music.youtube.com/watch?v=1",
+		},
+		{
+			TS:     time.Date(2024, 3, 1, 22, 4, 0, 0, time.UTC),
+			Artist: "Portishead",
+			Track:  "Roads",
+			URL:    "https://music.youtube.com/watch?v=2",
+		},
+	}, got)
+}
+
+func TestParseTitle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		title, channel string
+		artist, track  string
+	}{
+		{title: "Jóga", channel: "Björk - Topic", artist: "Björk", track: "Jóga"},
+		{title: "Teardrop - Remastered 2018", channel: "Massive Attack - Topic", artist: "Massive Attack", track: "Teardrop - Remastered 2018"},
+		{title: "Massive Attack - Teardrop [Lyric Video]", channel: "Lyrics World", artist: "Massive Attack", track: "Teardrop"},
+		{title: "Roads (Live) (Official HD Video)", channel: "PortisheadVEVO", artist: "Portishead", track: "Roads (Live)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			t.Parallel()
+
+			artist, track := youtubemusic.ParseTitle(tt.title, tt.channel)
+			assert.Equal(t, tt.artist, artist)
+			assert.Equal(t, tt.track, track)
+		})
+	}
+}
+
+func TestToListens(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+	got := youtubemusic.ToListens([]youtubemusic.Listen{
+		{TS: start, Artist: "Björk", Track: "Jóga"},
+		{TS: start.Add(4 * time.Minute), Artist: "Portishead", Track: "Roads"},
+		{TS: start.Add(time.Hour), Artist: "Massive Attack", Track: "Teardrop"},
+	}, "alice")
+
+	listened := func(ts time.Time, played time.Duration, artist, track string) listen.Listen {
+		return listen.Listen{
+			EndedAt:  ts,
+			Username: "alice",
+			Platform: "YouTube Music",
+			Played:   played,
+			Track:    track,
+			Artist:   artist,
+			Source:   youtubemusic.Source,
+		}
+	}
+
+	assert.Equal(t, []listen.Listen{
+		listened(start, 4*time.Minute, "Björk", "Jóga"),
+		// The next listen is too far for the play time to be estimated.
+		listened(start.Add(4*time.Minute), 0, "Portishead", "Roads"),
+		listened(start.Add(time.Hour), 0, "Massive Attack", "Teardrop"),
+	}, got)
+}
+
+func TestFindFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	history := filepath.Join(dir, "Takeout", "YouTube and YouTube Music", "history")
+	require.NoError(t, os.MkdirAll(history, 0o750))
+
+	path := filepath.Join(history, youtubemusic.FileName)
+	require.NoError(t, os.WriteFile(path, []byte("[]"), 0o600))
+
+	got, err := youtubemusic.FindFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, path, got)
+
+	html := filepath.Join(history, "watch-history.html")
+	require.NoError(t, os.WriteFile(html, nil, 0o600))
+
+	_, err = youtubemusic.FindFile(html)
+	require.ErrorIs(t, err, youtubemusic.ErrUnsupportedFile)
+}
@@ -1,5 +1,10 @@
 package ext
 
 const (
-	JSON = ".json"
+	JSON    = ".json"
+	CSV     = ".csv"
+	XSPF    = ".xspf"
+	M3U8    = ".m3u8"
+	M3U     = ".m3u"
+	Parquet = ".parquet"
 )
@@ -0,0 +1,45 @@
+package iox
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var ErrFileNotFound = errors.New("file not found")
+
+// FindFile returns path when it is a file, or the path of the first file
+// named name found anywhere under it when it is a directory, such as an
+// unzipped data export.
+func FindFile(path, name string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("os.Stat: %w", err)
+	}
+	if !info.IsDir() {
+		return path, nil
+	}
+
+	var found string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Name() == name {
+			found = p
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("filepath.WalkDir: %w", err)
+	}
+
+	if found == "" {
+		return "", fmt.Errorf("%w: %q in %s", ErrFileNotFound, name, path)
+	}
+
+	return found, nil
+}
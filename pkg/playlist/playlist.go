@@ -0,0 +1,162 @@
+// Package playlist writes lists of tracks as XSPF or M3U8 playlists, for
+// players and playlist converters to import.
+package playlist
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cadoween/decibel/pkg/ext"
+)
+
+var ErrInvalidFormat = errors.New("invalid playlist format")
+
+// Format is the way a playlist is written.
+type Format string
+
+const (
+	// FormatXSPF writes an XML Shareable Playlist, keeping tracks without a
+	// location so they can be matched by title and artist.
+	FormatXSPF Format = "xspf"
+	// FormatM3U8 writes an extended M3U playlist in UTF-8. M3U entries are
+	// locations, so tracks without one are left out.
+	FormatM3U8 Format = "m3u8"
+)
+
+const xspfNamespace = "http://xspf.org/ns/0/"
+
+// lineBreaks flattens the titles written on a single M3U line.
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// FormatFromPath returns the format of a playlist file from its extension,
+// .xspf, .m3u8 or .m3u.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ext.XSPF:
+		return FormatXSPF, nil
+	case ext.M3U8, ext.M3U:
+		return FormatM3U8, nil
+	default:
+		return "", fmt.Errorf("%w: %q, expected a .xspf, .m3u8 or .m3u file", ErrInvalidFormat, path)
+	}
+}
+
+// Playlist is a titled list of tracks.
+type Playlist struct {
+	Title  string
+	Tracks []Track
+}
+
+// Track is an entry of a playlist. Location is a URI such as a
+// spotify:track: URI, and Duration is zero when unknown.
+type Track struct {
+	Title    string
+	Artist   string
+	Album    string
+	Location string
+	Duration time.Duration
+}
+
+// Write writes the playlist to w in the given format.
+func Write(w io.Writer, format Format, playlist Playlist) error {
+	switch format {
+	case FormatXSPF:
+		return writeXSPF(w, playlist)
+	case FormatM3U8:
+		return writeM3U8(w, playlist)
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+	}
+}
+
+type xspfPlaylist struct {
+	XMLName   xml.Name      `xml:"playlist"`
+	Version   string        `xml:"version,attr"`
+	Namespace string        `xml:"xmlns,attr"`
+	Title     string        `xml:"title,omitempty"`
+	TrackList xspfTrackList `xml:"trackList"`
+}
+
+type xspfTrackList struct {
+	Tracks []xspfTrack `xml:"track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location,omitempty"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Album    string `xml:"album,omitempty"`
+	Duration int64  `xml:"duration,omitempty"`
+}
+
+func writeXSPF(w io.Writer, playlist Playlist) error {
+	doc := xspfPlaylist{
+		Version:   "1",
+		Namespace: xspfNamespace,
+		Title:     playlist.Title,
+	}
+	for _, track := range playlist.Tracks {
+		doc.TrackList.Tracks = append(doc.TrackList.Tracks, xspfTrack{
+			Location: track.Location,
+			Title:    track.Title,
+			Creator:  track.Artist,
+			Album:    track.Album,
+			Duration: track.Duration.Milliseconds(),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("io.WriteString: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("encoder.Encode: %w", err)
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("io.WriteString: %w", err)
+	}
+
+	return nil
+}
+
+func writeM3U8(w io.Writer, playlist Playlist) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "#EXTM3U")
+	if playlist.Title != "" {
+		fmt.Fprintf(bw, "#PLAYLIST:%s\n", lineBreaks.Replace(playlist.Title))
+	}
+
+	for _, track := range playlist.Tracks {
+		if track.Location == "" {
+			continue
+		}
+
+		seconds := int64(-1)
+		if track.Duration > 0 {
+			seconds = int64(track.Duration.Round(time.Second) / time.Second)
+		}
+
+		title := track.Title
+		if track.Artist != "" {
+			title = track.Artist + " - " + track.Title
+		}
+
+		fmt.Fprintf(bw, "#EXTINF:%d,%s\n%s\n", seconds, lineBreaks.Replace(title), track.Location)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("bw.Flush: %w", err)
+	}
+
+	return nil
+}
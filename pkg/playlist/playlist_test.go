@@ -0,0 +1,104 @@
+package playlist_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/pkg/playlist"
+)
+
+var tracks = playlist.Playlist{
+	Title: "Top Tracks",
+	Tracks: []playlist.Track{
+		{Title: "Jóga", Artist: "Björk", Album: "Homogenic", Location: "spotify:track:abc", Duration: 305400 * time.Millisecond},
+		{Title: "Roads & Rain", Artist: "Portishead"},
+		{Title: "Teardrop\nLive", Artist: "Massive Attack", Location: "spotify:track:def"},
+	},
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		format playlist.Format
+		want   string
+	}{
+		{
+			name:   "xspf",
+			format: playlist.FormatXSPF,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<playlist version="1" xmlns="http://xspf.org/ns/0/">
+  <title>Top Tracks</title>
+  <trackList>
+    <track>
+      <location>spotify:track:abc</location>
+      <title>Jóga</title>
+      <creator>Björk</creator>
+      <album>Homogenic</album>
+      <duration>305400</duration>
+    </track>
+    <track>
+      <title>Roads &amp; Rain</title>
+      <creator>Portishead</creator>
+    </track>
+    <track>
+      <location>spotify:track:def</location>
+      <title>Teardrop&#xA;Live</title>
+      <creator>Massive Attack</creator>
+    </track>
+  </trackList>
+</playlist>
+`,
+		},
+		{
+			name:   "m3u8",
+			format: playlist.FormatM3U8,
+			want: `#EXTM3U
+#PLAYLIST:Top Tracks
+#EXTINF:305,Björk - Jóga
+spotify:track:abc
+#EXTINF:-1,Massive Attack - Teardrop Live
+spotify:track:def
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			require.NoError(t, playlist.Write(&sb, tt.format, tracks))
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+
+	require.ErrorIs(t, playlist.Write(&strings.Builder{}, "pls", tracks), playlist.ErrInvalidFormat)
+}
+
+func TestFormatFromPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path    string
+		want    playlist.Format
+		wantErr error
+	}{
+		{path: "top.xspf", want: playlist.FormatXSPF},
+		{path: "out/Top.M3U8", want: playlist.FormatM3U8},
+		{path: "top.m3u", want: playlist.FormatM3U8},
+		{path: "top.pls", wantErr: playlist.ErrInvalidFormat},
+		{path: "top", wantErr: playlist.ErrInvalidFormat},
+	}
+
+	for _, tt := range tests {
+		got, err := playlist.FormatFromPath(tt.path)
+		require.ErrorIs(t, err, tt.wantErr, tt.path)
+		assert.Equal(t, tt.want, got, tt.path)
+	}
+}
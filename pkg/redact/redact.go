@@ -0,0 +1,161 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+var (
+	ErrInvalidMode  = errors.New("invalid redaction mode")
+	ErrSaltRequired = errors.New("a salt is required to hash values")
+)
+
+// hashLength is the length of hashed values, in hexadecimal digits.
+const hashLength = 32
+
+// Mode is the way a sensitive value is redacted.
+type Mode string
+
+const (
+	// ModeKeep leaves the value untouched.
+	ModeKeep Mode = "keep"
+	// ModeDrop replaces the value with an empty string.
+	ModeDrop Mode = "drop"
+	// ModeTruncate keeps the coarse part of the value only: the /24 network
+	// of IPv4 addresses (/48 for IPv6), or the user agent without its
+	// parenthesized device details.
+	ModeTruncate Mode = "truncate"
+	// ModeHash replaces the value with a salted hash, so equal values can
+	// still be told apart from different ones.
+	ModeHash Mode = "hash"
+)
+
+// ParseMode parses the name of a redaction mode, case insensitively.
+func ParseMode(s string) (Mode, error) {
+	mode := Mode(strings.ToLower(strings.TrimSpace(s)))
+	switch mode {
+	case ModeKeep, ModeDrop, ModeTruncate, ModeHash:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidMode, s)
+	}
+}
+
+// Redactor redacts IP addresses and user agents according to their mode.
+type Redactor struct {
+	IPMode        Mode
+	UserAgentMode Mode
+	Salt          string
+}
+
+// Validate checks that the redactor can be used, hashing without a salt is
+// rejected since IP addresses are trivial to brute force.
+func (r Redactor) Validate() error {
+	if (r.IPMode == ModeHash || r.UserAgentMode == ModeHash) && r.Salt == "" {
+		return ErrSaltRequired
+	}
+	return nil
+}
+
+// Enabled reports whether the redactor changes any value.
+func (r Redactor) Enabled() bool {
+	return !isKeep(r.IPMode) || !isKeep(r.UserAgentMode)
+}
+
+// IP redacts an IP address. Values that can't be parsed as an IP address are
+// dropped when truncating since there is no safe way to shorten them, except
+// networks, which are left as they are so truncating twice changes nothing.
+func (r Redactor) IP(ip string) string {
+	if ip == "" || isKeep(r.IPMode) {
+		return ip
+	}
+
+	switch r.IPMode {
+	case ModeDrop:
+		return ""
+	case ModeTruncate:
+		if _, err := netip.ParsePrefix(ip); err == nil {
+			return ip
+		}
+
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return ""
+		}
+
+		bits := 24
+		if addr.Is6() && !addr.Is4In6() {
+			bits = 48
+		}
+
+		prefix, err := addr.Unmap().Prefix(bits)
+		if err != nil {
+			return ""
+		}
+		return prefix.String()
+	case ModeHash:
+		return r.hash(ip)
+	case ModeKeep:
+		return ip
+	default:
+		return ip
+	}
+}
+
+// UserAgent redacts a user agent.
+func (r Redactor) UserAgent(userAgent string) string {
+	if userAgent == "" || isKeep(r.UserAgentMode) {
+		return userAgent
+	}
+
+	switch r.UserAgentMode {
+	case ModeDrop:
+		return ""
+	case ModeTruncate:
+		before, _, _ := strings.Cut(userAgent, "(")
+		return strings.TrimSpace(before)
+	case ModeHash:
+		return r.hash(userAgent)
+	case ModeKeep:
+		return userAgent
+	default:
+		return userAgent
+	}
+}
+
+// hash returns the salted hash of value, or value itself when it already is
+// such a hash, so hashing twice changes nothing.
+func (r Redactor) hash(value string) string {
+	if isHash(value) {
+		return value
+	}
+
+	mac := hmac.New(sha256.New, []byte(r.Salt))
+	_, _ = mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:hashLength/2])
+}
+
+// isHash reports whether value has the shape of the hashes written by hash,
+// 32 lowercase hexadecimal digits. Neither IP addresses nor user agents look
+// like that.
+func isHash(value string) bool {
+	if len(value) != hashLength {
+		return false
+	}
+
+	for _, c := range value {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isKeep(mode Mode) bool {
+	return mode == "" || mode == ModeKeep
+}
@@ -0,0 +1,84 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/pkg/redact"
+)
+
+func TestRedactor_IP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		redactor redact.Redactor
+		ip       string
+		want     string
+	}{
+		{name: "keep", redactor: redact.Redactor{IPMode: redact.ModeKeep}, ip: "192.168.1.42", want: "192.168.1.42"},
+		{name: "zero value keeps", redactor: redact.Redactor{}, ip: "192.168.1.42", want: "192.168.1.42"},
+		{name: "drop", redactor: redact.Redactor{IPMode: redact.ModeDrop}, ip: "192.168.1.42", want: ""},
+		{name: "truncate ipv4", redactor: redact.Redactor{IPMode: redact.ModeTruncate}, ip: "192.168.1.42", want: "192.168.1.0/24"},
+		{name: "truncate ipv6", redactor: redact.Redactor{IPMode: redact.ModeTruncate}, ip: "2001:db8:abcd:12::1", want: "2001:db8:abcd::/48"},
+		{name: "truncate mapped ipv4", redactor: redact.Redactor{IPMode: redact.ModeTruncate}, ip: "::ffff:10.1.2.3", want: "10.1.2.0/24"},
+		{name: "truncate invalid", redactor: redact.Redactor{IPMode: redact.ModeTruncate}, ip: "not an ip", want: ""},
+		{name: "truncate network", redactor: redact.Redactor{IPMode: redact.ModeTruncate}, ip: "192.168.1.0/24", want: "192.168.1.0/24"},
+		{name: "empty stays empty", redactor: redact.Redactor{IPMode: redact.ModeHash, Salt: "s"}, ip: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.redactor.IP(tt.ip))
+		})
+	}
+}
+
+func TestRedactor_Hash(t *testing.T) {
+	t.Parallel()
+
+	redactor := redact.Redactor{IPMode: redact.ModeHash, UserAgentMode: redact.ModeHash, Salt: "pepper"}
+
+	hashed := redactor.IP("192.168.1.42")
+	assert.Len(t, hashed, 32)
+	assert.Equal(t, hashed, redactor.IP("192.168.1.42"))
+	assert.NotEqual(t, hashed, redactor.IP("192.168.1.43"))
+
+	salted := redact.Redactor{IPMode: redact.ModeHash, Salt: "salt"}
+	assert.NotEqual(t, hashed, salted.IP("192.168.1.42"))
+
+	// Hashes aren't hashed again.
+	assert.Equal(t, hashed, redactor.IP(hashed))
+	assert.Equal(t, hashed, salted.IP(hashed))
+}
+
+func TestRedactor_UserAgent(t *testing.T) {
+	t.Parallel()
+
+	redactor := redact.Redactor{UserAgentMode: redact.ModeTruncate}
+	assert.Equal(t, "windows 10", redactor.UserAgent("windows 10 (10.0.19045; x64)"))
+	assert.Equal(t, "Spotify/8.8.0 Android/33", redactor.UserAgent("Spotify/8.8.0 Android/33 (SM-S911B)"))
+}
+
+func TestRedactor_Validate(t *testing.T) {
+	t.Parallel()
+
+	require.ErrorIs(t, redact.Redactor{IPMode: redact.ModeHash}.Validate(), redact.ErrSaltRequired)
+	require.NoError(t, redact.Redactor{IPMode: redact.ModeHash, Salt: "s"}.Validate())
+	require.NoError(t, redact.Redactor{IPMode: redact.ModeTruncate}.Validate())
+}
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	mode, err := redact.ParseMode(" Hash ")
+	require.NoError(t, err)
+	assert.Equal(t, redact.ModeHash, mode)
+
+	_, err = redact.ParseMode("scramble")
+	require.ErrorIs(t, err, redact.ErrInvalidMode)
+}
@@ -0,0 +1,224 @@
+// Package tabular writes rows of arbitrary values as an aligned text table,
+// CSV or JSON.
+package tabular
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+var ErrInvalidFormat = errors.New("invalid output format")
+
+// Format is the way a table is written.
+type Format string
+
+const (
+	// FormatTable aligns the columns under a header, like the stats commands.
+	FormatTable Format = "table"
+	// FormatCSV writes a header row followed by the rows as CSV.
+	FormatCSV Format = "csv"
+	// FormatJSON writes an array with an object per row, keyed by column.
+	FormatJSON Format = "json"
+)
+
+// MaxWidth is the width in cells after which table cells are truncated.
+const MaxWidth = 40
+
+// timeLayout is the layout of timestamps in table and CSV output.
+const timeLayout = "2006-01-02 15:04:05"
+
+// lineBreaks flattens multi-line values so they fit on a table row.
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// ParseFormat parses a format name, case insensitively.
+func ParseFormat(s string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(s)))
+	switch format {
+	case FormatTable, FormatCSV, FormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+	}
+}
+
+// Table is a list of rows with named columns. Values are the ones returned
+// by database/sql when scanning into an any: nil, int64, float64, bool,
+// []byte, string or time.Time.
+type Table struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// Write writes the table to w in the given format.
+func Write(w io.Writer, format Format, table Table) error {
+	switch format {
+	case FormatTable:
+		return writeText(w, table)
+	case FormatCSV:
+		return writeCSV(w, table)
+	case FormatJSON:
+		return writeJSON(w, table)
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+	}
+}
+
+func writeText(w io.Writer, table Table) error {
+	widths := make([]int, len(table.Columns))
+	cells := make([][]string, len(table.Rows))
+
+	for i, column := range table.Columns {
+		widths[i] = runewidth.StringWidth(column)
+	}
+
+	for i, row := range table.Rows {
+		cells[i] = make([]string, len(row))
+		for j, value := range row {
+			cell := runewidth.Truncate(lineBreaks.Replace(formatValue(value, "NULL")), MaxWidth, "...")
+			cells[i][j] = cell
+			if j < len(widths) {
+				widths[j] = max(widths[j], runewidth.StringWidth(cell))
+			}
+		}
+	}
+
+	var sb strings.Builder
+	writeLine := func(values []string) {
+		for i, value := range values {
+			if i > 0 {
+				sb.WriteString(" ")
+			}
+			if i == len(values)-1 {
+				sb.WriteString(value)
+				continue
+			}
+			sb.WriteString(runewidth.FillRight(value, widths[i]))
+		}
+		sb.WriteString("\n")
+	}
+
+	total := max(len(widths)-1, 0)
+	for _, width := range widths {
+		total += width
+	}
+
+	writeLine(table.Columns)
+	sb.WriteString(strings.Repeat("-", total) + "\n")
+	for _, row := range cells {
+		writeLine(row)
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("io.WriteString: %w", err)
+	}
+
+	return nil
+}
+
+func writeCSV(w io.Writer, table Table) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(table.Columns); err != nil {
+		return fmt.Errorf("cw.Write: %w", err)
+	}
+
+	for _, row := range table.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatValue(value, "")
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("cw.Write: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("cw.Error: %w", err)
+	}
+
+	return nil
+}
+
+// writeJSON writes the rows as objects keeping the order of the columns,
+// which a map would lose.
+func writeJSON(w io.Writer, table Table) error {
+	var sb strings.Builder
+	sb.WriteString("[")
+
+	for i, row := range table.Rows {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n  {")
+
+		for j, value := range row {
+			if j >= len(table.Columns) {
+				break
+			}
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+
+			key, err := json.Marshal(table.Columns[j])
+			if err != nil {
+				return fmt.Errorf("json.Marshal: %w", err)
+			}
+
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("json.Marshal: %w", err)
+			}
+
+			sb.Write(key)
+			sb.WriteString(": ")
+			sb.Write(encoded)
+		}
+
+		sb.WriteString("}")
+	}
+
+	if len(table.Rows) > 0 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("]\n")
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("io.WriteString: %w", err)
+	}
+
+	return nil
+}
+
+// formatValue formats a single value as text, writing null for nil values.
+func formatValue(value any, null string) string {
+	switch v := value.(type) {
+	case nil:
+		return null
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.UTC().Format(timeLayout)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,98 @@
+package tabular_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/pkg/tabular"
+)
+
+var table = tabular.Table{
+	Columns: []string{"artist", "plays", "share", "first", "skipped"},
+	Rows: [][]any{
+		{"Björk", int64(12), 0.25, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), true},
+		{[]byte("Portishead"), int64(3), nil, nil, false},
+	},
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		format tabular.Format
+		want   string
+	}{
+		{
+			name:   "table",
+			format: tabular.FormatTable,
+			want: `artist     plays share first               skipped
+--------------------------------------------------
+Björk      12    0.25  2024-01-02 03:04:05 true
+Portishead 3     NULL  NULL                false
+`,
+		},
+		{
+			name:   "csv",
+			format: tabular.FormatCSV,
+			want: `artist,plays,share,first,skipped
+Björk,12,0.25,2024-01-02 03:04:05,true
+Portishead,3,,,false
+`,
+		},
+		{
+			name:   "json",
+			format: tabular.FormatJSON,
+			want: `[
+  {"artist": "Björk", "plays": 12, "share": 0.25, "first": "2024-01-02T03:04:05Z", "skipped": true},
+  {"artist": "Portishead", "plays": 3, "share": null, "first": null, "skipped": false}
+]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			require.NoError(t, tabular.Write(&sb, tt.format, table))
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+}
+
+func TestWrite_Empty(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	require.NoError(t, tabular.Write(&sb, tabular.FormatJSON, tabular.Table{Columns: []string{"a"}}))
+	assert.Equal(t, "[]\n", sb.String())
+}
+
+func TestWrite_TruncatesLongCells(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	long := tabular.Table{Columns: []string{"track"}, Rows: [][]any{{strings.Repeat("a", 50) + "\nb"}}}
+	require.NoError(t, tabular.Write(&sb, tabular.FormatTable, long))
+
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, strings.Repeat("a", tabular.MaxWidth-3)+"...", lines[2])
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	format, err := tabular.ParseFormat(" JSON ")
+	require.NoError(t, err)
+	assert.Equal(t, tabular.FormatJSON, format)
+
+	_, err = tabular.ParseFormat("xml")
+	require.ErrorIs(t, err, tabular.ErrInvalidFormat)
+}
@@ -0,0 +1,107 @@
+// Package termchart draws small charts with Unicode block characters for
+// terminal output.
+package termchart
+
+import (
+	"math"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// barBlocks are the partial blocks used for the last cell of a bar, from one
+// eighth to a full cell.
+var barBlocks = []rune("▏▎▍▌▋▊▉█")
+
+// sparkBlocks are the blocks of a sparkline, from lowest to highest.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Bar draws value as a horizontal bar relative to maxValue, padded with spaces
+// to width cells. Each cell is split in eighths so close values still get
+// bars of different lengths.
+func Bar(value, maxValue float64, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	eighths := 0
+	if maxValue > 0 && value > 0 {
+		eighths = int(math.Round(math.Min(value/maxValue, 1) * float64(width*8)))
+		// Non zero values always get a sliver of a bar.
+		eighths = max(eighths, 1)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat(string(barBlocks[7]), eighths/8))
+
+	cells := eighths / 8
+	if rest := eighths % 8; rest > 0 {
+		sb.WriteRune(barBlocks[rest-1])
+		cells++
+	}
+	sb.WriteString(strings.Repeat(" ", width-cells))
+
+	return sb.String()
+}
+
+// Sparkline draws values as a line of blocks scaled between zero and the
+// highest value, one block per value.
+func Sparkline(values []float64) string {
+	maxValue := 0.0
+	for _, value := range values {
+		maxValue = math.Max(maxValue, value)
+	}
+
+	var sb strings.Builder
+	for _, value := range values {
+		if maxValue <= 0 || value <= 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		level := int(math.Round(value / maxValue * float64(len(sparkBlocks)-1)))
+		sb.WriteRune(sparkBlocks[level])
+	}
+
+	return sb.String()
+}
+
+// Palette colors text with ANSI escape codes when enabled, and leaves it as is
+// otherwise.
+type Palette struct {
+	enabled bool
+}
+
+// NewPalette enables colors when f is a terminal, unless they are turned off
+// with the NO_COLOR environment variable or a dumb terminal.
+func NewPalette(f *os.File) Palette {
+	enabled := isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+		enabled = false
+	}
+
+	return Palette{enabled: enabled}
+}
+
+func (p Palette) Bold(s string) string {
+	return p.wrap("1", s)
+}
+
+func (p Palette) Green(s string) string {
+	return p.wrap("32", s)
+}
+
+func (p Palette) Red(s string) string {
+	return p.wrap("31", s)
+}
+
+func (p Palette) Cyan(s string) string {
+	return p.wrap("36", s)
+}
+
+func (p Palette) wrap(code, s string) string {
+	if !p.enabled || s == "" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
@@ -0,0 +1,66 @@
+package termchart_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cadoween/decibel/pkg/termchart"
+)
+
+func TestBar(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		value float64
+		max   float64
+		width int
+		want  string
+	}{
+		{name: "full bar", value: 10, max: 10, width: 4, want: "████"},
+		{name: "half bar", value: 5, max: 10, width: 4, want: "██  "},
+		{name: "partial cell", value: 10, max: 16, width: 2, want: "█▎"},
+		{name: "tiny value keeps a sliver", value: 0.001, max: 10, width: 3, want: "▏  "},
+		{name: "zero value", value: 0, max: 10, width: 3, want: "   "},
+		{name: "zero max", value: 3, max: 0, width: 2, want: "  "},
+		{name: "value above max is capped", value: 20, max: 10, width: 2, want: "██"},
+		{name: "no width", value: 5, max: 10, width: 0, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := termchart.Bar(tt.value, tt.max, tt.width)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.width, utf8.RuneCountInString(got))
+		})
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "▁▅█ ", termchart.Sparkline([]float64{0.1, 4, 8, 0}))
+	assert.Equal(t, "   ", termchart.Sparkline([]float64{0, 0, 0}))
+	assert.Empty(t, termchart.Sparkline(nil))
+}
+
+func TestPalette(t *testing.T) {
+	t.Parallel()
+
+	// Files other than terminals, such as output piped to another command,
+	// aren't colored.
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	palette := termchart.NewPalette(f)
+	assert.Equal(t, "ok", palette.Green("ok"))
+	assert.Empty(t, palette.Bold(""))
+}
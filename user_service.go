@@ -1,5 +1,103 @@
 package decibel
 
-type UserService struct{
-	testshaha string
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vingarcia/ksql"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrInvalidProfile  = errors.New("invalid profile")
+)
+
+var profilesTable = ksql.NewTable("profiles", "name")
+
+// Profile is a named alias for the account username found in the exports,
+// so a household can share a database and tell its members apart.
+type Profile struct {
+	CreatedAt time.Time `ksql:"created_at" json:"created_at"`
+	Name      string    `ksql:"name" json:"name"`
+	Username  string    `ksql:"username" json:"username"`
+}
+
+// UserService manages the profiles stored in the database. It expects the
+// database schema to be already migrated.
+type UserService struct {
+	sqlProvider ksql.Provider
+}
+
+func NewUserService(sqlProvider ksql.Provider) *UserService {
+	return &UserService{
+		sqlProvider: sqlProvider,
+	}
+}
+
+func (s *UserService) AddProfile(ctx context.Context, name, username string) (Profile, error) {
+	if name == "" || username == "" {
+		return Profile{}, fmt.Errorf("%w: name and username are required", ErrInvalidProfile)
+	}
+
+	profile := Profile{
+		CreatedAt: time.Now().UTC(),
+		Name:      name,
+		Username:  username,
+	}
+
+	if err := s.sqlProvider.Insert(ctx, profilesTable, &profile); err != nil {
+		return Profile{}, fmt.Errorf("s.sqlProvider.Insert: %w", err)
+	}
+
+	return profile, nil
+}
+
+func (s *UserService) ListProfiles(ctx context.Context) ([]Profile, error) {
+	var profiles []Profile
+	if err := s.sqlProvider.Query(ctx, &profiles, "FROM profiles ORDER BY name ASC"); err != nil {
+		return nil, fmt.Errorf("s.sqlProvider.Query: %w", err)
+	}
+
+	return profiles, nil
+}
+
+func (s *UserService) RemoveProfile(ctx context.Context, name string) error {
+	if err := s.sqlProvider.Delete(ctx, profilesTable, name); err != nil {
+		if errors.Is(err, ksql.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+		}
+		return fmt.Errorf("s.sqlProvider.Delete: %w", err)
+	}
+
+	return nil
+}
+
+// ResolveUsernames maps profile names to their usernames. Names that don't
+// match any profile are kept as is, so raw usernames can be used as well.
+func (s *UserService) ResolveUsernames(ctx context.Context, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	profiles, err := s.ListProfiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.ListProfiles: %w", err)
+	}
+
+	usernames := make(map[string]string, len(profiles))
+	for _, profile := range profiles {
+		usernames[profile.Name] = profile.Username
+	}
+
+	resolved := make([]string, 0, len(names))
+	for _, name := range names {
+		if username, ok := usernames[name]; ok {
+			name = username
+		}
+		resolved = append(resolved, name)
+	}
+
+	return resolved, nil
 }
@@ -0,0 +1,101 @@
+package decibel_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cadoween/decibel"
+	"github.com/cadoween/decibel/internal/spotify/ksqltest"
+)
+
+func TestUserService_ResolveUsernames(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	tests := []struct {
+		name    string
+		names   []string
+		mock    func(*ksqltest.MockProvider)
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "resolves profiles and keeps raw usernames",
+			names: []string{"alice", "31xyz"},
+			mock: func(m *ksqltest.MockProvider) {
+				m.EXPECT().Query(ctx, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, records any, _ string, _ ...any) error {
+						*(records.(*[]decibel.Profile)) = []decibel.Profile{
+							{Name: "alice", Username: "31abc"},
+							{Name: "bob", Username: "31def"},
+						}
+						return nil
+					})
+			},
+			want: []string{"31abc", "31xyz"},
+		},
+		{
+			name:  "no names means no filter",
+			names: nil,
+			mock:  func(*ksqltest.MockProvider) {},
+			want:  nil,
+		},
+		{
+			name:  "handles query error",
+			names: []string{"alice"},
+			mock: func(m *ksqltest.MockProvider) {
+				m.EXPECT().Query(ctx, gomock.Any(), gomock.Any()).Return(assert.AnError)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockProvider := ksqltest.NewMockProvider(ctrl)
+			tt.mock(mockProvider)
+
+			userService := decibel.NewUserService(mockProvider)
+			got, err := userService.ResolveUsernames(ctx, tt.names)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUserService_AddProfile(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := ksqltest.NewMockProvider(ctrl)
+	userService := decibel.NewUserService(mockProvider)
+
+	_, err := userService.AddProfile(context.TODO(), "", "31abc")
+	require.ErrorIs(t, err, decibel.ErrInvalidProfile)
+
+	mockProvider.EXPECT().Insert(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	profile, err := userService.AddProfile(context.TODO(), "alice", "31abc")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", profile.Name)
+	assert.Equal(t, "31abc", profile.Username)
+	assert.False(t, profile.CreatedAt.IsZero())
+}